@@ -0,0 +1,272 @@
+package layered
+
+import (
+	"fmt"
+	"sort"
+)
+
+// sweeps is the number of times the barycentre heuristic passes down and back
+// up through the layers looking for an ordering with fewer crossings
+const sweeps = 12
+
+// Graph ranks nodes into layers so that every edge points downwards, in the
+// style of Sugiyama. Edges that go back up are reversed to break cycles, each
+// node is put in the lowest layer that its longest incoming path reaches, and
+// the nodes in each layer are ordered to reduce the number of edges that
+// cross between layers.
+type Graph struct {
+	nodes []string
+	known map[string]bool
+	edges [][2]string
+}
+
+func NewGraph() *Graph {
+	return &Graph{
+		nodes: []string{},
+		known: make(map[string]bool),
+		edges: [][2]string{},
+	}
+}
+
+// AddNode adds a node to the graph. Nodes keep the order they were added in
+// until there is a reason to move them.
+func (g *Graph) AddNode(id string) {
+	if !g.known[id] {
+		g.known[id] = true
+		g.nodes = append(g.nodes, id)
+	}
+}
+
+// AddEdge adds a directed edge, adding either node if it isn't already there
+func (g *Graph) AddEdge(from, to string) {
+	g.AddNode(from)
+	g.AddNode(to)
+	g.edges = append(g.edges, [2]string{from, to})
+}
+
+// RankNodes returns the nodes in each layer, from top to bottom. Edges that
+// pass through a layer without stopping at a node leave an empty string in
+// that layer so that there is space for them to be routed.
+func (g *Graph) RankNodes() [][]string {
+	edges := g.acyclicEdges()
+	rank := g.longestPathRanks(edges)
+
+	// Split long edges with dummy nodes so that every edge only joins
+	// adjacent layers
+	layers := make([][]string, 0)
+	place := func(id string, r int) {
+		for len(layers) <= r {
+			layers = append(layers, []string{})
+		}
+		layers[r] = append(layers[r], id)
+	}
+	for _, n := range g.nodes {
+		place(n, rank[n])
+	}
+
+	dummies := map[string]bool{}
+	down := map[string][]string{}
+	up := map[string][]string{}
+	link := func(from, to string) {
+		down[from] = append(down[from], to)
+		up[to] = append(up[to], from)
+	}
+	for i, e := range edges {
+		from := e[0]
+		for r := rank[e[0]] + 1; r < rank[e[1]]; r++ {
+			dummy := dummyID(i, r)
+			dummies[dummy] = true
+			place(dummy, r)
+			link(from, dummy)
+			from = dummy
+		}
+		link(from, e[1])
+	}
+
+	layers = reduceCrossings(layers, down, up)
+
+	for _, layer := range layers {
+		for i, id := range layer {
+			if dummies[id] {
+				layer[i] = ""
+			}
+		}
+	}
+
+	return layers
+}
+
+// dummyID names the place where an edge passes through a layer. The
+// leading NUL keeps it from clashing with a real node.
+func dummyID(edge, rank int) string {
+	return fmt.Sprintf("\x00%d-%d", edge, rank)
+}
+
+// acyclicEdges returns the edges with any that would close a cycle
+// reversed. Self loops are dropped as they don't affect the layers.
+func (g *Graph) acyclicEdges() [][2]string {
+	out := map[string][]int{}
+	for i, e := range g.edges {
+		out[e[0]] = append(out[e[0]], i)
+	}
+
+	reversed := make([]bool, len(g.edges))
+	visited := map[string]bool{}
+	onStack := map[string]bool{}
+
+	var visit func(node string)
+	visit = func(node string) {
+		visited[node] = true
+		onStack[node] = true
+		for _, i := range out[node] {
+			to := g.edges[i][1]
+			if onStack[to] {
+				reversed[i] = true
+			} else if !visited[to] {
+				visit(to)
+			}
+		}
+		onStack[node] = false
+	}
+
+	for _, n := range g.nodes {
+		if !visited[n] {
+			visit(n)
+		}
+	}
+
+	edges := [][2]string{}
+	for i, e := range g.edges {
+		switch {
+		case e[0] == e[1]:
+			continue
+		case reversed[i]:
+			edges = append(edges, [2]string{e[1], e[0]})
+		default:
+			edges = append(edges, e)
+		}
+	}
+	return edges
+}
+
+// longestPathRanks puts each node in the layer after the lowest of the nodes
+// that point to it, so nodes without any incoming edges are at the top
+func (g *Graph) longestPathRanks(edges [][2]string) map[string]int {
+	incoming := map[string]int{}
+	out := map[string][]string{}
+	for _, e := range edges {
+		incoming[e[1]]++
+		out[e[0]] = append(out[e[0]], e[1])
+	}
+
+	rank := map[string]int{}
+	queue := []string{}
+	for _, n := range g.nodes {
+		if incoming[n] == 0 {
+			queue = append(queue, n)
+		}
+	}
+
+	for len(queue) > 0 {
+		n := queue[0]
+		queue = queue[1:]
+		for _, to := range out[n] {
+			rank[to] = max(rank[to], rank[n]+1)
+			incoming[to]--
+			if incoming[to] == 0 {
+				queue = append(queue, to)
+			}
+		}
+	}
+
+	return rank
+}
+
+// reduceCrossings sweeps down and up through the layers, sorting each layer
+// by the average position of its neighbours in the layer before it. The
+// ordering with the fewest crossings is kept.
+func reduceCrossings(layers [][]string, down, up map[string][]string) [][]string {
+	best := copyLayers(layers)
+	bestCrossings := countCrossings(layers, down)
+
+	for i := 0; i < sweeps && bestCrossings > 0; i++ {
+		for r := 1; r < len(layers); r++ {
+			sortByBarycentre(layers[r], layers[r-1], up)
+		}
+		for r := len(layers) - 2; r >= 0; r-- {
+			sortByBarycentre(layers[r], layers[r+1], down)
+		}
+
+		if c := countCrossings(layers, down); c < bestCrossings {
+			best = copyLayers(layers)
+			bestCrossings = c
+		}
+	}
+
+	return best
+}
+
+// sortByBarycentre orders the layer by the average position of each node's
+// neighbours in the fixed layer. Nodes without any neighbours there keep
+// their current position.
+func sortByBarycentre(layer, fixed []string, neighbours map[string][]string) {
+	position := map[string]int{}
+	for i, id := range fixed {
+		position[id] = i
+	}
+
+	barycentre := map[string]float64{}
+	for i, id := range layer {
+		barycentre[id] = float64(i)
+		if len(neighbours[id]) == 0 {
+			continue
+		}
+		total := 0
+		for _, n := range neighbours[id] {
+			total += position[n]
+		}
+		barycentre[id] = float64(total) / float64(len(neighbours[id]))
+	}
+
+	sort.SliceStable(layer, func(i, j int) bool {
+		return barycentre[layer[i]] < barycentre[layer[j]]
+	})
+}
+
+// countCrossings counts the pairs of edges that cross between each pair of
+// adjacent layers
+func countCrossings(layers [][]string, down map[string][]string) int {
+	crossings := 0
+	for r := 0; r < len(layers)-1; r++ {
+		below := map[string]int{}
+		for i, id := range layers[r+1] {
+			below[id] = i
+		}
+
+		type edge struct{ from, to int }
+		edges := []edge{}
+		for i, id := range layers[r] {
+			for _, to := range down[id] {
+				edges = append(edges, edge{from: i, to: below[to]})
+			}
+		}
+
+		for i := range edges {
+			for j := i + 1; j < len(edges); j++ {
+				a, b := edges[i], edges[j]
+				if (a.from < b.from && a.to > b.to) || (a.from > b.from && a.to < b.to) {
+					crossings++
+				}
+			}
+		}
+	}
+	return crossings
+}
+
+func copyLayers(layers [][]string) [][]string {
+	c := make([][]string, len(layers))
+	for i, l := range layers {
+		c[i] = append([]string{}, l...)
+	}
+	return c
+}
@@ -0,0 +1,85 @@
+package layered
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLayered_RanksByLongestPath(t *testing.T) {
+	g := NewGraph()
+
+	g.AddEdge("A", "B")
+	g.AddEdge("B", "C")
+	g.AddEdge("A", "D")
+	g.AddEdge("D", "C")
+	g.AddEdge("C", "E")
+
+	assert.Equal(t, [][]string{{"A"}, {"B", "D"}, {"C"}, {"E"}}, g.RankNodes())
+}
+
+func TestLayered_BreaksCycles(t *testing.T) {
+	g := NewGraph()
+
+	g.AddEdge("A", "B")
+	g.AddEdge("B", "C")
+	g.AddEdge("C", "A")
+	g.AddEdge("C", "C")
+
+	layers := g.RankNodes()
+
+	assert.Len(t, layers, 3)
+	assert.Equal(t, []string{"A"}, layers[0])
+	assert.ElementsMatch(t, []string{"B", ""}, layers[1], "C to A is reversed and passes B")
+	assert.Equal(t, []string{"C"}, layers[2])
+}
+
+func TestLayered_KeepsNodesWithoutEdges(t *testing.T) {
+	g := NewGraph()
+
+	g.AddNode("A")
+	g.AddEdge("B", "C")
+	g.AddNode("D")
+
+	assert.Equal(t, [][]string{{"A", "B", "D"}, {"C"}}, g.RankNodes())
+}
+
+func TestLayered_LeavesSpaceForLongEdges(t *testing.T) {
+	g := NewGraph()
+
+	g.AddEdge("A", "B")
+	g.AddEdge("B", "C")
+	g.AddEdge("A", "C")
+
+	layers := g.RankNodes()
+
+	assert.Len(t, layers, 3)
+	assert.Equal(t, []string{"A"}, layers[0])
+	assert.ElementsMatch(t, []string{"B", ""}, layers[1])
+	assert.Equal(t, []string{"C"}, layers[2])
+}
+
+func TestLayered_ReducesCrossings(t *testing.T) {
+	g := NewGraph()
+
+	g.AddNode("A")
+	g.AddNode("B")
+	g.AddNode("C")
+	g.AddNode("D")
+	g.AddEdge("A", "D")
+	g.AddEdge("B", "C")
+
+	layers := g.RankNodes()
+
+	assert.Equal(t, [][]string{{"A", "B"}, {"D", "C"}}, layers)
+}
+
+func TestCountCrossings(t *testing.T) {
+	down := map[string][]string{
+		"A": {"D"},
+		"B": {"C"},
+	}
+
+	assert.Equal(t, 1, countCrossings([][]string{{"A", "B"}, {"C", "D"}}, down))
+	assert.Equal(t, 0, countCrossings([][]string{{"A", "B"}, {"D", "C"}}, down))
+}
@@ -0,0 +1,336 @@
+package config
+
+import (
+	"fmt"
+	"html"
+	"regexp"
+	"strings"
+
+	"github.com/dnnrly/layli/internal/domain"
+	"github.com/dnnrly/layli/internal/usecases"
+)
+
+// DOTParser reads Graphviz DOT files so that existing diagrams can be drawn
+// by layli. The graph is turned in to the same config as a layli file, so
+// it has the same defaults and is checked in the same way.
+type DOTParser struct {
+	reader usecases.FileReader
+}
+
+func NewDOTParser(reader usecases.FileReader) *DOTParser {
+	return &DOTParser{reader: reader}
+}
+
+func (p *DOTParser) Parse(path string) (*domain.Diagram, error) {
+	data, err := p.reader.Read(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading config file: %w", err)
+	}
+
+	graph, err := parseDOT(string(data))
+	if err != nil {
+		return nil, fmt.Errorf("reading DOT file: %w", err)
+	}
+
+	cfg := graph.toConfig()
+	applyDefaults(cfg)
+
+	if err := validate(cfg); err != nil {
+		return nil, err
+	}
+
+	return toDomain(cfg), nil
+}
+
+// toConfig turns the graph in to a layli config. Graphviz lays graphs out in
+// ranks and sizes nodes to fit their labels, so the layered layout and auto
+// sized nodes are used to get a diagram that looks the same.
+func (g *dotGraph) toConfig() *configFile {
+	cfg := &configFile{
+		Layout:   string(domain.LayoutLayered),
+		NodeSize: sizeAuto,
+	}
+
+	switch dir := strings.ToUpper(g.attrs["rankdir"]); dir {
+	case "":
+	case "TB", "BT", "LR", "RL":
+		cfg.Direction = dir
+	default:
+		cfg.Warnings = append(cfg.Warnings,
+			fmt.Sprintf("rankdir %s is not known, drawing the graph from top to bottom", g.attrs["rankdir"]))
+	}
+
+	for _, n := range g.nodes {
+		cfg.Nodes = append(cfg.Nodes, configNode{
+			ID:       n.id,
+			Contents: g.label(n.attrs, n.id),
+			Class:    n.attrs["class"],
+			Style:    dotStyle(n.attrs, true),
+			Shape:    dotShape(n.attrs["shape"], n.attrs["style"]),
+		})
+	}
+
+	seen := map[[2]string]bool{}
+	for _, e := range g.edges {
+		if g.strict {
+			// Strict graphs only have 1 edge between each pair of nodes
+			pair := [2]string{e.from.id, e.to.id}
+			if !g.directed && pair[0] > pair[1] {
+				pair[0], pair[1] = pair[1], pair[0]
+			}
+			if seen[pair] {
+				continue
+			}
+			seen[pair] = true
+		}
+
+		arrow, head := g.arrow(e.attrs)
+		cfg.Edges = append(cfg.Edges, configEdge{
+			ID:       e.attrs["id"],
+			From:     e.from.id,
+			To:       e.to.id,
+			FromPort: dotSide(e.from.port, e.attrs["tailport"]),
+			ToPort:   dotSide(e.to.port, e.attrs["headport"]),
+			Label:    g.label(e.attrs, ""),
+			Class:    e.attrs["class"],
+			Style:    dotStyle(e.attrs, false),
+			Arrow:    arrow,
+			Head:     head,
+		})
+	}
+
+	cfg.Groups = g.groups(g.clusters)
+
+	return cfg
+}
+
+// groups turns clusters in to groups, leaving out any that are empty
+func (g *dotGraph) groups(clusters []*dotCluster) []configGroup {
+	var groups []configGroup
+	for _, c := range clusters {
+		group := configGroup{
+			ID:     c.id,
+			Label:  g.label(c.attrs, ""),
+			Nodes:  c.nodes,
+			Groups: g.groups(c.clusters),
+		}
+		if len(group.Nodes) == 0 && len(group.Groups) == 0 {
+			continue
+		}
+		groups = append(groups, group)
+	}
+	return groups
+}
+
+// label returns the label from the attributes, replacing the escapes that
+// Graphviz uses for names and line breaks. Nodes show their ID when they
+// don't have a label.
+func (g *dotGraph) label(attrs map[string]string, id string) string {
+	label, found := attrs["label"]
+	if !found {
+		return id
+	}
+
+	text := strings.Builder{}
+	for i := 0; i < len(label); i++ {
+		if label[i] != '\\' || i+1 == len(label) {
+			text.WriteByte(label[i])
+			continue
+		}
+
+		i++
+		switch label[i] {
+		case 'N':
+			text.WriteString(id)
+		case 'G':
+			text.WriteString(g.name)
+		case 'n', 'l', 'r':
+			text.WriteByte('\n')
+		default:
+			text.WriteByte(label[i])
+		}
+	}
+	return strings.TrimSuffix(text.String(), "\n")
+}
+
+// arrow works out which ends of an edge get arrow heads and what shape they
+// are. Edges in undirected graphs don't have arrows unless they ask for
+// them.
+func (g *dotGraph) arrow(attrs map[string]string) (string, string) {
+	dir := attrs["dir"]
+	if dir == "" && !g.directed {
+		dir = "none"
+	}
+
+	arrow := ""
+	shape := attrs["arrowhead"]
+	switch dir {
+	case "none":
+		arrow = string(domain.ArrowNone)
+	case "back":
+		arrow = string(domain.ArrowBackward)
+		shape = attrs["arrowtail"]
+	case "both":
+		arrow = string(domain.ArrowBoth)
+	}
+
+	if shape == "none" {
+		return string(domain.ArrowNone), ""
+	}
+	return arrow, dotHead(shape)
+}
+
+// dotShapes maps Graphviz node shapes on to the closest layli shape
+var dotShapes = map[string]domain.NodeShape{
+	"box":           domain.ShapeRect,
+	"rect":          domain.ShapeRect,
+	"rectangle":     domain.ShapeRect,
+	"square":        domain.ShapeRect,
+	"record":        domain.ShapeRect,
+	"mrecord":       domain.ShapeRounded,
+	"ellipse":       domain.ShapeEllipse,
+	"oval":          domain.ShapeEllipse,
+	"circle":        domain.ShapeEllipse,
+	"doublecircle":  domain.ShapeEllipse,
+	"point":         domain.ShapeEllipse,
+	"egg":           domain.ShapeEllipse,
+	"diamond":       domain.ShapeDiamond,
+	"mdiamond":      domain.ShapeDiamond,
+	"cylinder":      domain.ShapeCylinder,
+	"hexagon":       domain.ShapeHexagon,
+	"octagon":       domain.ShapeHexagon,
+	"doubleoctagon": domain.ShapeHexagon,
+	"note":          domain.ShapeNote,
+	"tab":           domain.ShapeNote,
+	"folder":        domain.ShapeNote,
+}
+
+// dotShape returns the layli shape for a Graphviz shape. Boxes with rounded
+// corners keep them and shapes that layli can't draw are left as the
+// default.
+func dotShape(shape, style string) string {
+	s := dotShapes[strings.ToLower(shape)]
+	if s == domain.ShapeRect && dotStyles(style)["rounded"] {
+		s = domain.ShapeRounded
+	}
+	return string(s)
+}
+
+// dotHead returns the layli arrow head for a Graphviz arrow shape. Graphviz
+// shapes can start with o for an outline and l or r for half of the shape,
+// which are dropped when layli doesn't have a shape that matches.
+func dotHead(shape string) string {
+	shape = strings.TrimLeft(strings.ToLower(shape), "lr")
+	switch shape {
+	case "vee", "open", "empty", "onormal", "halfopen":
+		return string(domain.HeadOpenArrow)
+	case "diamond":
+		return string(domain.HeadDiamond)
+	case "odiamond", "ediamond":
+		return string(domain.HeadHollowDiamond)
+	case "dot", "odot":
+		return string(domain.HeadCircle)
+	case "crow", "ocrow":
+		return string(domain.HeadCrowsFoot)
+	}
+	return ""
+}
+
+// dotSide returns the side of the node for a port, which can be given after
+// the node ID or as an attribute. Only the compass points for the sides are
+// kept, layli places the path anywhere on that side.
+func dotSide(port, attr string) string {
+	if port == "" {
+		port = attr
+	}
+	if i := strings.LastIndex(port, ":"); i >= 0 {
+		port = port[i+1:]
+	}
+
+	switch port {
+	case "n":
+		return "north"
+	case "s":
+		return "south"
+	case "e":
+		return "east"
+	case "w":
+		return "west"
+	}
+	return ""
+}
+
+// dotStyle turns the Graphviz attributes that change how a node or edge
+// looks in to CSS. A style that is already CSS is kept as it is. Only nodes
+// are filled.
+func dotStyle(attrs map[string]string, filled bool) string {
+	if style := attrs["style"]; strings.Contains(style, ":") {
+		return style
+	}
+
+	styles := dotStyles(attrs["style"])
+	css := []string{}
+	if c := attrs["color"]; c != "" {
+		css = append(css, "stroke: "+dotColour(c)+";")
+	}
+	if filled {
+		c := attrs["fillcolor"]
+		if c == "" && styles["filled"] {
+			c = attrs["color"]
+			if c == "" {
+				c = "lightgrey"
+			}
+		}
+		if c != "" {
+			css = append(css, "fill: "+dotColour(c)+";")
+		}
+	}
+	if w := attrs["penwidth"]; w != "" {
+		css = append(css, "stroke-width: "+w+";")
+	} else if styles["bold"] {
+		css = append(css, "stroke-width: 2;")
+	}
+	if styles["dashed"] {
+		css = append(css, "stroke-dasharray: 5;")
+	}
+	if styles["dotted"] {
+		css = append(css, "stroke-dasharray: 1 3;")
+	}
+	if styles["invis"] {
+		css = append(css, "visibility: hidden;")
+	}
+	return strings.Join(css, " ")
+}
+
+// dotStyles splits the Graphviz style attribute in to its parts
+func dotStyles(style string) map[string]bool {
+	styles := map[string]bool{}
+	for _, s := range strings.Split(style, ",") {
+		styles[strings.TrimSpace(s)] = true
+	}
+	return styles
+}
+
+// dotColour returns the first colour of a Graphviz colour list, which is
+// used for gradients and edges with several colours
+func dotColour(c string) string {
+	c, _, _ = strings.Cut(c, ":")
+	c, _, _ = strings.Cut(c, ";")
+	return strings.TrimSpace(c)
+}
+
+var (
+	htmlBreak = regexp.MustCompile(`(?i)<br(\s+("[^"]*"|'[^']*'|[^'">])*)?/?>`)
+	htmlTag   = regexp.MustCompile(`<("[^"]*"|'[^']*'|[^'">])*>`)
+)
+
+// htmlText returns the text of an HTML label. Line breaks are kept as the
+// same escape that Graphviz uses in other labels.
+func htmlText(s string) string {
+	text := htmlTag.ReplaceAllString(htmlBreak.ReplaceAllString(s, "\n"), "")
+	lines := strings.Split(html.UnescapeString(text), "\n")
+	for i, l := range lines {
+		lines[i] = strings.Join(strings.Fields(l), " ")
+	}
+	return strings.Join(lines, `\n`)
+}
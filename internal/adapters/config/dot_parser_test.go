@@ -0,0 +1,384 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/dnnrly/layli/internal/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func parseDOTFile(t *testing.T, dot string) (*domain.Diagram, error) {
+	t.Helper()
+	parser := NewDOTParser(&mockFileReader{files: map[string][]byte{"graph.dot": []byte(dot)}})
+	return parser.Parse("graph.dot")
+}
+
+func TestDOTParser_Parse(t *testing.T) {
+	t.Run("nodes and edges", func(t *testing.T) {
+		diagram, err := parseDOTFile(t, `
+// The simplest graph
+digraph G {
+    a -> b;
+    b -> c -> a
+    d
+}
+`)
+		require.NoError(t, err)
+
+		require.Len(t, diagram.Nodes, 4)
+		assert.Equal(t, "a", diagram.Nodes[0].ID)
+		assert.Equal(t, "a", diagram.Nodes[0].Contents, "nodes show their ID without a label")
+		assert.Equal(t, domain.SizeAuto, diagram.Config.NodeSize)
+		assert.Empty(t, diagram.Nodes[0].Size, "nodes use the diagram's size")
+		assert.Equal(t, "d", diagram.Nodes[3].ID)
+
+		require.Len(t, diagram.Edges, 3)
+		assert.Equal(t, domain.Edge{ID: "edge-1", From: "a", To: "b"}, diagram.Edges[0])
+		assert.Equal(t, "b", diagram.Edges[1].From)
+		assert.Equal(t, "c", diagram.Edges[1].To)
+		assert.Equal(t, "c", diagram.Edges[2].From)
+		assert.Equal(t, "a", diagram.Edges[2].To)
+
+		assert.Equal(t, domain.LayoutLayered, diagram.Config.LayoutType)
+		assert.Equal(t, 20, diagram.Config.PathAttempts)
+		assert.Equal(t, 2, diagram.Config.Margin)
+	})
+
+	t.Run("labels", func(t *testing.T) {
+		diagram, err := parseDOTFile(t, `
+digraph "My graph" {
+    a [label="First\nline"]
+    b [label="Node \N in \G"]
+    c [label=<<b>Bold</b><br/>and &amp; more>]
+    d [label="joined " + "together"]
+    a -> b [label="calls"]
+}
+`)
+		require.NoError(t, err)
+
+		assert.Equal(t, "First\nline", diagram.Nodes[0].Contents)
+		assert.Equal(t, "Node b in My graph", diagram.Nodes[1].Contents)
+		assert.Equal(t, "Bold\nand & more", diagram.Nodes[2].Contents)
+		assert.Equal(t, "joined together", diagram.Nodes[3].Contents)
+		assert.Equal(t, "calls", diagram.Edges[0].Label)
+	})
+
+	t.Run("HTML strings", func(t *testing.T) {
+		diagram, err := parseDOTFile(t, `
+digraph {
+    label=<<i>Graph</i>>
+    a [label=<<b>x</b>>]
+    b [label=<<font color="a>b" face='<c>'>y</font>>]
+    <<u>c</u>> -> a [label=<<b>calls</b>>]
+}
+`)
+		require.NoError(t, err)
+
+		require.Len(t, diagram.Nodes, 3)
+		assert.Equal(t, "x", diagram.Nodes[0].Contents)
+		assert.Equal(t, "y", diagram.Nodes[1].Contents, "quoted > and < do not end the string")
+		assert.Equal(t, "<u>c</u>", diagram.Nodes[2].ID)
+		assert.Equal(t, "calls", diagram.Edges[0].Label)
+	})
+
+	t.Run("shapes", func(t *testing.T) {
+		diagram, err := parseDOTFile(t, `
+digraph {
+    a [shape=box]
+    b [shape=box, style="rounded,filled"]
+    c [shape=circle]
+    d [shape=cylinder]
+    e [shape=Mdiamond]
+    f [shape=star]
+    g
+}
+`)
+		require.NoError(t, err)
+
+		shapes := []domain.NodeShape{}
+		for _, n := range diagram.Nodes {
+			shapes = append(shapes, n.Shape)
+		}
+		assert.Equal(t, []domain.NodeShape{
+			domain.ShapeRect,
+			domain.ShapeRounded,
+			domain.ShapeEllipse,
+			domain.ShapeCylinder,
+			domain.ShapeDiamond,
+			"",
+			"",
+		}, shapes)
+	})
+
+	t.Run("classes and styles", func(t *testing.T) {
+		diagram, err := parseDOTFile(t, `
+digraph {
+    a [class="service", color=red, fillcolor="#eeeeee"]
+    b [style=filled, color=blue, penwidth=2]
+    c [style="fill: pink;"]
+    a -> b [class=async, style=dashed, color="green:blue"]
+    b -> c [style=bold]
+}
+`)
+		require.NoError(t, err)
+
+		assert.Equal(t, "service", diagram.Nodes[0].Class)
+		assert.Equal(t, "stroke: red; fill: #eeeeee;", diagram.Nodes[0].Style)
+		assert.Equal(t, "stroke: blue; fill: blue; stroke-width: 2;", diagram.Nodes[1].Style)
+		assert.Equal(t, "fill: pink;", diagram.Nodes[2].Style, "CSS is kept as it is")
+		assert.Equal(t, "async", diagram.Edges[0].Class)
+		assert.Equal(t, "stroke: green; stroke-dasharray: 5;", diagram.Edges[0].Style)
+		assert.Equal(t, "stroke-width: 2;", diagram.Edges[1].Style)
+	})
+
+	t.Run("default attributes", func(t *testing.T) {
+		diagram, err := parseDOTFile(t, `
+digraph {
+    a
+    node [shape=box]
+    edge [color=red]
+    b -> c
+    subgraph {
+        node [shape=ellipse]
+        d
+    }
+    e
+}
+`)
+		require.NoError(t, err)
+
+		assert.Equal(t, domain.NodeShape(""), diagram.Nodes[0].Shape, "defaults only apply to nodes after them")
+		assert.Equal(t, domain.ShapeRect, diagram.Nodes[1].Shape)
+		assert.Equal(t, domain.ShapeRect, diagram.Nodes[2].Shape)
+		assert.Equal(t, domain.ShapeEllipse, diagram.Nodes[3].Shape)
+		assert.Equal(t, domain.ShapeRect, diagram.Nodes[4].Shape, "defaults in a subgraph stay in it")
+		assert.Equal(t, "stroke: red;", diagram.Edges[0].Style)
+	})
+
+	t.Run("arrows", func(t *testing.T) {
+		diagram, err := parseDOTFile(t, `
+digraph {
+    a -> b
+    a -> b [dir=both, arrowhead=diamond]
+    a -> b [dir=back, arrowtail=odot]
+    a -> b [arrowhead=none]
+    a -> b [arrowhead=vee]
+    a -> b [arrowhead=crow]
+}
+`)
+		require.NoError(t, err)
+
+		arrows := []domain.Edge{}
+		for _, e := range diagram.Edges {
+			arrows = append(arrows, domain.Edge{Arrow: e.Arrow, Head: e.Head})
+		}
+		assert.Equal(t, []domain.Edge{
+			{},
+			{Arrow: domain.ArrowBoth, Head: domain.HeadDiamond},
+			{Arrow: domain.ArrowBackward, Head: domain.HeadCircle},
+			{Arrow: domain.ArrowNone},
+			{Head: domain.HeadOpenArrow},
+			{Head: domain.HeadCrowsFoot},
+		}, arrows)
+	})
+
+	t.Run("undirected graphs", func(t *testing.T) {
+		diagram, err := parseDOTFile(t, `
+graph {
+    a -- b
+    b -- c [dir=forward]
+}
+`)
+		require.NoError(t, err)
+
+		assert.Equal(t, domain.ArrowNone, diagram.Edges[0].Arrow)
+		assert.Equal(t, domain.ArrowDirection(""), diagram.Edges[1].Arrow)
+	})
+
+	t.Run("strict graphs drop repeated edges", func(t *testing.T) {
+		diagram, err := parseDOTFile(t, `
+strict graph {
+    a -- b
+    b -- a
+    a -- c
+}
+`)
+		require.NoError(t, err)
+
+		require.Len(t, diagram.Edges, 2)
+		assert.Equal(t, "c", diagram.Edges[1].To)
+	})
+
+	t.Run("parallel edges", func(t *testing.T) {
+		diagram, err := parseDOTFile(t, `
+digraph {
+    a -> b [id=request]
+    a -> b
+}
+`)
+		require.NoError(t, err)
+
+		assert.Equal(t, "request", diagram.Edges[0].ID)
+		assert.Equal(t, "edge-2", diagram.Edges[1].ID)
+	})
+
+	t.Run("edges to subgraphs", func(t *testing.T) {
+		diagram, err := parseDOTFile(t, `
+digraph {
+    a -> {b c}
+}
+`)
+		require.NoError(t, err)
+
+		require.Len(t, diagram.Edges, 2)
+		assert.Equal(t, "b", diagram.Edges[0].To)
+		assert.Equal(t, "c", diagram.Edges[1].To)
+	})
+
+	t.Run("ports", func(t *testing.T) {
+		diagram, err := parseDOTFile(t, `
+digraph {
+    a:n -> b:s
+    a:f0:e -> b:f1
+    a -> b [tailport=w, headport=ne]
+}
+`)
+		require.NoError(t, err)
+
+		assert.Equal(t, "north", diagram.Edges[0].FromPort)
+		assert.Equal(t, "south", diagram.Edges[0].ToPort)
+		assert.Equal(t, "east", diagram.Edges[1].FromPort)
+		assert.Equal(t, "", diagram.Edges[1].ToPort, "only the compass point is kept")
+		assert.Equal(t, "west", diagram.Edges[2].FromPort)
+		assert.Equal(t, "", diagram.Edges[2].ToPort, "layli ports are on a side")
+	})
+
+	t.Run("clusters", func(t *testing.T) {
+		diagram, err := parseDOTFile(t, `
+digraph {
+    subgraph cluster_backend {
+        label = "Backend"
+        api
+        subgraph cluster_data {
+            graph [label="Data"]
+            db
+        }
+    }
+    subgraph cluster_empty {
+    }
+    subgraph not_a_cluster {
+        web
+    }
+    web -> api -> db
+}
+`)
+		require.NoError(t, err)
+
+		assert.Equal(t, []domain.Group{
+			{
+				ID:    "cluster_backend",
+				Label: "Backend",
+				Nodes: []string{"api"},
+				Groups: []domain.Group{
+					{ID: "cluster_data", Label: "Data", Nodes: []string{"db"}},
+				},
+			},
+		}, diagram.Groups)
+	})
+
+	t.Run("nodes are in the first cluster they are used in", func(t *testing.T) {
+		diagram, err := parseDOTFile(t, `
+digraph {
+    a
+    subgraph cluster_1 { a }
+    subgraph cluster_2 { a; b }
+}
+`)
+		require.NoError(t, err)
+
+		require.Len(t, diagram.Groups, 2)
+		assert.Equal(t, []string{"a"}, diagram.Groups[0].Nodes)
+		assert.Equal(t, []string{"b"}, diagram.Groups[1].Nodes)
+	})
+
+	t.Run("reopened clusters are merged", func(t *testing.T) {
+		diagram, err := parseDOTFile(t, `
+digraph {
+    subgraph cluster_a { label="A"; a }
+    b
+    subgraph cluster_a { c }
+    a -> c
+}
+`)
+		require.NoError(t, err)
+
+		assert.Equal(t, []domain.Group{
+			{ID: "cluster_a", Label: "A", Nodes: []string{"a", "c"}},
+		}, diagram.Groups)
+	})
+
+	t.Run("rankdir", func(t *testing.T) {
+		diagram, err := parseDOTFile(t, `digraph G { rankdir=LR; a -> b -> c; }`)
+		require.NoError(t, err)
+		assert.Equal(t, domain.DirectionLeftRight, diagram.Config.Direction)
+		assert.Empty(t, diagram.Warnings)
+
+		diagram, err = parseDOTFile(t, `digraph { graph [rankdir=bt] a -> b }`)
+		require.NoError(t, err)
+		assert.Equal(t, domain.DirectionBottomTop, diagram.Config.Direction)
+
+		diagram, err = parseDOTFile(t, `digraph { a -> b }`)
+		require.NoError(t, err)
+		assert.Empty(t, diagram.Config.Direction, "graphs go from top to bottom unless they say otherwise")
+
+		diagram, err = parseDOTFile(t, `digraph { rankdir=XY; subgraph { rankdir=LR } a -> b }`)
+		require.NoError(t, err)
+		assert.Empty(t, diagram.Config.Direction, "only the graph itself sets the direction")
+		assert.Equal(t, []string{"rankdir XY is not known, drawing the graph from top to bottom"}, diagram.Warnings)
+	})
+
+	t.Run("comments", func(t *testing.T) {
+		diagram, err := parseDOTFile(t, `
+# made by a tool
+/* a graph
+   with comments */
+digraph {
+    a // first node
+}
+`)
+		require.NoError(t, err)
+		assert.Len(t, diagram.Nodes, 1)
+	})
+}
+
+func TestDOTParser_Parse_Errors(t *testing.T) {
+	tests := []struct {
+		name string
+		dot  string
+		err  string
+	}{
+		{name: "not a graph", dot: `nodes: []`, err: "reading DOT file: line 1: expected 'graph' or 'digraph' but found 'nodes'"},
+		{name: "not closed", dot: "digraph {\n  a -> b\n", err: "reading DOT file: line 3: expected '}' but found end of file"},
+		{name: "string not closed", dot: "digraph {\n  a [label=\"oops]\n}", err: "reading DOT file: line 2: string is not closed"},
+		{name: "bad character", dot: `digraph { a -> b @ }`, err: "reading DOT file: line 1: unexpected character '@'"},
+		{name: "missing attribute value", dot: `digraph { a [label] }`, err: "reading DOT file: line 1: expected '=' but found ']'"},
+		{name: "wrong edge", dot: `digraph { a -- b }`, err: "reading DOT file: line 1: cannot use -- in a digraph"},
+		{name: "wrong arc", dot: `graph { a -> b }`, err: "reading DOT file: line 1: cannot use -> in a graph"},
+		{name: "more than 1 graph", dot: `digraph { a } digraph { b }`, err: "reading DOT file: line 1: expected end of file but found 'digraph'"},
+		{name: "no nodes", dot: `digraph { }`, err: "must specify at least 1 node"},
+		{name: "cluster named after a node", dot: `digraph { subgraph cluster { cluster } }`, err: "group id cluster is already used"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := parseDOTFile(t, tt.dot)
+			assert.EqualError(t, err, tt.err)
+		})
+	}
+
+	t.Run("file not found", func(t *testing.T) {
+		_, err := NewDOTParser(&mockFileReader{}).Parse("missing.dot")
+		assert.ErrorContains(t, err, "reading config file: file not found: missing.dot")
+	})
+}
@@ -0,0 +1,601 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// dotTokenKind is the kind of a token in a DOT file
+type dotTokenKind int
+
+const (
+	dotEOF    dotTokenKind = iota
+	dotID                  // identifiers, numerals, quoted strings and HTML strings
+	dotPunct               // { } [ ] = ; , : +
+	dotEdgeOp              // -> and --
+)
+
+type dotToken struct {
+	kind dotTokenKind
+	text string
+	line int
+
+	// quoted IDs are never keywords and can be joined together with +
+	quoted bool
+	html   bool
+}
+
+// lexDOT splits a DOT file in to tokens, dropping whitespace and comments
+func lexDOT(src string) ([]dotToken, error) {
+	tokens := []dotToken{}
+	runes := []rune(src)
+	line := 1
+
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		next := rune(0)
+		if i+1 < len(runes) {
+			next = runes[i+1]
+		}
+
+		switch {
+		case r == '\n':
+			line++
+			i++
+		case unicode.IsSpace(r):
+			i++
+		case r == '#', r == '/' && next == '/':
+			for i < len(runes) && runes[i] != '\n' {
+				i++
+			}
+		case r == '/' && next == '*':
+			start := line
+			i += 2
+			for ; i < len(runes) && !(runes[i] == '*' && i+1 < len(runes) && runes[i+1] == '/'); i++ {
+				if runes[i] == '\n' {
+					line++
+				}
+			}
+			if i >= len(runes) {
+				return nil, fmt.Errorf("line %d: comment is not closed", start)
+			}
+			i += 2
+		case r == '-' && (next == '>' || next == '-'):
+			tokens = append(tokens, dotToken{kind: dotEdgeOp, text: string(runes[i : i+2]), line: line})
+			i += 2
+		case strings.ContainsRune("{}[]=;,:+", r):
+			tokens = append(tokens, dotToken{kind: dotPunct, text: string(r), line: line})
+			i++
+		case r == '"':
+			start := line
+			text := strings.Builder{}
+			for i++; i < len(runes) && runes[i] != '"'; i++ {
+				switch {
+				case runes[i] == '\\' && i+1 < len(runes) && runes[i+1] == '"':
+					text.WriteRune('"')
+					i++
+				case runes[i] == '\\' && i+1 < len(runes) && runes[i+1] == '\n':
+					// A backslash at the end of a line carries on the string
+					line++
+					i++
+				default:
+					if runes[i] == '\n' {
+						line++
+					}
+					text.WriteRune(runes[i])
+				}
+			}
+			if i >= len(runes) {
+				return nil, fmt.Errorf("line %d: string is not closed", start)
+			}
+			tokens = append(tokens, dotToken{kind: dotID, text: text.String(), line: start, quoted: true})
+			i++
+		case r == '<':
+			// HTML strings end at the > that balances the opening <. Quoted
+			// attribute values inside tags can hold < and > of their own.
+			start := line
+			depth := 0
+			begin := i
+			quote := rune(0)
+			for ; i < len(runes); i++ {
+				switch c := runes[i]; {
+				case c == '\n':
+					line++
+				case quote != 0:
+					if c == quote {
+						quote = 0
+					}
+				case (c == '"' || c == '\'') && depth > 1:
+					quote = c
+				case c == '<':
+					depth++
+				case c == '>':
+					depth--
+				}
+				if depth == 0 {
+					break
+				}
+			}
+			if i >= len(runes) {
+				return nil, fmt.Errorf("line %d: HTML string is not closed", start)
+			}
+			tokens = append(tokens, dotToken{kind: dotID, text: string(runes[begin+1 : i]), line: start, quoted: true, html: true})
+			i++
+		case r == '-' || r == '.' || unicode.IsDigit(r):
+			begin := i
+			i++
+			for i < len(runes) && (unicode.IsDigit(runes[i]) || runes[i] == '.') {
+				i++
+			}
+			tokens = append(tokens, dotToken{kind: dotID, text: string(runes[begin:i]), line: line})
+		case r == '_' || unicode.IsLetter(r):
+			begin := i
+			for i < len(runes) && (runes[i] == '_' || unicode.IsLetter(runes[i]) || unicode.IsDigit(runes[i])) {
+				i++
+			}
+			tokens = append(tokens, dotToken{kind: dotID, text: string(runes[begin:i]), line: line})
+		default:
+			return nil, fmt.Errorf("line %d: unexpected character %q", line, r)
+		}
+	}
+
+	return append(tokens, dotToken{kind: dotEOF, line: line}), nil
+}
+
+// dotGraph is everything that is read from a DOT file, before it is turned
+// in to a layli config
+type dotGraph struct {
+	name     string
+	strict   bool
+	directed bool
+	nodes    []*dotNode
+	edges    []dotEdge
+	clusters []*dotCluster
+
+	// attrs are those set on the graph itself, such as rankdir
+	attrs map[string]string
+
+	byID        map[string]*dotNode
+	clusterByID map[string]*dotCluster
+}
+
+type dotNode struct {
+	id      string
+	attrs   map[string]string
+	cluster *dotCluster
+}
+
+type dotEdge struct {
+	from, to dotEndpoint
+	attrs    map[string]string
+}
+
+// dotEndpoint is a node at one end of an edge, with the port that was given
+// after its ID
+type dotEndpoint struct {
+	id   string
+	port string
+}
+
+// dotCluster is a subgraph whose name starts with "cluster". Graphviz draws
+// a box around these, so they become groups.
+type dotCluster struct {
+	id       string
+	attrs    map[string]string
+	nodes    []string
+	clusters []*dotCluster
+}
+
+// dotScope holds the default attributes for the graph or subgraph that is
+// being read
+type dotScope struct {
+	node    map[string]string
+	edge    map[string]string
+	graph   map[string]string
+	cluster *dotCluster
+}
+
+// child returns the scope for a subgraph. Defaults for nodes and edges are
+// inherited but the attributes of the graph, such as its label, are not.
+func (s *dotScope) child() *dotScope {
+	return &dotScope{
+		node:    copyAttrs(s.node),
+		edge:    copyAttrs(s.edge),
+		graph:   map[string]string{},
+		cluster: s.cluster,
+	}
+}
+
+type dotParser struct {
+	tokens []dotToken
+	pos    int
+	graph  *dotGraph
+}
+
+// parseDOT reads the first graph in a DOT file
+func parseDOT(src string) (*dotGraph, error) {
+	tokens, err := lexDOT(src)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &dotParser{
+		tokens: tokens,
+		graph:  &dotGraph{byID: map[string]*dotNode{}, clusterByID: map[string]*dotCluster{}},
+	}
+	if err := p.parseGraph(); err != nil {
+		return nil, err
+	}
+	return p.graph, nil
+}
+
+func (p *dotParser) peek() dotToken {
+	return p.tokens[p.pos]
+}
+
+func (p *dotParser) next() dotToken {
+	t := p.tokens[p.pos]
+	if t.kind != dotEOF {
+		p.pos++
+	}
+	return t
+}
+
+// keyword returns true if the next token is the keyword given. Keywords are
+// not case sensitive.
+func (p *dotParser) keyword(k string) bool {
+	t := p.peek()
+	return t.kind == dotID && !t.quoted && strings.EqualFold(t.text, k)
+}
+
+func (p *dotParser) punct(s string) bool {
+	t := p.peek()
+	return t.kind == dotPunct && t.text == s
+}
+
+func (p *dotParser) expect(s string) error {
+	if !p.punct(s) {
+		return p.unexpected(fmt.Sprintf("'%s'", s))
+	}
+	p.next()
+	return nil
+}
+
+func (p *dotParser) unexpected(wanted string) error {
+	t := p.peek()
+	found := fmt.Sprintf("'%s'", t.text)
+	if t.kind == dotEOF {
+		found = "end of file"
+	}
+	return fmt.Errorf("line %d: expected %s but found %s", t.line, wanted, found)
+}
+
+// id reads an ID, joining quoted strings that are separated by +
+func (p *dotParser) id() (dotToken, error) {
+	t := p.peek()
+	if t.kind != dotID {
+		return t, p.unexpected("an ID")
+	}
+	p.next()
+
+	for t.quoted && !t.html && p.punct("+") {
+		p.next()
+		more := p.peek()
+		if more.kind != dotID || !more.quoted || more.html {
+			return t, p.unexpected("a quoted string")
+		}
+		p.next()
+		t.text += more.text
+	}
+	return t, nil
+}
+
+func (p *dotParser) parseGraph() error {
+	if p.keyword("strict") {
+		p.next()
+		p.graph.strict = true
+	}
+
+	switch {
+	case p.keyword("digraph"):
+		p.graph.directed = true
+	case p.keyword("graph"):
+	default:
+		return p.unexpected("'graph' or 'digraph'")
+	}
+	p.next()
+
+	if p.peek().kind == dotID {
+		name, err := p.id()
+		if err != nil {
+			return err
+		}
+		p.graph.name = name.text
+	}
+
+	if err := p.expect("{"); err != nil {
+		return err
+	}
+
+	scope := &dotScope{node: map[string]string{}, edge: map[string]string{}, graph: map[string]string{}}
+	if _, err := p.parseStatements(scope); err != nil {
+		return err
+	}
+	p.graph.attrs = scope.graph
+
+	if err := p.expect("}"); err != nil {
+		return err
+	}
+	if p.peek().kind != dotEOF {
+		return p.unexpected("end of file")
+	}
+	return nil
+}
+
+// parseStatements reads statements up to the closing brace, returning the
+// IDs of all of the nodes that are used in them
+func (p *dotParser) parseStatements(scope *dotScope) ([]string, error) {
+	used := []string{}
+	for !p.punct("}") {
+		if p.peek().kind == dotEOF {
+			return nil, p.unexpected("'}'")
+		}
+
+		ids, err := p.parseStatement(scope)
+		if err != nil {
+			return nil, err
+		}
+		used = append(used, ids...)
+
+		if p.punct(";") {
+			p.next()
+		}
+	}
+	return used, nil
+}
+
+func (p *dotParser) parseStatement(scope *dotScope) ([]string, error) {
+	for _, k := range []string{"graph", "node", "edge"} {
+		if !p.keyword(k) {
+			continue
+		}
+		p.next()
+		attrs, err := p.parseAttrLists()
+		if err != nil {
+			return nil, err
+		}
+		switch k {
+		case "graph":
+			mergeAttrs(scope.graph, attrs)
+		case "node":
+			mergeAttrs(scope.node, attrs)
+		case "edge":
+			mergeAttrs(scope.edge, attrs)
+		}
+		return nil, nil
+	}
+
+	if p.keyword("subgraph") || p.punct("{") {
+		ids, err := p.parseSubgraph(scope)
+		if err != nil {
+			return nil, err
+		}
+		return p.parseEdges(scope, endpoints(ids))
+	}
+
+	name, err := p.id()
+	if err != nil {
+		return nil, err
+	}
+	if p.punct("=") {
+		p.next()
+		value, err := p.id()
+		if err != nil {
+			return nil, err
+		}
+		scope.graph[name.text] = value.text
+		return nil, nil
+	}
+
+	end, err := p.parsePort(name.text)
+	if err != nil {
+		return nil, err
+	}
+	if p.peek().kind == dotEdgeOp {
+		return p.parseEdges(scope, []dotEndpoint{end})
+	}
+
+	attrs, err := p.parseAttrLists()
+	if err != nil {
+		return nil, err
+	}
+	mergeAttrs(p.useNode(scope, name.text).attrs, attrs)
+	return []string{name.text}, nil
+}
+
+// parsePort reads the port that can follow a node ID, keeping only the
+// compass point
+func (p *dotParser) parsePort(id string) (dotEndpoint, error) {
+	end := dotEndpoint{id: id}
+	for p.punct(":") {
+		p.next()
+		port, err := p.id()
+		if err != nil {
+			return end, err
+		}
+		end.port = port.text
+	}
+	return end, nil
+}
+
+// parseEdges reads a chain of edges that starts with the endpoints given.
+// Each endpoint can be a node or a subgraph, which joins to every node in
+// it.
+func (p *dotParser) parseEdges(scope *dotScope, first []dotEndpoint) ([]string, error) {
+	chain := [][]dotEndpoint{first}
+	for p.peek().kind == dotEdgeOp {
+		op := p.next()
+		if p.graph.directed && op.text != "->" {
+			return nil, fmt.Errorf("line %d: cannot use -- in a digraph", op.line)
+		}
+		if !p.graph.directed && op.text != "--" {
+			return nil, fmt.Errorf("line %d: cannot use -> in a graph", op.line)
+		}
+
+		if p.keyword("subgraph") || p.punct("{") {
+			ids, err := p.parseSubgraph(scope)
+			if err != nil {
+				return nil, err
+			}
+			chain = append(chain, endpoints(ids))
+			continue
+		}
+
+		name, err := p.id()
+		if err != nil {
+			return nil, err
+		}
+		end, err := p.parsePort(name.text)
+		if err != nil {
+			return nil, err
+		}
+		chain = append(chain, []dotEndpoint{end})
+	}
+
+	attrs, err := p.parseAttrLists()
+	if err != nil {
+		return nil, err
+	}
+
+	used := []string{}
+	for _, ends := range chain {
+		for _, e := range ends {
+			p.useNode(scope, e.id)
+			used = append(used, e.id)
+		}
+	}
+	for i := 1; i < len(chain); i++ {
+		for _, from := range chain[i-1] {
+			for _, to := range chain[i] {
+				edgeAttrs := copyAttrs(scope.edge)
+				mergeAttrs(edgeAttrs, attrs)
+				p.graph.edges = append(p.graph.edges, dotEdge{from: from, to: to, attrs: edgeAttrs})
+			}
+		}
+	}
+	return used, nil
+}
+
+// parseSubgraph reads a subgraph, returning the IDs of the nodes in it
+func (p *dotParser) parseSubgraph(scope *dotScope) ([]string, error) {
+	name := ""
+	if p.keyword("subgraph") {
+		p.next()
+		if p.peek().kind == dotID {
+			t, err := p.id()
+			if err != nil {
+				return nil, err
+			}
+			name = t.text
+		}
+	}
+	if err := p.expect("{"); err != nil {
+		return nil, err
+	}
+
+	inner := scope.child()
+	if strings.HasPrefix(name, "cluster") {
+		// Opening a cluster that has already been read adds to it, in the
+		// same way as Graphviz
+		c, found := p.graph.clusterByID[name]
+		if !found {
+			c = &dotCluster{id: name, attrs: map[string]string{}}
+			p.graph.clusterByID[name] = c
+			if scope.cluster == nil {
+				p.graph.clusters = append(p.graph.clusters, c)
+			} else {
+				scope.cluster.clusters = append(scope.cluster.clusters, c)
+			}
+		}
+		inner.cluster = c
+	}
+
+	ids, err := p.parseStatements(inner)
+	if err != nil {
+		return nil, err
+	}
+	if inner.cluster != scope.cluster {
+		mergeAttrs(inner.cluster.attrs, inner.graph)
+	}
+
+	return ids, p.expect("}")
+}
+
+// parseAttrLists reads any number of attribute lists, such as [a=b, c=d]
+func (p *dotParser) parseAttrLists() (map[string]string, error) {
+	attrs := map[string]string{}
+	for p.punct("[") {
+		p.next()
+		for !p.punct("]") {
+			name, err := p.id()
+			if err != nil {
+				return nil, err
+			}
+			if err := p.expect("="); err != nil {
+				return nil, err
+			}
+			value, err := p.id()
+			if err != nil {
+				return nil, err
+			}
+			if value.html {
+				value.text = htmlText(value.text)
+			}
+			attrs[name.text] = value.text
+
+			if p.punct(",") || p.punct(";") {
+				p.next()
+			}
+		}
+		p.next()
+	}
+	return attrs, nil
+}
+
+// useNode returns the node with the ID given, creating it with the default
+// attributes of the scope if this is the first time that it has been used.
+// Nodes belong to the first cluster that they are used in.
+func (p *dotParser) useNode(scope *dotScope, id string) *dotNode {
+	n, found := p.graph.byID[id]
+	if !found {
+		n = &dotNode{id: id, attrs: copyAttrs(scope.node)}
+		p.graph.byID[id] = n
+		p.graph.nodes = append(p.graph.nodes, n)
+	}
+	if n.cluster == nil && scope.cluster != nil {
+		n.cluster = scope.cluster
+		scope.cluster.nodes = append(scope.cluster.nodes, id)
+	}
+	return n
+}
+
+func endpoints(ids []string) []dotEndpoint {
+	ends := make([]dotEndpoint, len(ids))
+	for i, id := range ids {
+		ends[i] = dotEndpoint{id: id}
+	}
+	return ends
+}
+
+func copyAttrs(attrs map[string]string) map[string]string {
+	c := make(map[string]string, len(attrs))
+	mergeAttrs(c, attrs)
+	return c
+}
+
+func mergeAttrs(into, from map[string]string) {
+	for k, v := range from {
+		into[k] = v
+	}
+}
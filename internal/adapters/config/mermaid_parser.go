@@ -0,0 +1,490 @@
+package config
+
+import (
+	"fmt"
+	"html"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/dnnrly/layli/internal/domain"
+	"github.com/dnnrly/layli/internal/usecases"
+)
+
+// MermaidParser reads Mermaid flowcharts. Like DOTParser, the flowchart is
+// turned in to the same config as a layli file so that it has the same
+// defaults and is checked in the same way.
+type MermaidParser struct {
+	reader usecases.FileReader
+}
+
+func NewMermaidParser(reader usecases.FileReader) *MermaidParser {
+	return &MermaidParser{reader: reader}
+}
+
+func (p *MermaidParser) Parse(path string) (*domain.Diagram, error) {
+	data, err := p.reader.Read(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading config file: %w", err)
+	}
+
+	cfg, err := parseMermaid(string(data))
+	if err != nil {
+		return nil, fmt.Errorf("reading Mermaid file: %w", err)
+	}
+
+	applyDefaults(cfg)
+
+	if err := validate(cfg); err != nil {
+		return nil, err
+	}
+
+	return toDomain(cfg), nil
+}
+
+// mermaidShapes are the brackets around the text of a node and the shape
+// that they are drawn as. Longer brackets come first so that they are
+// matched before the single brackets that they start with.
+var mermaidShapes = []struct {
+	open, close string
+	shape       domain.NodeShape
+}{
+	{"([", "])", domain.ShapeRounded},
+	{"[(", ")]", domain.ShapeCylinder},
+	{"[[", "]]", domain.ShapeRect},
+	{"((", "))", domain.ShapeEllipse},
+	{"{{", "}}", domain.ShapeHexagon},
+	{"[/", "/]", domain.ShapeRect},
+	{"[\\", "\\]", domain.ShapeRect},
+	{"[", "]", domain.ShapeRect},
+	{"(", ")", domain.ShapeRounded},
+	{"{", "}", domain.ShapeDiamond},
+	{">", "]", domain.ShapeRect},
+}
+
+var (
+	mermaidHeader   = regexp.MustCompile(`^(flowchart|graph)(\s+(TB|TD|BT|LR|RL))?$`)
+	mermaidID       = regexp.MustCompile(`^[\p{L}\p{N}_]+(-[\p{L}\p{N}_]+)*`)
+	mermaidLink     = regexp.MustCompile(`^(<?)(-{2,}|={2,}|-\.+-)([>ox]?)`)
+	mermaidLinkText = regexp.MustCompile(`^(<?)(--|==|-\.)\s+(.+?)\s+(-{2,}|={2,}|\.+-)([>ox]?)`)
+	mermaidBreak    = regexp.MustCompile(`(?i)<br\s*/?>`)
+	mermaidEntity   = regexp.MustCompile(`#(\w+);`)
+)
+
+// mermaidFlowchart collects the flowchart as it is read
+type mermaidFlowchart struct {
+	cfg     *configFile
+	nodes   map[string]int
+	grouped map[string]bool
+
+	// subgraphs are the groups that are open, the innermost is last
+	subgraphs []*configGroup
+}
+
+// parseMermaid reads a flowchart. Statements are separated by new lines or
+// semicolons.
+func parseMermaid(src string) (*configFile, error) {
+	f := &mermaidFlowchart{
+		cfg: &configFile{
+			Layout:   string(domain.LayoutLayered),
+			NodeSize: sizeAuto,
+			Styles:   map[string]string{},
+		},
+		nodes:   map[string]int{},
+		grouped: map[string]bool{},
+	}
+
+	header := false
+	for i, line := range strings.Split(src, "\n") {
+		line, _, _ = strings.Cut(line, "%%")
+		for _, stmt := range mermaidStatements(line) {
+			stmt = strings.TrimSpace(stmt)
+			if stmt == "" || strings.HasPrefix(stmt, "```") {
+				continue
+			}
+
+			var err error
+			if !header {
+				m := mermaidHeader.FindStringSubmatch(stmt)
+				if m == nil {
+					err = fmt.Errorf("expected flowchart but found '%s'", stmt)
+				} else {
+					f.direction(m[3])
+				}
+				header = true
+			} else {
+				err = f.statement(stmt)
+			}
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %w", i+1, err)
+			}
+		}
+	}
+
+	if len(f.subgraphs) != 0 {
+		return nil, fmt.Errorf("subgraph %s does not have an end", f.subgraphs[len(f.subgraphs)-1].ID)
+	}
+
+	if _, found := f.cfg.Styles[".default"]; found {
+		for i := range f.cfg.Nodes {
+			f.cfg.Nodes[i].Class = strings.TrimSpace("default " + f.cfg.Nodes[i].Class)
+		}
+	}
+	return f.cfg, nil
+}
+
+func (f *mermaidFlowchart) statement(stmt string) error {
+	keyword, rest, _ := strings.Cut(stmt, " ")
+	rest = strings.TrimSpace(rest)
+
+	switch keyword {
+	case "subgraph":
+		return f.subgraph(rest)
+	case "end":
+		if len(f.subgraphs) == 0 {
+			return fmt.Errorf("end is not in a subgraph")
+		}
+		f.endSubgraph()
+		return nil
+	case "classDef":
+		names, props, _ := strings.Cut(rest, " ")
+		for _, name := range strings.Split(names, ",") {
+			f.cfg.Styles["."+name] = mermaidCSS(props)
+		}
+		return nil
+	case "class":
+		ids, class, _ := strings.Cut(rest, " ")
+		for _, id := range strings.Split(ids, ",") {
+			n := f.node(id)
+			n.Class = strings.TrimSpace(n.Class + " " + strings.TrimSpace(class))
+		}
+		return nil
+	case "style":
+		id, props, _ := strings.Cut(rest, " ")
+		f.node(id).Style = mermaidCSS(props)
+		return nil
+	case "linkStyle":
+		return f.linkStyle(rest)
+	case "direction":
+		f.direction(rest)
+		return nil
+	case "click":
+		// There is nothing to click on in an SVG
+		return nil
+	}
+
+	return f.chain(stmt)
+}
+
+// mermaidDirections are the ways that a flowchart can be drawn, with top to
+// bottom used when the header leaves it out
+var mermaidDirections = map[string]domain.Direction{
+	"":   domain.DirectionTopBottom,
+	"TB": domain.DirectionTopBottom,
+	"TD": domain.DirectionTopBottom,
+	"BT": domain.DirectionBottomTop,
+	"LR": domain.DirectionLeftRight,
+	"RL": domain.DirectionRightLeft,
+}
+
+// direction sets the way that the flowchart is drawn. Subgraphs are drawn the
+// same way as the flowchart that they are in, so there is a warning when one
+// asks for something else.
+func (f *mermaidFlowchart) direction(dir string) {
+	d, ok := mermaidDirections[dir]
+	if !ok {
+		f.cfg.Warnings = append(f.cfg.Warnings,
+			fmt.Sprintf("direction %s is not known, ignoring it", dir))
+		return
+	}
+	if len(f.subgraphs) == 0 {
+		f.cfg.Direction = string(d)
+		return
+	}
+	if string(d) != f.cfg.Direction {
+		f.cfg.Warnings = append(f.cfg.Warnings,
+			fmt.Sprintf("subgraph %s direction %s is not supported, drawing it in the direction of the flowchart",
+				f.subgraphs[len(f.subgraphs)-1].ID, dir))
+	}
+}
+
+// subgraph opens a group. The title can follow the ID in brackets or be
+// used as the ID.
+func (f *mermaidFlowchart) subgraph(def string) error {
+	id, title := def, def
+	if i := strings.Index(def, "["); i > 0 && strings.HasSuffix(def, "]") {
+		id = strings.TrimSpace(def[:i])
+		title = def[i+1 : len(def)-1]
+	}
+	id = strings.Trim(id, `"`)
+	if id == "" {
+		return fmt.Errorf("subgraph must have an id")
+	}
+
+	f.subgraphs = append(f.subgraphs, &configGroup{ID: id, Label: mermaidText(title)})
+	return nil
+}
+
+// endSubgraph closes the innermost group, adding it to the group that it is
+// in. Empty subgraphs are dropped.
+func (f *mermaidFlowchart) endSubgraph() {
+	g := f.subgraphs[len(f.subgraphs)-1]
+	f.subgraphs = f.subgraphs[:len(f.subgraphs)-1]
+	if len(g.Nodes) == 0 && len(g.Groups) == 0 {
+		return
+	}
+
+	if len(f.subgraphs) == 0 {
+		f.cfg.Groups = append(f.cfg.Groups, *g)
+		return
+	}
+	parent := f.subgraphs[len(f.subgraphs)-1]
+	parent.Groups = append(parent.Groups, *g)
+}
+
+// linkStyle styles the edges with the indexes given, counting from 0 in the
+// order that they are defined
+func (f *mermaidFlowchart) linkStyle(def string) error {
+	indexes, props, _ := strings.Cut(def, " ")
+	for _, index := range strings.Split(indexes, ",") {
+		if index == "default" {
+			f.cfg.Styles[".path-line"] = mermaidCSS(props)
+			continue
+		}
+
+		i, err := strconv.Atoi(index)
+		if err != nil || i < 0 || i >= len(f.cfg.Edges) {
+			return fmt.Errorf("linkStyle refers to unknown link %s", index)
+		}
+		f.cfg.Edges[i].Style = strings.TrimSpace(f.cfg.Edges[i].Style + " " + mermaidCSS(props))
+	}
+	return nil
+}
+
+// node returns the node with the ID given, adding it to the diagram if it
+// hasn't been used before. Nodes belong to the first subgraph that they are
+// used in, even if they were used outside of it first.
+func (f *mermaidFlowchart) node(id string) *configNode {
+	i, found := f.nodes[id]
+	if !found {
+		i = len(f.cfg.Nodes)
+		f.nodes[id] = i
+		f.cfg.Nodes = append(f.cfg.Nodes, configNode{ID: id, Contents: id})
+	}
+	if len(f.subgraphs) > 0 && !f.grouped[id] {
+		g := f.subgraphs[len(f.subgraphs)-1]
+		g.Nodes = append(g.Nodes, id)
+		f.grouped[id] = true
+	}
+	return &f.cfg.Nodes[i]
+}
+
+// chain reads nodes joined by links, such as A & B --> C -.-> D. Every node
+// on one side of a link is joined to every node on the other side.
+func (f *mermaidFlowchart) chain(stmt string) error {
+	rest := stmt
+	from, rest, err := f.nodeGroup(rest)
+	if err != nil {
+		return err
+	}
+
+	for rest != "" {
+		var edge configEdge
+		edge, rest, err = mermaidEdge(rest)
+		if err != nil {
+			return err
+		}
+
+		var to []string
+		to, rest, err = f.nodeGroup(rest)
+		if err != nil {
+			return err
+		}
+
+		for _, a := range from {
+			for _, b := range to {
+				e := edge
+				e.From, e.To = a, b
+				f.cfg.Edges = append(f.cfg.Edges, e)
+			}
+		}
+		from = to
+	}
+	return nil
+}
+
+// nodeGroup reads nodes separated by &, returning their IDs and the rest of
+// the statement
+func (f *mermaidFlowchart) nodeGroup(s string) ([]string, string, error) {
+	ids := []string{}
+	for {
+		id, rest, err := f.nodeDef(strings.TrimSpace(s))
+		if err != nil {
+			return nil, "", err
+		}
+		ids = append(ids, id)
+
+		rest = strings.TrimSpace(rest)
+		if !strings.HasPrefix(rest, "&") {
+			return ids, rest, nil
+		}
+		s = rest[1:]
+	}
+}
+
+// nodeDef reads a node ID with the text and shape that can follow it, and
+// any class that is added with :::
+func (f *mermaidFlowchart) nodeDef(s string) (string, string, error) {
+	id := mermaidID.FindString(s)
+	if id == "" {
+		return "", "", fmt.Errorf("expected a node but found '%s'", s)
+	}
+	n := f.node(id)
+	rest := s[len(id):]
+
+	for _, sh := range mermaidShapes {
+		if !strings.HasPrefix(rest, sh.open) {
+			continue
+		}
+
+		text, after, err := mermaidBracketed(rest[len(sh.open):], sh.close)
+		if err != nil {
+			return "", "", fmt.Errorf("node %s: %w", id, err)
+		}
+		n.Contents = text
+		n.Shape = string(sh.shape)
+		rest = after
+		break
+	}
+
+	if strings.HasPrefix(rest, ":::") {
+		class := mermaidID.FindString(rest[3:])
+		n.Class = strings.TrimSpace(n.Class + " " + class)
+		rest = rest[3+len(class):]
+	}
+	return id, rest, nil
+}
+
+// mermaidBracketed reads text up to the closing bracket given. The text can
+// be quoted so that it can contain brackets.
+func mermaidBracketed(s, close string) (string, string, error) {
+	if strings.HasPrefix(s, `"`) {
+		end := strings.Index(s[1:], `"`)
+		if end < 0 || !strings.HasPrefix(s[end+2:], close) {
+			return "", "", fmt.Errorf("text is not closed with %s", close)
+		}
+		return mermaidText(s[1 : end+1]), s[end+2+len(close):], nil
+	}
+
+	end := strings.Index(s, close)
+	if end < 0 {
+		return "", "", fmt.Errorf("text is not closed with %s", close)
+	}
+	return mermaidText(s[:end]), s[end+len(close):], nil
+}
+
+// mermaidEdge reads a link with its label, returning an edge without any
+// nodes and the rest of the statement. Dotted and thick links are styled to
+// match how Mermaid draws them.
+func mermaidEdge(s string) (configEdge, string, error) {
+	var start, line, end, label string
+	if m := mermaidLinkText.FindStringSubmatch(s); m != nil {
+		start, line, label, end = m[1], m[2]+m[4], mermaidText(m[3]), m[5]
+		s = s[len(m[0]):]
+	} else if m := mermaidLink.FindStringSubmatch(s); m != nil {
+		start, line, end = m[1], m[2], m[3]
+		s = s[len(m[0]):]
+	} else {
+		return configEdge{}, "", fmt.Errorf("expected a link but found '%s'", s)
+	}
+
+	s = strings.TrimSpace(s)
+	if strings.HasPrefix(s, "|") {
+		text, after, err := mermaidBracketed(s[1:], "|")
+		if err != nil {
+			return configEdge{}, "", fmt.Errorf("link label: %w", err)
+		}
+		label, s = text, after
+	}
+
+	e := configEdge{Label: label}
+	switch {
+	case start != "" && end != "":
+		e.Arrow = string(domain.ArrowBoth)
+	case start != "":
+		e.Arrow = string(domain.ArrowBackward)
+	case end == "":
+		e.Arrow = string(domain.ArrowNone)
+	}
+	if end == "o" {
+		e.Head = string(domain.HeadCircle)
+	}
+
+	switch {
+	case strings.Contains(line, "."):
+		e.Style = "stroke-dasharray: 5;"
+	case strings.HasPrefix(line, "="):
+		e.Style = "stroke-width: 3;"
+	}
+	return e, s, nil
+}
+
+// mermaidStatements splits a line in to statements at the semicolons that
+// aren't in quotes or at the end of an entity such as #quot;
+func mermaidStatements(line string) []string {
+	stmts := []string{}
+	quoted := false
+	start := 0
+	for i, r := range line {
+		switch {
+		case r == '"':
+			quoted = !quoted
+		case r == ';' && !quoted && !mermaidEntityStart.MatchString(line[start:i]):
+			stmts = append(stmts, line[start:i])
+			start = i + 1
+		}
+	}
+	return append(stmts, line[start:])
+}
+
+var mermaidEntityStart = regexp.MustCompile(`#\w+$`)
+
+// mermaidText returns the text of a node or label, with its line breaks and
+// entities replaced. Mermaid writes entities with # instead of &, so #quot;
+// is a quote and #35; is a hash.
+func mermaidText(s string) string {
+	s = strings.Trim(strings.TrimSpace(s), `"`)
+	s = mermaidEntity.ReplaceAllStringFunc(s, func(e string) string {
+		name := e[1 : len(e)-1]
+		if _, err := strconv.Atoi(name); err == nil {
+			return "&#" + name + ";"
+		}
+		return "&" + name + ";"
+	})
+	return html.UnescapeString(mermaidBreak.ReplaceAllString(s, "\n"))
+}
+
+// mermaidCSS turns the properties of a style, which are separated by
+// commas, in to CSS
+func mermaidCSS(props string) string {
+	css := []string{}
+	depth := 0
+	start := 0
+	props = strings.TrimSpace(props)
+	for i, r := range props + "," {
+		switch r {
+		case '(':
+			depth++
+		case ')':
+			depth--
+		case ',':
+			if depth == 0 {
+				if p := strings.TrimSpace(props[start:i]); p != "" {
+					css = append(css, p+";")
+				}
+				start = i + 1
+			}
+		}
+	}
+	return strings.Join(css, " ")
+}
@@ -0,0 +1,282 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/dnnrly/layli/internal/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func parseMermaidFile(t *testing.T, flowchart string) (*domain.Diagram, error) {
+	t.Helper()
+	parser := NewMermaidParser(&mockFileReader{files: map[string][]byte{"flow.mmd": []byte(flowchart)}})
+	return parser.Parse("flow.mmd")
+}
+
+func TestMermaidParser_Parse(t *testing.T) {
+	t.Run("nodes and edges", func(t *testing.T) {
+		diagram, err := parseMermaidFile(t, `
+%% The simplest flowchart
+flowchart LR
+    A --> B
+    B --> C --> A; D
+`)
+		require.NoError(t, err)
+
+		require.Len(t, diagram.Nodes, 4)
+		assert.Equal(t, "A", diagram.Nodes[0].ID)
+		assert.Equal(t, "A", diagram.Nodes[0].Contents, "nodes show their ID without any text")
+		assert.Equal(t, domain.SizeAuto, diagram.Config.NodeSize)
+		assert.Empty(t, diagram.Nodes[0].Size, "nodes use the diagram's size")
+		assert.Equal(t, "D", diagram.Nodes[3].ID)
+
+		require.Len(t, diagram.Edges, 3)
+		assert.Equal(t, domain.Edge{ID: "edge-1", From: "A", To: "B"}, diagram.Edges[0])
+		assert.Equal(t, "C", diagram.Edges[2].From)
+		assert.Equal(t, "A", diagram.Edges[2].To)
+
+		assert.Equal(t, domain.LayoutLayered, diagram.Config.LayoutType)
+		assert.Equal(t, 20, diagram.Config.PathAttempts)
+	})
+
+	t.Run("shapes", func(t *testing.T) {
+		diagram, err := parseMermaidFile(t, `
+graph TD
+    a[Box]
+    b(Rounded)
+    c{Decision}
+    d([Stadium])
+    e[(Database)]
+    f((Circle))
+    g{{Hexagon}}
+    h>Flag]
+    i
+`)
+		require.NoError(t, err)
+
+		nodes := []domain.Node{}
+		for _, n := range diagram.Nodes {
+			nodes = append(nodes, domain.Node{Contents: n.Contents, Shape: n.Shape})
+		}
+		assert.Equal(t, []domain.Node{
+			{Contents: "Box", Shape: domain.ShapeRect},
+			{Contents: "Rounded", Shape: domain.ShapeRounded},
+			{Contents: "Decision", Shape: domain.ShapeDiamond},
+			{Contents: "Stadium", Shape: domain.ShapeRounded},
+			{Contents: "Database", Shape: domain.ShapeCylinder},
+			{Contents: "Circle", Shape: domain.ShapeEllipse},
+			{Contents: "Hexagon", Shape: domain.ShapeHexagon},
+			{Contents: "Flag", Shape: domain.ShapeRect},
+			{Contents: "i"},
+		}, nodes)
+	})
+
+	t.Run("text", func(t *testing.T) {
+		diagram, err := parseMermaidFile(t, `
+flowchart TB
+    a["Text with (brackets); and a semicolon"]
+    b[First<br/>second]
+    c[Say #quot;hi#quot; #35;1]
+    a-->b
+    b[Changed]
+`)
+		require.NoError(t, err)
+
+		assert.Equal(t, "Text with (brackets); and a semicolon", diagram.Nodes[0].Contents)
+		assert.Equal(t, "Changed", diagram.Nodes[1].Contents, "the last text for a node is used")
+		assert.Equal(t, `Say "hi" #1`, diagram.Nodes[2].Contents)
+	})
+
+	t.Run("links", func(t *testing.T) {
+		diagram, err := parseMermaidFile(t, `
+flowchart LR
+    a --> b
+    a --- b
+    a -.-> b
+    a ==> b
+    a <--> b
+    a --o b
+    a ---> b
+    a-->b
+`)
+		require.NoError(t, err)
+
+		edges := []domain.Edge{}
+		for _, e := range diagram.Edges {
+			edges = append(edges, domain.Edge{From: e.From, To: e.To, Arrow: e.Arrow, Head: e.Head, Style: e.Style})
+		}
+		assert.Equal(t, []domain.Edge{
+			{From: "a", To: "b"},
+			{From: "a", To: "b", Arrow: domain.ArrowNone},
+			{From: "a", To: "b", Style: "stroke-dasharray: 5;"},
+			{From: "a", To: "b", Style: "stroke-width: 3;"},
+			{From: "a", To: "b", Arrow: domain.ArrowBoth},
+			{From: "a", To: "b", Head: domain.HeadCircle},
+			{From: "a", To: "b"},
+			{From: "a", To: "b"},
+		}, edges)
+	})
+
+	t.Run("link labels", func(t *testing.T) {
+		diagram, err := parseMermaidFile(t, `
+flowchart LR
+    a -->|yes| b
+    a -- no --> c
+    a -.->|"maybe | later"| d
+    a -. retry .-> a
+`)
+		require.NoError(t, err)
+
+		require.Len(t, diagram.Edges, 4)
+		assert.Equal(t, "yes", diagram.Edges[0].Label)
+		assert.Equal(t, "no", diagram.Edges[1].Label)
+		assert.Equal(t, "c", diagram.Edges[1].To)
+		assert.Equal(t, "maybe | later", diagram.Edges[2].Label)
+		assert.Equal(t, "retry", diagram.Edges[3].Label)
+		assert.Equal(t, "stroke-dasharray: 5;", diagram.Edges[3].Style)
+	})
+
+	t.Run("node groups", func(t *testing.T) {
+		diagram, err := parseMermaidFile(t, `
+flowchart LR
+    a & b --> c & d
+`)
+		require.NoError(t, err)
+
+		require.Len(t, diagram.Edges, 4)
+		assert.Equal(t, "a", diagram.Edges[0].From)
+		assert.Equal(t, "c", diagram.Edges[0].To)
+		assert.Equal(t, "b", diagram.Edges[3].From)
+		assert.Equal(t, "d", diagram.Edges[3].To)
+	})
+
+	t.Run("classes and styles", func(t *testing.T) {
+		diagram, err := parseMermaidFile(t, `
+flowchart LR
+    classDef important fill:#f96,stroke:rgb(0,0,0),stroke-width:2px
+    classDef default fill:white
+    a:::important --> b
+    c
+    class b,c important
+    style c color:red
+    linkStyle 0 stroke:blue
+`)
+		require.NoError(t, err)
+
+		assert.Equal(t, "fill:#f96; stroke:rgb(0,0,0); stroke-width:2px;", diagram.Config.Styles[".important"])
+		assert.Equal(t, "fill:white;", diagram.Config.Styles[".default"])
+		assert.Equal(t, "default important", diagram.Nodes[0].Class)
+		assert.Equal(t, "default important", diagram.Nodes[1].Class)
+		assert.Equal(t, "color:red;", diagram.Nodes[2].Style)
+		assert.Equal(t, "stroke:blue;", diagram.Edges[0].Style)
+	})
+
+	t.Run("subgraphs", func(t *testing.T) {
+		diagram, err := parseMermaidFile(t, `
+flowchart TB
+    subgraph backend [Back end]
+        api
+        subgraph data
+            direction LR
+            db[(DB)]
+        end
+    end
+    subgraph empty
+    end
+    web --> api --> db
+`)
+		require.NoError(t, err)
+
+		assert.Equal(t, []domain.Group{
+			{
+				ID:    "backend",
+				Label: "Back end",
+				Nodes: []string{"api"},
+				Groups: []domain.Group{
+					{ID: "data", Label: "data", Nodes: []string{"db"}},
+				},
+			},
+		}, diagram.Groups)
+	})
+
+	t.Run("nodes are in the first subgraph they are used in", func(t *testing.T) {
+		diagram, err := parseMermaidFile(t, `
+flowchart TB
+    a --> b
+    subgraph one
+        a
+    end
+    subgraph two
+        a --> c
+    end
+`)
+		require.NoError(t, err)
+
+		require.Len(t, diagram.Groups, 2)
+		assert.Equal(t, []string{"a"}, diagram.Groups[0].Nodes)
+		assert.Equal(t, []string{"c"}, diagram.Groups[1].Nodes)
+	})
+
+	t.Run("directions", func(t *testing.T) {
+		diagram, err := parseMermaidFile(t, "flowchart TD\n    a --> b\n")
+		require.NoError(t, err)
+		assert.Empty(t, diagram.Warnings)
+		assert.Equal(t, domain.DirectionTopBottom, diagram.Config.Direction)
+
+		diagram, err = parseMermaidFile(t, "graph\n    a --> b\n")
+		require.NoError(t, err)
+		assert.Equal(t, domain.DirectionTopBottom, diagram.Config.Direction)
+
+		diagram, err = parseMermaidFile(t, `
+flowchart LR
+    subgraph s
+        direction BT
+        a
+    end
+    subgraph t
+        direction LR
+        b
+    end
+    direction RL
+    direction XY
+`)
+		require.NoError(t, err)
+		assert.Equal(t, domain.DirectionRightLeft, diagram.Config.Direction)
+		assert.Equal(t, []string{
+			"subgraph s direction BT is not supported, drawing it in the direction of the flowchart",
+			"direction XY is not known, ignoring it",
+		}, diagram.Warnings)
+	})
+
+	t.Run("markdown code fence", func(t *testing.T) {
+		diagram, err := parseMermaidFile(t, "```mermaid\nflowchart LR\n    a --> b\n```\n")
+		require.NoError(t, err)
+		assert.Len(t, diagram.Nodes, 2)
+	})
+}
+
+func TestMermaidParser_Parse_Errors(t *testing.T) {
+	tests := []struct {
+		name      string
+		flowchart string
+		err       string
+	}{
+		{name: "not a flowchart", flowchart: "sequenceDiagram\n  a->>b: hi", err: "reading Mermaid file: line 1: expected flowchart but found 'sequenceDiagram'"},
+		{name: "text not closed", flowchart: "flowchart LR\n  a[oops --> b", err: "reading Mermaid file: line 2: node a: text is not closed with ]"},
+		{name: "bad link", flowchart: "flowchart LR\n  a ~~> b", err: "reading Mermaid file: line 2: expected a link but found '~~> b'"},
+		{name: "missing node", flowchart: "flowchart LR\n  a -->", err: "reading Mermaid file: line 2: expected a node but found ''"},
+		{name: "end without subgraph", flowchart: "flowchart LR\n  a\n  end", err: "reading Mermaid file: line 3: end is not in a subgraph"},
+		{name: "subgraph without end", flowchart: "flowchart LR\n  subgraph s\n  a", err: "reading Mermaid file: subgraph s does not have an end"},
+		{name: "unknown link style", flowchart: "flowchart LR\n  a --> b\n  linkStyle 1 stroke:red", err: "reading Mermaid file: line 3: linkStyle refers to unknown link 1"},
+		{name: "no nodes", flowchart: "flowchart LR", err: "must specify at least 1 node"},
+		{name: "subgraph named after a node", flowchart: "flowchart LR\n  subgraph a\n  a\n  end", err: "group id a is already used"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := parseMermaidFile(t, tt.flowchart)
+			assert.EqualError(t, err, tt.err)
+		})
+	}
+}
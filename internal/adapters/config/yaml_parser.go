@@ -9,11 +9,12 @@ import (
 )
 
 type configPath struct {
-	Attempts  int    `yaml:"attempts,omitempty"`
-	Strategy  string `yaml:"strategy,omitempty"`
-	Algorithm string `yaml:"algorithm,omitempty"`
-	Heuristic string `yaml:"heuristic,omitempty"`
-	Class     string `yaml:"class,omitempty"`
+	Attempts    int    `yaml:"attempts,omitempty"`
+	Strategy    string `yaml:"strategy,omitempty"`
+	Algorithm   string `yaml:"algorithm,omitempty"`
+	Heuristic   string `yaml:"heuristic,omitempty"`
+	BendPenalty *int   `yaml:"bend-penalty,omitempty"`
+	Class       string `yaml:"class,omitempty"`
 }
 
 type configPosition struct {
@@ -25,16 +26,39 @@ type configNode struct {
 	ID       string         `yaml:"id"`
 	Contents string         `yaml:"contents"`
 	Position configPosition `yaml:"position,omitempty"`
+	Width    int            `yaml:"width,omitempty"`
+	Height   int            `yaml:"height,omitempty"`
+	Size     string         `yaml:"size,omitempty"`
 	Class    string         `yaml:"class,omitempty"`
 	Style    string         `yaml:"style,omitempty"`
+	Shape    string         `yaml:"shape,omitempty"`
+	Ports    []configPort   `yaml:"ports,omitempty"`
+}
+
+type configPort struct {
+	Name   string `yaml:"name"`
+	Side   string `yaml:"side"`
+	Offset int    `yaml:"offset"`
 }
 
 type configEdge struct {
-	ID    string `yaml:"id,omitempty"`
-	From  string `yaml:"from"`
-	To    string `yaml:"to"`
-	Class string `yaml:"class,omitempty"`
-	Style string `yaml:"style,omitempty"`
+	ID       string `yaml:"id,omitempty"`
+	From     string `yaml:"from"`
+	To       string `yaml:"to"`
+	FromPort string `yaml:"from-port,omitempty"`
+	ToPort   string `yaml:"to-port,omitempty"`
+	Label    string `yaml:"label,omitempty"`
+	Class    string `yaml:"class,omitempty"`
+	Style    string `yaml:"style,omitempty"`
+	Arrow    string `yaml:"arrow,omitempty"`
+	Head     string `yaml:"head,omitempty"`
+}
+
+type configGroup struct {
+	ID     string        `yaml:"id"`
+	Label  string        `yaml:"label,omitempty"`
+	Nodes  []string      `yaml:"nodes,omitempty"`
+	Groups []configGroup `yaml:"groups,omitempty"`
 }
 
 type configFile struct {
@@ -43,13 +67,35 @@ type configFile struct {
 	Path           configPath        `yaml:"path,omitempty"`
 	Nodes          []configNode      `yaml:"nodes"`
 	Edges          []configEdge      `yaml:"edges"`
+	Groups         []configGroup     `yaml:"groups,omitempty"`
 	NodeWidth      int               `yaml:"width"`
 	NodeHeight     int               `yaml:"height"`
+	NodeSize       string            `yaml:"size,omitempty"`
 	Border         int               `yaml:"border"`
 	Margin         int               `yaml:"margin"`
 	Styles         map[string]string `yaml:"styles,omitempty"`
+	Seed           int64             `yaml:"seed,omitempty"`
+
+	// Direction is the way that the layered layout draws ranks, set by the
+	// formats that have one, such as Mermaid
+	Direction string `yaml:"-"`
+
+	// Warnings are about parts of other formats, such as Mermaid, that
+	// can't be drawn the way that they ask
+	Warnings []string `yaml:"-"`
 }
 
+// Ways that the size of a node can be chosen
+const (
+	sizeFixed = "fixed"
+	sizeAuto  = "auto"
+)
+
+// defaultBendPenalty makes a corner cost the same as 3 extra grid units of
+// path. It is only used when the bend penalty is left out, as 0 turns the
+// penalty off.
+const defaultBendPenalty = 300
+
 type YAMLParser struct {
 	reader usecases.FileReader
 }
@@ -88,6 +134,10 @@ func applyDefaults(cfg *configFile) {
 	if cfg.Path.Heuristic == "" {
 		cfg.Path.Heuristic = "euclidean" // Default heuristic for A*
 	}
+	if cfg.Path.BendPenalty == nil {
+		penalty := defaultBendPenalty
+		cfg.Path.BendPenalty = &penalty
+	}
 	if cfg.NodeWidth == 0 {
 		cfg.NodeWidth = 5
 	}
@@ -104,13 +154,31 @@ func applyDefaults(cfg *configFile) {
 		cfg.LayoutAttempts = 10
 	}
 
+	// Edges without an ID are named after their position, skipping any
+	// name that has been given to another edge
+	taken := map[string]bool{}
+	for _, e := range cfg.Edges {
+		taken[e.ID] = true
+	}
 	for i, e := range cfg.Edges {
 		if e.ID == "" {
-			cfg.Edges[i].ID = fmt.Sprintf("edge-%d", i+1)
+			cfg.Edges[i].ID = nextEdgeID(i+1, taken)
 		}
 	}
 }
 
+// nextEdgeID returns the first edge-N from n on that hasn't been taken,
+// taking it
+func nextEdgeID(n int, taken map[string]bool) string {
+	id := fmt.Sprintf("edge-%d", n)
+	for taken[id] {
+		n++
+		id = fmt.Sprintf("edge-%d", n)
+	}
+	taken[id] = true
+	return id
+}
+
 func validate(cfg *configFile) error {
 	if cfg.Path.Attempts > 10000 {
 		return fmt.Errorf("cannot specify more that 10000 path attempts")
@@ -135,9 +203,20 @@ func validate(cfg *configFile) error {
 		return fmt.Errorf("invalid heuristic: %s. Valid options: euclidean, manhattan", cfg.Path.Heuristic)
 	}
 
+	if cfg.Path.BendPenalty != nil && *cfg.Path.BendPenalty < 0 {
+		return fmt.Errorf("bend penalty cannot be negative")
+	}
+
+	if cfg.Border < 0 {
+		return fmt.Errorf("border cannot be negative")
+	}
+
 	if cfg.Margin > 10 {
 		return fmt.Errorf("margin cannot be larger than 10")
 	}
+	if !validSize(cfg.NodeSize) {
+		return fmt.Errorf("invalid node size: %s. Valid options: fixed, auto", cfg.NodeSize)
+	}
 	if cfg.LayoutAttempts > 10000 {
 		return fmt.Errorf("cannot specify more that 10000 layout attempts")
 	}
@@ -149,31 +228,182 @@ func validate(cfg *configFile) error {
 		if n.ID == "" {
 			return fmt.Errorf("all nodes must have an id")
 		}
+		if n.Width < 0 || n.Height < 0 {
+			return fmt.Errorf("node %s cannot have a negative width or height", n.ID)
+		}
+		if !validSize(n.Size) {
+			return fmt.Errorf("invalid size for node %s: %s. Valid options: fixed, auto", n.ID, n.Size)
+		}
+		if !validShape(n.Shape) {
+			return fmt.Errorf("invalid shape for node %s: %s. Valid options: rounded, rect, ellipse, diamond, cylinder, hexagon, note, person", n.ID, n.Shape)
+		}
+		if err := validatePorts(n); err != nil {
+			return err
+		}
 	}
 
 	nodeIDs := make(map[string]bool, len(cfg.Nodes))
+	ports := make(map[string]map[string]bool, len(cfg.Nodes))
 	for _, n := range cfg.Nodes {
 		nodeIDs[n.ID] = true
+		ports[n.ID] = map[string]bool{}
+		for _, p := range n.Ports {
+			ports[n.ID][p.Name] = true
+		}
 	}
 
+	edgeIDs := make(map[string]bool, len(cfg.Edges))
 	for _, e := range cfg.Edges {
 		if e.From == "" || e.To == "" {
 			return fmt.Errorf("all edges must have a from and a to")
 		}
-		if e.From == e.To {
-			return fmt.Errorf("edges cannot have the same from and to")
+		if edgeIDs[e.ID] {
+			return fmt.Errorf("edge id %s is used more than once", e.ID)
+		}
+		edgeIDs[e.ID] = true
+		if !validArrow(e.Arrow) {
+			return fmt.Errorf("invalid arrow for edge %s: %s. Valid options: none, forward, backward, both", e.ID, e.Arrow)
+		}
+		if !validHead(e.Head) {
+			return fmt.Errorf("invalid head for edge %s: %s. Valid options: triangle, open-arrow, diamond, hollow-diamond, circle, crows-foot", e.ID, e.Head)
 		}
 		if !nodeIDs[e.From] || !nodeIDs[e.To] {
 			return fmt.Errorf("all edges must have a from and a to that are valid node ids")
 		}
+		if e.FromPort != "" && !validSide(e.FromPort) && !ports[e.From][e.FromPort] {
+			return fmt.Errorf("node %s does not have a port called %s", e.From, e.FromPort)
+		}
+		if e.ToPort != "" && !validSide(e.ToPort) && !ports[e.To][e.ToPort] {
+			return fmt.Errorf("node %s does not have a port called %s", e.To, e.ToPort)
+		}
+	}
+
+	return validateGroups(cfg.Groups, nodeIDs)
+}
+
+// validateGroups checks that every group has a unique ID and that each node
+// is in at most 1 group
+func validateGroups(groups []configGroup, nodeIDs map[string]bool) error {
+	used := make(map[string]bool, len(nodeIDs))
+	for id := range nodeIDs {
+		used[id] = true
+	}
+	grouped := map[string]string{}
+
+	var check func(groups []configGroup) error
+	check = func(groups []configGroup) error {
+		for _, g := range groups {
+			if g.ID == "" {
+				return fmt.Errorf("all groups must have an id")
+			}
+			if used[g.ID] {
+				return fmt.Errorf("group id %s is already used", g.ID)
+			}
+			used[g.ID] = true
+
+			if len(g.Nodes) == 0 && len(g.Groups) == 0 {
+				return fmt.Errorf("group %s must contain at least 1 node", g.ID)
+			}
+			for _, id := range g.Nodes {
+				if !nodeIDs[id] {
+					return fmt.Errorf("group %s contains unknown node %s", g.ID, id)
+				}
+				if other, found := grouped[id]; found {
+					return fmt.Errorf("node %s cannot be in groups %s and %s", id, other, g.ID)
+				}
+				grouped[id] = g.ID
+			}
+
+			if err := check(g.Groups); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	return check(groups)
+}
+
+func toDomainGroups(groups []configGroup) []domain.Group {
+	if len(groups) == 0 {
+		return nil
 	}
 
+	converted := make([]domain.Group, len(groups))
+	for i, g := range groups {
+		converted[i] = domain.Group{
+			ID:     g.ID,
+			Label:  g.Label,
+			Nodes:  g.Nodes,
+			Groups: toDomainGroups(g.Groups),
+		}
+	}
+	return converted
+}
+
+func validSize(s string) bool {
+	return s == "" || s == sizeFixed || s == sizeAuto
+}
+
+func validSide(s string) bool {
+	return s == "north" || s == "south" || s == "east" || s == "west"
+}
+
+func validShape(s string) bool {
+	switch domain.NodeShape(s) {
+	case "", domain.ShapeRounded, domain.ShapeRect, domain.ShapeEllipse, domain.ShapeDiamond,
+		domain.ShapeCylinder, domain.ShapeHexagon, domain.ShapeNote, domain.ShapePerson:
+		return true
+	}
+	return false
+}
+
+func validArrow(s string) bool {
+	switch domain.ArrowDirection(s) {
+	case "", domain.ArrowNone, domain.ArrowForward, domain.ArrowBackward, domain.ArrowBoth:
+		return true
+	}
+	return false
+}
+
+func validHead(s string) bool {
+	switch domain.ArrowHead(s) {
+	case "", domain.HeadTriangle, domain.HeadOpenArrow, domain.HeadDiamond,
+		domain.HeadHollowDiamond, domain.HeadCircle, domain.HeadCrowsFoot:
+		return true
+	}
+	return false
+}
+
+func validatePorts(n configNode) error {
+	seen := map[string]bool{}
+	for _, p := range n.Ports {
+		if p.Name == "" {
+			return fmt.Errorf("all ports on node %s must have a name", n.ID)
+		}
+		if validSide(p.Name) {
+			return fmt.Errorf("port %s on node %s cannot be named after a side", p.Name, n.ID)
+		}
+		if seen[p.Name] {
+			return fmt.Errorf("node %s has more than 1 port called %s", n.ID, p.Name)
+		}
+		seen[p.Name] = true
+		if !validSide(p.Side) {
+			return fmt.Errorf("invalid side for port %s on node %s: %s. Valid options: north, south, east, west", p.Name, n.ID, p.Side)
+		}
+		if p.Offset < 1 {
+			return fmt.Errorf("port %s on node %s must have an offset of at least 1", p.Name, n.ID)
+		}
+	}
 	return nil
 }
 
 func toDomain(cfg *configFile) *domain.Diagram {
 	nodes := make([]domain.Node, len(cfg.Nodes))
 	for i, n := range cfg.Nodes {
+		// Only the sizes that have been set explicitly are kept. The rest
+		// are left empty so that they use the diagram's node size when the
+		// node is arranged.
 		nodes[i] = domain.Node{
 			ID:       n.ID,
 			Contents: n.Contents,
@@ -181,21 +411,35 @@ func toDomain(cfg *configFile) *domain.Diagram {
 				X: n.Position.X,
 				Y: n.Position.Y,
 			},
-			Width:  cfg.NodeWidth,
-			Height: cfg.NodeHeight,
+			Width:  n.Width,
+			Height: n.Height,
+			Size:   domain.NodeSize(n.Size),
 			Class:  n.Class,
 			Style:  n.Style,
+			Shape:  domain.NodeShape(n.Shape),
+		}
+		for _, p := range n.Ports {
+			nodes[i].Ports = append(nodes[i].Ports, domain.Port{
+				Name:   p.Name,
+				Side:   p.Side,
+				Offset: p.Offset,
+			})
 		}
 	}
 
 	edges := make([]domain.Edge, len(cfg.Edges))
 	for i, e := range cfg.Edges {
 		edges[i] = domain.Edge{
-			ID:    e.ID,
-			From:  e.From,
-			To:    e.To,
-			Class: e.Class,
-			Style: e.Style,
+			ID:       e.ID,
+			From:     e.From,
+			To:       e.To,
+			FromPort: e.FromPort,
+			ToPort:   e.ToPort,
+			Label:    e.Label,
+			Class:    e.Class,
+			Style:    e.Style,
+			Arrow:    domain.ArrowDirection(e.Arrow),
+			Head:     domain.ArrowHead(e.Head),
 		}
 	}
 
@@ -205,23 +449,29 @@ func toDomain(cfg *configFile) *domain.Diagram {
 	}
 
 	return &domain.Diagram{
-		Nodes: nodes,
-		Edges: edges,
+		Nodes:  nodes,
+		Edges:  edges,
+		Groups: toDomainGroups(cfg.Groups),
 		Config: domain.DiagramConfig{
 			LayoutType:     domain.LayoutType(cfg.Layout),
 			LayoutAttempts: cfg.LayoutAttempts,
 			NodeWidth:      cfg.NodeWidth,
 			NodeHeight:     cfg.NodeHeight,
+			NodeSize:       domain.NodeSize(cfg.NodeSize),
+			Direction:      domain.Direction(cfg.Direction),
 			Border:         cfg.Border,
 			Margin:         cfg.Margin,
 			Spacing:        20,
 			PathAttempts:   cfg.Path.Attempts,
 			PathStrategy:   cfg.Path.Strategy,
 			Pathfinding: domain.PathfindingConfig{
-				Algorithm: domain.PathfindingAlgorithm(cfg.Path.Algorithm),
-				Heuristic: domain.PathfindingHeuristic(cfg.Path.Heuristic),
+				Algorithm:   domain.PathfindingAlgorithm(cfg.Path.Algorithm),
+				Heuristic:   domain.PathfindingHeuristic(cfg.Path.Heuristic),
+				BendPenalty: *cfg.Path.BendPenalty,
 			},
 			Styles: styles,
+			Seed:   cfg.Seed,
 		},
+		Warnings: cfg.Warnings,
 	}
 }
@@ -53,6 +53,7 @@ nodes:
 		assert.Equal(t, 20, diagram.Config.Spacing)
 		assert.Equal(t, 20, diagram.Config.PathAttempts)
 		assert.Equal(t, 10, diagram.Config.LayoutAttempts)
+		assert.Equal(t, 300, diagram.Config.Pathfinding.BendPenalty)
 	})
 
 	t.Run("full config with all fields", func(t *testing.T) {
@@ -67,6 +68,7 @@ margin: 5
 path:
   attempts: 100
   strategy: random
+  bend-penalty: 50
   class: path-class
 nodes:
   - id: node-1
@@ -100,6 +102,7 @@ styles:
 		assert.Equal(t, 5, diagram.Config.Margin)
 		assert.Equal(t, 100, diagram.Config.PathAttempts)
 		assert.Equal(t, "random", diagram.Config.PathStrategy)
+		assert.Equal(t, 50, diagram.Config.Pathfinding.BendPenalty)
 		assert.Equal(t, map[string]string{".c1": "fill: black;"}, diagram.Config.Styles)
 
 		require.Len(t, diagram.Nodes, 2)
@@ -108,8 +111,8 @@ styles:
 		assert.Equal(t, domain.Position{X: 1, Y: 2}, diagram.Nodes[0].Position)
 		assert.Equal(t, "my-class", diagram.Nodes[0].Class)
 		assert.Equal(t, "fill: red", diagram.Nodes[0].Style)
-		assert.Equal(t, 10, diagram.Nodes[0].Width)
-		assert.Equal(t, 6, diagram.Nodes[0].Height)
+		assert.Equal(t, 0, diagram.Nodes[0].Width, "uses the diagram width")
+		assert.Equal(t, 0, diagram.Nodes[0].Height, "uses the diagram height")
 
 		require.Len(t, diagram.Edges, 1)
 		assert.Equal(t, "e1", diagram.Edges[0].ID)
@@ -119,6 +122,22 @@ styles:
 		assert.Equal(t, "stroke: blue", diagram.Edges[0].Style)
 	})
 
+	t.Run("bend penalty of 0 turns it off", func(t *testing.T) {
+		parser := newParser(map[string][]byte{
+			"no-bends.layli": []byte(`
+path:
+  bend-penalty: 0
+nodes:
+  - id: node-1
+`),
+		})
+
+		diagram, err := parser.Parse("no-bends.layli")
+		require.NoError(t, err)
+
+		assert.Equal(t, 0, diagram.Config.Pathfinding.BendPenalty)
+	})
+
 	t.Run("edge ID auto-generation", func(t *testing.T) {
 		parser := newParser(map[string][]byte{
 			"edges.layli": []byte(`
@@ -150,6 +169,169 @@ edges:
 		assert.Equal(t, "edge-3", diagram.Edges[2].ID)
 	})
 
+	t.Run("generated edge IDs skip those already used", func(t *testing.T) {
+		parser := newParser(map[string][]byte{
+			"edges.layli": []byte(`
+nodes:
+  - id: a
+  - id: b
+edges:
+  - id: edge-2
+    from: a
+    to: b
+  - from: b
+    to: a
+`),
+		})
+
+		diagram, err := parser.Parse("edges.layli")
+		require.NoError(t, err)
+
+		require.Len(t, diagram.Edges, 2)
+		assert.Equal(t, "edge-2", diagram.Edges[0].ID)
+		assert.Equal(t, "edge-3", diagram.Edges[1].ID)
+	})
+
+	t.Run("edge labels", func(t *testing.T) {
+		parser := newParser(map[string][]byte{
+			"labels.layli": []byte(`
+nodes:
+  - id: node-1
+  - id: node-2
+edges:
+  - from: node-1
+    to: node-2
+    label: HTTP
+  - from: node-2
+    to: node-1
+`),
+		})
+
+		diagram, err := parser.Parse("labels.layli")
+		require.NoError(t, err)
+
+		require.Len(t, diagram.Edges, 2)
+		assert.Equal(t, "HTTP", diagram.Edges[0].Label)
+		assert.Equal(t, "", diagram.Edges[1].Label)
+	})
+
+	t.Run("self loops", func(t *testing.T) {
+		parser := newParser(map[string][]byte{
+			"loop.layli": []byte(`
+nodes:
+  - id: retry
+edges:
+  - from: retry
+    to: retry
+`),
+		})
+
+		diagram, err := parser.Parse("loop.layli")
+		require.NoError(t, err)
+
+		require.Len(t, diagram.Edges, 1)
+		assert.Equal(t, "retry", diagram.Edges[0].From)
+		assert.Equal(t, "retry", diagram.Edges[0].To)
+	})
+
+	t.Run("arrow heads", func(t *testing.T) {
+		parser := newParser(map[string][]byte{
+			"arrows.layli": []byte(`
+nodes:
+  - id: order
+  - id: line
+edges:
+  - from: order
+    to: line
+    arrow: both
+    head: crows-foot
+  - from: line
+    to: order
+`),
+		})
+
+		diagram, err := parser.Parse("arrows.layli")
+		require.NoError(t, err)
+
+		assert.Equal(t, domain.ArrowBoth, diagram.Edges[0].Arrow)
+		assert.Equal(t, domain.HeadCrowsFoot, diagram.Edges[0].Head)
+		assert.Equal(t, domain.ArrowDirection(""), diagram.Edges[1].Arrow)
+		assert.Equal(t, domain.ArrowHead(""), diagram.Edges[1].Head)
+	})
+
+	t.Run("node shapes", func(t *testing.T) {
+		parser := newParser(map[string][]byte{
+			"shapes.layli": []byte(`
+nodes:
+  - id: db
+    shape: cylinder
+  - id: api
+`),
+		})
+
+		diagram, err := parser.Parse("shapes.layli")
+		require.NoError(t, err)
+
+		assert.Equal(t, domain.ShapeCylinder, diagram.Nodes[0].Shape)
+		assert.Equal(t, domain.NodeShape(""), diagram.Nodes[1].Shape)
+	})
+
+	t.Run("nested groups", func(t *testing.T) {
+		parser := newParser(map[string][]byte{
+			"groups.layli": []byte(`
+nodes:
+  - id: api
+  - id: db
+  - id: web
+groups:
+  - id: payments
+    label: Payments subsystem
+    nodes: [api]
+    groups:
+      - id: storage
+        nodes: [db]
+`),
+		})
+
+		diagram, err := parser.Parse("groups.layli")
+		require.NoError(t, err)
+
+		assert.Equal(t, []domain.Group{
+			{
+				ID:     "payments",
+				Label:  "Payments subsystem",
+				Nodes:  []string{"api"},
+				Groups: []domain.Group{{ID: "storage", Nodes: []string{"db"}}},
+			},
+		}, diagram.Groups)
+	})
+
+	t.Run("edge ports", func(t *testing.T) {
+		parser := newParser(map[string][]byte{
+			"ports.layli": []byte(`
+nodes:
+  - id: node-1
+  - id: node-2
+    ports:
+      - name: in
+        side: west
+        offset: 1
+edges:
+  - from: node-1
+    to: node-2
+    from-port: east
+    to-port: in
+`),
+		})
+
+		diagram, err := parser.Parse("ports.layli")
+		require.NoError(t, err)
+
+		assert.Equal(t, []domain.Port{{Name: "in", Side: "west", Offset: 1}}, diagram.Nodes[1].Ports)
+		assert.Equal(t, "east", diagram.Edges[0].FromPort)
+		assert.Equal(t, "in", diagram.Edges[0].ToPort)
+	})
+
 	t.Run("default values applied correctly", func(t *testing.T) {
 		parser := newParser(map[string][]byte{
 			"defaults.layli": []byte(`
@@ -185,6 +367,22 @@ nodes:
 		assert.Empty(t, diagram.Config.Styles)
 	})
 
+	t.Run("seed is read", func(t *testing.T) {
+		parser := newParser(map[string][]byte{
+			"seed.layli": []byte(`
+layout: random-shortest-square
+seed: 1234
+nodes:
+  - id: a
+`),
+		})
+
+		diagram, err := parser.Parse("seed.layli")
+		require.NoError(t, err)
+
+		assert.Equal(t, int64(1234), diagram.Config.Seed)
+	})
+
 	t.Run("node dimensions come from config", func(t *testing.T) {
 		parser := newParser(map[string][]byte{
 			"dims.layli": []byte(`
@@ -199,10 +397,91 @@ nodes:
 		diagram, err := parser.Parse("dims.layli")
 		require.NoError(t, err)
 
-		assert.Equal(t, 8, diagram.Nodes[0].Width)
-		assert.Equal(t, 4, diagram.Nodes[0].Height)
-		assert.Equal(t, 8, diagram.Nodes[1].Width)
-		assert.Equal(t, 4, diagram.Nodes[1].Height)
+		// Nodes are left without a size of their own so that they use the
+		// diagram's, even if it is changed before they are arranged
+		assert.Equal(t, 8, diagram.Config.NodeWidth)
+		assert.Equal(t, 4, diagram.Config.NodeHeight)
+		for _, n := range diagram.Nodes {
+			assert.Equal(t, 0, n.Width, n.ID)
+			assert.Equal(t, 0, n.Height, n.ID)
+		}
+	})
+
+	t.Run("nodes can be sized automatically", func(t *testing.T) {
+		parser := newParser(map[string][]byte{
+			"auto.layli": []byte(`
+size: auto
+nodes:
+  - id: a
+  - id: b
+    width: 9
+  - id: c
+    size: fixed
+`),
+		})
+
+		diagram, err := parser.Parse("auto.layli")
+		require.NoError(t, err)
+
+		assert.Equal(t, domain.SizeAuto, diagram.Config.NodeSize)
+
+		assert.Empty(t, diagram.Nodes[0].Size)
+		assert.Equal(t, 0, diagram.Nodes[0].Width)
+		assert.Equal(t, 0, diagram.Nodes[0].Height)
+
+		assert.Empty(t, diagram.Nodes[1].Size)
+		assert.Equal(t, 9, diagram.Nodes[1].Width)
+		assert.Equal(t, 0, diagram.Nodes[1].Height)
+
+		assert.Equal(t, domain.SizeFixed, diagram.Nodes[2].Size)
+		assert.Equal(t, 0, diagram.Nodes[2].Width)
+		assert.Equal(t, 0, diagram.Nodes[2].Height)
+	})
+
+	t.Run("single nodes can be sized automatically", func(t *testing.T) {
+		parser := newParser(map[string][]byte{
+			"auto.layli": []byte(`
+nodes:
+  - id: a
+    size: auto
+  - id: b
+`),
+		})
+
+		diagram, err := parser.Parse("auto.layli")
+		require.NoError(t, err)
+
+		assert.Empty(t, diagram.Config.NodeSize)
+		assert.Equal(t, domain.SizeAuto, diagram.Nodes[0].Size)
+		assert.Empty(t, diagram.Nodes[1].Size)
+		assert.Equal(t, 0, diagram.Nodes[1].Width)
+	})
+
+	t.Run("node dimensions can be overridden", func(t *testing.T) {
+		parser := newParser(map[string][]byte{
+			"sizes.layli": []byte(`
+width: 8
+height: 4
+nodes:
+  - id: a
+    width: 12
+  - id: b
+    height: 7
+  - id: c
+`),
+		})
+
+		diagram, err := parser.Parse("sizes.layli")
+		require.NoError(t, err)
+
+		assert.Equal(t, 12, diagram.Nodes[0].Width)
+		assert.Equal(t, 0, diagram.Nodes[0].Height)
+		assert.Equal(t, 0, diagram.Nodes[1].Width)
+		assert.Equal(t, 7, diagram.Nodes[1].Height)
+		assert.Equal(t, 0, diagram.Nodes[2].Width)
+		assert.Equal(t, 0, diagram.Nodes[2].Height)
+		assert.Equal(t, 8, diagram.Config.NodeWidth)
+		assert.Equal(t, 4, diagram.Config.NodeHeight)
 	})
 }
 
@@ -249,6 +528,38 @@ edges:
 `, "all nodes must have an id")
 	})
 
+	t.Run("node with negative size", func(t *testing.T) {
+		check(t, `
+nodes:
+  - id: a
+    width: -3
+`, "node a cannot have a negative width or height")
+	})
+
+	t.Run("unknown diagram size", func(t *testing.T) {
+		check(t, `
+size: huge
+nodes:
+  - id: a
+`, "invalid node size: huge")
+	})
+
+	t.Run("unknown node size", func(t *testing.T) {
+		check(t, `
+nodes:
+  - id: a
+    size: tiny
+`, "invalid size for node a: tiny")
+	})
+
+	t.Run("unknown node shape", func(t *testing.T) {
+		check(t, `
+nodes:
+  - id: a
+    shape: star
+`, "invalid shape for node a: star")
+	})
+
 	t.Run("edge without from and to", func(t *testing.T) {
 		check(t, `
 nodes:
@@ -268,22 +579,41 @@ edges:
 `, "all edges must have a from and a to that are valid node ids")
 	})
 
-	t.Run("edge with same from and to", func(t *testing.T) {
+	t.Run("duplicate edge id", func(t *testing.T) {
 		check(t, `
 nodes:
   - id: a
   - id: b
-  - id: c
-  - id: d
-  - id: e
 edges:
-  - from: a
+  - id: request
+    from: a
     to: b
+  - id: request
+    from: a
+    to: b
+`, "edge id request is used more than once")
+	})
+
+	t.Run("unknown arrow", func(t *testing.T) {
+		check(t, `
+nodes:
+  - id: a
+edges:
   - from: a
     to: a
-  - from: b
-    to: c
-`, "edges cannot have the same from and to")
+    arrow: sideways
+`, "invalid arrow for edge edge-1: sideways")
+	})
+
+	t.Run("unknown arrow head", func(t *testing.T) {
+		check(t, `
+nodes:
+  - id: a
+edges:
+  - from: a
+    to: a
+    head: star
+`, "invalid head for edge edge-1: star")
 	})
 
 	t.Run("path attempts too high", func(t *testing.T) {
@@ -296,6 +626,132 @@ nodes:
 `, "cannot specify more that 10000 path attempts")
 	})
 
+	t.Run("negative bend penalty", func(t *testing.T) {
+		check(t, `
+path:
+  bend-penalty: -1
+nodes:
+  - id: a
+`, "bend penalty cannot be negative")
+	})
+
+	t.Run("negative border", func(t *testing.T) {
+		check(t, `
+border: -3
+nodes:
+  - id: a
+`, "border cannot be negative")
+	})
+
+	t.Run("port on an unknown side", func(t *testing.T) {
+		check(t, `
+nodes:
+  - id: a
+    ports:
+      - name: in
+        side: up
+        offset: 1
+`, "invalid side for port in on node a: up")
+	})
+
+	t.Run("port without an offset", func(t *testing.T) {
+		check(t, `
+nodes:
+  - id: a
+    ports:
+      - name: in
+        side: west
+`, "port in on node a must have an offset of at least 1")
+	})
+
+	t.Run("port named after a side", func(t *testing.T) {
+		check(t, `
+nodes:
+  - id: a
+    ports:
+      - name: east
+        side: west
+        offset: 1
+`, "port east on node a cannot be named after a side")
+	})
+
+	t.Run("duplicate port names", func(t *testing.T) {
+		check(t, `
+nodes:
+  - id: a
+    ports:
+      - name: in
+        side: west
+        offset: 1
+      - name: in
+        side: north
+        offset: 1
+`, "node a has more than 1 port called in")
+	})
+
+	t.Run("group without an ID", func(t *testing.T) {
+		check(t, `
+nodes:
+  - id: a
+groups:
+  - nodes: [a]
+`, "all groups must have an id")
+	})
+
+	t.Run("group ID used by a node", func(t *testing.T) {
+		check(t, `
+nodes:
+  - id: a
+groups:
+  - id: a
+    nodes: [a]
+`, "group id a is already used")
+	})
+
+	t.Run("empty group", func(t *testing.T) {
+		check(t, `
+nodes:
+  - id: a
+groups:
+  - id: g
+`, "group g must contain at least 1 node")
+	})
+
+	t.Run("group with an unknown node", func(t *testing.T) {
+		check(t, `
+nodes:
+  - id: a
+groups:
+  - id: g
+    nodes: [b]
+`, "group g contains unknown node b")
+	})
+
+	t.Run("node in more than 1 group", func(t *testing.T) {
+		check(t, `
+nodes:
+  - id: a
+groups:
+  - id: g
+    nodes: [a]
+    groups:
+      - id: h
+        nodes: [a]
+`, "node a cannot be in groups g and h")
+	})
+
+	t.Run("edge with an unknown port", func(t *testing.T) {
+		check(t, `
+nodes:
+  - id: a
+  - id: b
+edges:
+  - from: a
+    to: b
+    from-port: top
+`, "node a does not have a port called top")
+	})
+
 	t.Run("layout attempts too high", func(t *testing.T) {
 		check(t, `
 nodes:
@@ -17,19 +17,35 @@ func ToLayoutConfig(d *domain.Diagram) layout.Config {
 				X: n.Position.X,
 				Y: n.Position.Y,
 			},
-			Class: n.Class,
-			Style: n.Style,
+			Width:  n.Width,
+			Height: n.Height,
+			Sizing: string(n.Size),
+			Class:  n.Class,
+			Style:  n.Style,
+			Shape:  string(n.Shape),
+		}
+		for _, p := range n.Ports {
+			nodes[i].Ports = append(nodes[i].Ports, layout.ConfigPort{
+				Name:   p.Name,
+				Side:   p.Side,
+				Offset: p.Offset,
+			})
 		}
 	}
 
 	edges := make(layout.ConfigEdges, len(d.Edges))
 	for i, e := range d.Edges {
 		edges[i] = layout.ConfigEdge{
-			ID:    e.ID,
-			From:  e.From,
-			To:    e.To,
-			Class: e.Class,
-			Style: e.Style,
+			ID:       e.ID,
+			From:     e.From,
+			To:       e.To,
+			FromPort: e.FromPort,
+			ToPort:   e.ToPort,
+			Label:    e.Label,
+			Class:    e.Class,
+			Style:    e.Style,
+			Arrow:    string(e.Arrow),
+			Head:     string(e.Head),
 		}
 	}
 
@@ -38,12 +54,59 @@ func ToLayoutConfig(d *domain.Diagram) layout.Config {
 		LayoutAttempts: d.Config.LayoutAttempts,
 		NodeWidth:      d.Config.NodeWidth,
 		NodeHeight:     d.Config.NodeHeight,
+		NodeSizing:     string(d.Config.NodeSize),
+		Direction:      string(d.Config.Direction),
 		Border:         d.Config.Border,
 		Margin:         d.Config.Margin,
 		Spacing:        d.Config.Spacing,
+		Seed:           d.Config.Seed,
 		Nodes:          nodes,
 		Edges:          edges,
+		Groups:         ToLayoutGroups(d.Groups),
+	}
+}
+
+// ToLayoutGroups converts domain groups, and any groups nested inside them,
+// to layout groups.
+func ToLayoutGroups(groups []domain.Group) layout.ConfigGroups {
+	if len(groups) == 0 {
+		return nil
+	}
+
+	converted := make(layout.ConfigGroups, len(groups))
+	for i, g := range groups {
+		converted[i] = layout.ConfigGroup{
+			Id:     g.ID,
+			Label:  g.Label,
+			Nodes:  g.Nodes,
+			Groups: ToLayoutGroups(g.Groups),
+		}
+	}
+	return converted
+}
+
+// ToLayoutNodes converts the already arranged domain nodes to layout nodes.
+// Nodes without their own dimensions fall back to the diagram defaults.
+func ToLayoutNodes(d *domain.Diagram) layout.LayoutNodes {
+	nodes := make(layout.LayoutNodes, len(d.Nodes))
+	for i, n := range d.Nodes {
+		width := n.Width
+		if width == 0 {
+			width = d.Config.NodeWidth
+		}
+		height := n.Height
+		if height == 0 {
+			height = d.Config.NodeHeight
+		}
+		nodes[i] = layout.NewLayoutNode(
+			n.ID, n.Contents,
+			n.Position.X, n.Position.Y,
+			width, height,
+			n.Class, n.Style,
+		)
+		nodes[i].Shape = string(n.Shape)
 	}
+	return nodes
 }
 
 // ToLayoutConfigWithPath is like ToLayoutConfig but includes the path configuration.
@@ -61,10 +124,11 @@ func ToLayoutConfigWithPath(d *domain.Diagram) layout.Config {
 func ToLayoutConfigWithFullPaths(d *domain.Diagram) layout.Config {
 	cfg := ToLayoutConfig(d)
 	cfg.Path = layout.ConfigPath{
-		Strategy:  d.Config.PathStrategy,
-		Attempts:  d.Config.PathAttempts,
-		Algorithm: string(d.Config.Pathfinding.Algorithm),
-		Heuristic: string(d.Config.Pathfinding.Heuristic),
+		Strategy:    d.Config.PathStrategy,
+		Attempts:    d.Config.PathAttempts,
+		Algorithm:   string(d.Config.Pathfinding.Algorithm),
+		Heuristic:   string(d.Config.Pathfinding.Heuristic),
+		BendPenalty: d.Config.Pathfinding.BendPenalty,
 	}
 	return cfg
 }
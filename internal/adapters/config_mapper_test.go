@@ -1,9 +1,11 @@
 package adapters
 
 import (
+	"reflect"
 	"testing"
 
 	"github.com/dnnrly/layli/internal/domain"
+	"github.com/dnnrly/layli/layout"
 )
 
 func TestToLayoutConfig(t *testing.T) {
@@ -173,3 +175,125 @@ func TestToLayoutConfigEmptyDiagram(t *testing.T) {
 		t.Errorf("Expected 0 edges, got %d", len(config.Edges))
 	}
 }
+
+func TestToLayoutConfigDirection(t *testing.T) {
+	diagram := &domain.Diagram{
+		Config: domain.DiagramConfig{Direction: domain.DirectionLeftRight},
+	}
+
+	config := ToLayoutConfig(diagram)
+
+	if config.Direction != layout.DirectionLeftRight {
+		t.Errorf("Expected direction '%s', got '%s'", layout.DirectionLeftRight, config.Direction)
+	}
+}
+
+func TestToLayoutConfigAutoSize(t *testing.T) {
+	diagram := &domain.Diagram{
+		Nodes: []domain.Node{
+			{ID: "fixed", Width: 5, Height: 3},
+			{ID: "auto", Width: 7, Size: domain.SizeAuto},
+		},
+		Config: domain.DiagramConfig{NodeSize: domain.SizeFixed},
+	}
+
+	config := ToLayoutConfig(diagram)
+
+	if config.NodeSizing != layout.SizingFixed {
+		t.Errorf("Expected diagram sizing '%s', got '%s'", layout.SizingFixed, config.NodeSizing)
+	}
+	if config.Nodes[0].Sizing != "" {
+		t.Errorf("Expected node sizing to be empty, got '%s'", config.Nodes[0].Sizing)
+	}
+	if config.Nodes[1].Sizing != layout.SizingAuto {
+		t.Errorf("Expected node sizing '%s', got '%s'", layout.SizingAuto, config.Nodes[1].Sizing)
+	}
+	if config.Nodes[1].Width != 7 || config.Nodes[1].Height != 0 {
+		t.Errorf("Expected node size 7x0, got %dx%d", config.Nodes[1].Width, config.Nodes[1].Height)
+	}
+}
+
+func TestToLayoutConfigPorts(t *testing.T) {
+	diagram := &domain.Diagram{
+		Nodes: []domain.Node{
+			{ID: "a"},
+			{ID: "b", Ports: []domain.Port{{Name: "in", Side: "west", Offset: 2}}},
+		},
+		Edges: []domain.Edge{
+			{From: "a", To: "b", FromPort: "south", ToPort: "in"},
+		},
+	}
+
+	config := ToLayoutConfig(diagram)
+
+	expected := layout.ConfigPorts{{Name: "in", Side: layout.PortWest, Offset: 2}}
+	if !reflect.DeepEqual(config.Nodes[1].Ports, expected) {
+		t.Errorf("Expected ports %v, got %v", expected, config.Nodes[1].Ports)
+	}
+	if config.Edges[0].FromPort != layout.PortSouth || config.Edges[0].ToPort != "in" {
+		t.Errorf("Expected ports south and in, got '%s' and '%s'", config.Edges[0].FromPort, config.Edges[0].ToPort)
+	}
+}
+
+func TestToLayoutShapes(t *testing.T) {
+	diagram := &domain.Diagram{
+		Config: domain.DiagramConfig{NodeWidth: 5, NodeHeight: 3},
+		Nodes: []domain.Node{
+			{ID: "a", Shape: domain.ShapeCylinder},
+			{ID: "b"},
+		},
+	}
+
+	config := ToLayoutConfig(diagram)
+	if config.Nodes[0].Shape != layout.ShapeCylinder || config.Nodes[1].Shape != "" {
+		t.Errorf("Expected config shapes cylinder and none, got '%s' and '%s'", config.Nodes[0].Shape, config.Nodes[1].Shape)
+	}
+
+	nodes := ToLayoutNodes(diagram)
+	if nodes[0].Shape != layout.ShapeCylinder || nodes[1].Shape != "" {
+		t.Errorf("Expected layout shapes cylinder and none, got '%s' and '%s'", nodes[0].Shape, nodes[1].Shape)
+	}
+}
+
+func TestToLayoutConfigArrows(t *testing.T) {
+	diagram := &domain.Diagram{
+		Nodes: []domain.Node{{ID: "a"}, {ID: "b"}},
+		Edges: []domain.Edge{
+			{From: "a", To: "b", Arrow: domain.ArrowBackward, Head: domain.HeadHollowDiamond},
+		},
+	}
+
+	config := ToLayoutConfig(diagram)
+
+	if config.Edges[0].Arrow != layout.ArrowBackward || config.Edges[0].Head != layout.HeadHollowDiamond {
+		t.Errorf("Expected backward hollow-diamond, got '%s' and '%s'", config.Edges[0].Arrow, config.Edges[0].Head)
+	}
+}
+
+func TestToLayoutConfigGroups(t *testing.T) {
+	diagram := &domain.Diagram{
+		Nodes: []domain.Node{{ID: "a"}, {ID: "b"}},
+		Groups: []domain.Group{
+			{
+				ID:     "outer",
+				Label:  "Outer",
+				Nodes:  []string{"a"},
+				Groups: []domain.Group{{ID: "inner", Nodes: []string{"b"}}},
+			},
+		},
+	}
+
+	config := ToLayoutConfig(diagram)
+
+	expected := layout.ConfigGroups{
+		{
+			Id:     "outer",
+			Label:  "Outer",
+			Nodes:  []string{"a"},
+			Groups: layout.ConfigGroups{{Id: "inner", Nodes: []string{"b"}}},
+		},
+	}
+	if !reflect.DeepEqual(config.Groups, expected) {
+		t.Errorf("Expected groups %v, got %v", expected, config.Groups)
+	}
+}
@@ -9,6 +9,7 @@
 //   - layout/     : Layout algorithms (FlowSquare, TopoSort, etc.)
 //   - pathfinding/: Pathfinding algorithms (Dijkstra, A*, etc.)
 //   - rendering/  : Output renderers (SVG, PNG, etc.)
+//   - reporting/  : Warnings for the user (stderr)
 //
 // Each adapter is independent and can be tested in isolation.
 package adapters
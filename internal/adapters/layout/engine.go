@@ -4,6 +4,7 @@ import (
 	"fmt"
 
 	"github.com/dnnrly/layli/internal/adapters"
+	"github.com/dnnrly/layli/internal/common"
 	"github.com/dnnrly/layli/internal/domain"
 	"github.com/dnnrly/layli/layout"
 )
@@ -15,7 +16,17 @@ func NewLayoutAdapter() *LayoutAdapter {
 }
 
 func (a *LayoutAdapter) Arrange(diagram *domain.Diagram) error {
+	// Record the seed that is used so that the diagram can be made again
+	if diagram.Config.Seed == 0 {
+		seed, err := common.NewSeed()
+		if err != nil {
+			return err
+		}
+		diagram.Config.Seed = seed
+	}
+
 	cfg := adapters.ToLayoutConfig(diagram)
+	cfg.Random = common.New(diagram.Config.Seed)
 
 	arranger, err := selectArranger(diagram.Config.LayoutType)
 	if err != nil {
@@ -65,6 +76,8 @@ func selectArranger(lt domain.LayoutType) (layout.LayoutArrangementFunc, error)
 		return layout.LayoutRandomShortestSquare, nil
 	case domain.LayoutAbsolute:
 		return layout.LayoutAbsolute, nil
+	case domain.LayoutLayered:
+		return layout.LayoutLayered, nil
 	default:
 		return nil, fmt.Errorf("unknown layout type: %s", lt)
 	}
@@ -175,6 +175,81 @@ func TestLayoutAdapter_Arrange(t *testing.T) {
 		}
 	})
 
+	t.Run("a seed is chosen and recorded when there isn't one", func(t *testing.T) {
+		t.Setenv("LAYLI_TEST_SEED", "")
+		adapter := NewLayoutAdapter()
+
+		diagram := &domain.Diagram{
+			Config: baseDiagramConfig(),
+			Nodes:  []domain.Node{{ID: "a", Contents: "A"}},
+		}
+
+		err := adapter.Arrange(diagram)
+		require.NoError(t, err)
+		assert.NotZero(t, diagram.Config.Seed)
+	})
+
+	t.Run("the same seed arranges nodes the same way", func(t *testing.T) {
+		arrange := func(seed int64) []domain.Node {
+			cfg := baseDiagramConfig()
+			cfg.LayoutType = domain.LayoutRandomShortest
+			cfg.Seed = seed
+
+			diagram := &domain.Diagram{
+				Config: cfg,
+				Nodes: []domain.Node{
+					{ID: "a", Contents: "A"}, {ID: "b", Contents: "B"}, {ID: "c", Contents: "C"},
+					{ID: "d", Contents: "D"}, {ID: "e", Contents: "E"}, {ID: "f", Contents: "F"},
+				},
+				Edges: []domain.Edge{
+					{ID: "e1", From: "a", To: "f"},
+					{ID: "e2", From: "b", To: "e"},
+					{ID: "e3", From: "c", To: "d"},
+				},
+			}
+
+			err := NewLayoutAdapter().Arrange(diagram)
+			require.NoError(t, err)
+			assert.Equal(t, seed, diagram.Config.Seed, "the seed is kept")
+			return diagram.Nodes
+		}
+
+		assert.Equal(t, arrange(1234), arrange(1234))
+	})
+
+	t.Run("layered layout", func(t *testing.T) {
+		adapter := NewLayoutAdapter()
+		cfg := baseDiagramConfig()
+		cfg.LayoutType = domain.LayoutLayered
+
+		diagram := &domain.Diagram{
+			Config: cfg,
+			Nodes: []domain.Node{
+				{ID: "a", Contents: "A"},
+				{ID: "b", Contents: "B"},
+				{ID: "c", Contents: "C"},
+				{ID: "d", Contents: "D"},
+			},
+			Edges: []domain.Edge{
+				{ID: "e1", From: "a", To: "b"},
+				{ID: "e2", From: "a", To: "c"},
+				{ID: "e3", From: "c", To: "a"},
+			},
+		}
+
+		err := adapter.Arrange(diagram)
+		require.NoError(t, err)
+
+		nodeByID := map[string]domain.Node{}
+		for _, n := range diagram.Nodes {
+			nodeByID[n.ID] = n
+		}
+
+		assert.Less(t, nodeByID["a"].Position.Y, nodeByID["b"].Position.Y)
+		assert.Equal(t, nodeByID["b"].Position.Y, nodeByID["c"].Position.Y)
+		assert.Equal(t, nodeByID["a"].Position.Y, nodeByID["d"].Position.Y, "nodes without edges are kept")
+	})
+
 	t.Run("unknown layout type returns error", func(t *testing.T) {
 		adapter := NewLayoutAdapter()
 		cfg := baseDiagramConfig()
@@ -216,6 +291,55 @@ func TestLayoutAdapter_Arrange(t *testing.T) {
 		}
 	})
 
+	t.Run("auto sized nodes are measured before arranging", func(t *testing.T) {
+		adapter := NewLayoutAdapter()
+		cfg := baseDiagramConfig()
+		cfg.LayoutType = domain.LayoutFlowSquare
+
+		diagram := &domain.Diagram{
+			Config: cfg,
+			Nodes: []domain.Node{
+				{ID: "a", Contents: "A rather long description", Size: domain.SizeAuto},
+				{ID: "b", Contents: "B", Size: domain.SizeAuto},
+				{ID: "c", Contents: "C"},
+			},
+		}
+
+		err := adapter.Arrange(diagram)
+		require.NoError(t, err)
+
+		assert.Equal(t, 8, diagram.Nodes[0].Width)
+		assert.Equal(t, 3, diagram.Nodes[0].Height)
+		assert.Equal(t, 3, diagram.Nodes[1].Width)
+		assert.Equal(t, 3, diagram.Nodes[1].Height)
+		assert.Equal(t, 5, diagram.Nodes[2].Width)
+		assert.Equal(t, 3, diagram.Nodes[2].Height)
+
+		assert.Equal(t, 3, diagram.Nodes[0].Position.X)
+		assert.Equal(t, 15, diagram.Nodes[1].Position.X, "next column starts after the wide node")
+	})
+
+	t.Run("nodes use the size of the diagram unless they choose their own", func(t *testing.T) {
+		adapter := NewLayoutAdapter()
+		cfg := baseDiagramConfig()
+		cfg.LayoutType = domain.LayoutFlowSquare
+		cfg.NodeSize = domain.SizeAuto
+
+		diagram := &domain.Diagram{
+			Config: cfg,
+			Nodes: []domain.Node{
+				{ID: "a", Contents: "A rather long description"},
+				{ID: "b", Contents: "A rather long description", Size: domain.SizeFixed},
+			},
+		}
+
+		err := adapter.Arrange(diagram)
+		require.NoError(t, err)
+
+		assert.Equal(t, 8, diagram.Nodes[0].Width)
+		assert.Equal(t, 5, diagram.Nodes[1].Width)
+	})
+
 	t.Run("class and style preservation", func(t *testing.T) {
 		adapter := NewLayoutAdapter()
 		cfg := baseDiagramConfig()
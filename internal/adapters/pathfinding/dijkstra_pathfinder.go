@@ -4,6 +4,7 @@ import (
 	"fmt"
 
 	"github.com/dnnrly/layli/internal/adapters"
+	"github.com/dnnrly/layli/internal/common"
 	"github.com/dnnrly/layli/internal/domain"
 	"github.com/dnnrly/layli/layout"
 	"github.com/dnnrly/layli/pathfinder/dijkstra"
@@ -17,12 +18,17 @@ func NewDijkstraPathfinder() *DijkstraPathfinder {
 
 func (p *DijkstraPathfinder) FindPaths(diagram *domain.Diagram) error {
 	cfg := adapters.ToLayoutConfigWithFullPaths(diagram)
+	cfg.Random = common.New(diagram.Config.Seed)
 
 	finder := func(start, end dijkstra.Point) layout.PathFinder {
 		return createPathfinder(start, end, cfg.Path)
 	}
 
-	layoutObj, err := layout.NewLayoutFromConfig(finder, &cfg)
+	// Route against the positions the layout engine has already chosen rather
+	// than arranging the nodes a second time.
+	nodes := adapters.ToLayoutNodes(diagram)
+
+	layoutObj, err := layout.NewLayoutFromNodes(finder, &cfg, nodes)
 	if err != nil {
 		return fmt.Errorf("finding paths: %w", err)
 	}
@@ -38,8 +44,30 @@ func (p *DijkstraPathfinder) FindPaths(diagram *domain.Diagram) error {
 			points[j] = domain.Position{X: int(pt.X), Y: int(pt.Y)}
 		}
 		diagram.Edges[i].Path = &domain.Path{Points: points}
+
+		diagram.Edges[i].LabelPlacement = nil
+		if lp.LabelPlacement != nil {
+			diagram.Edges[i].LabelPlacement = &domain.LabelPlacement{
+				Position: domain.Position{
+					X: int(lp.LabelPlacement.At.X),
+					Y: int(lp.LabelPlacement.At.Y),
+				},
+				Side: domain.LabelSide(lp.LabelPlacement.Side),
+			}
+		}
 	}
 
+	diagram.Crossings = nil
+	for _, c := range layoutObj.Crossings {
+		diagram.Crossings = append(diagram.Crossings, domain.Crossing{
+			EdgeA: c.A,
+			EdgeB: c.B,
+			At:    domain.Position{X: int(c.At.X), Y: int(c.At.Y)},
+		})
+	}
+
+	diagram.UnplacedLabels = layoutObj.UnplacedLabels
+
 	return nil
 }
 
@@ -53,6 +81,9 @@ func createPathfinder(start, end dijkstra.Point, pathConfig layout.ConfigPath) l
 		return dijkstra.NewBidirectionalPathFinder(start, end)
 	default:
 		// Default to Dijkstra for backward compatibility
+		if pathConfig.BendPenalty > 0 {
+			return dijkstra.NewBendPathFinder(start, end, int64(pathConfig.BendPenalty))
+		}
 		return dijkstra.NewPathFinder(start, end)
 	}
 }
@@ -68,9 +99,12 @@ func getHeuristic(heuristicName string) dijkstra.HeuristicFunction {
 	}
 }
 
+// findMatchingPath finds the path that was routed for the edge. Paths are
+// matched by ID so that parallel edges between the same nodes each get their
+// own path.
 func findMatchingPath(paths layout.LayoutPaths, edge domain.Edge) *layout.LayoutPath {
 	for _, lp := range paths {
-		if lp.From == edge.From && lp.To == edge.To {
+		if lp.ID == edge.ID {
 			return &lp
 		}
 	}
@@ -3,8 +3,10 @@ package pathfinding
 import (
 	"testing"
 
+	layoutadapter "github.com/dnnrly/layli/internal/adapters/layout"
 	"github.com/dnnrly/layli/internal/domain"
 	"github.com/dnnrly/layli/layout"
+	"github.com/dnnrly/layli/pathfinder/dijkstra"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -182,6 +184,31 @@ func TestDijkstraPathfinder_FindPaths(t *testing.T) {
 		require.NotNil(t, diagram.Edges[0].Path)
 	})
 
+	t.Run("edge labels are placed", func(t *testing.T) {
+		pf := NewDijkstraPathfinder()
+		cfg := baseDiagramConfig()
+
+		diagram := &domain.Diagram{
+			Config: cfg,
+			Nodes: []domain.Node{
+				{ID: "a", Contents: "A", Position: domain.Position{X: 3, Y: 3}, Width: 5, Height: 3},
+				{ID: "b", Contents: "B", Position: domain.Position{X: 12, Y: 3}, Width: 5, Height: 3},
+			},
+			Edges: []domain.Edge{
+				{ID: "e1", From: "a", To: "b", Label: "HTTP"},
+				{ID: "e2", From: "b", To: "a"},
+			},
+		}
+
+		err := pf.FindPaths(diagram)
+		require.NoError(t, err)
+
+		require.NotNil(t, diagram.Edges[0].LabelPlacement)
+		assert.Equal(t, 4, diagram.Edges[0].LabelPlacement.Position.Y)
+		assert.Equal(t, domain.LabelAbove, diagram.Edges[0].LabelPlacement.Side)
+		assert.Nil(t, diagram.Edges[1].LabelPlacement)
+	})
+
 	t.Run("path not found returns error", func(t *testing.T) {
 		pf := NewDijkstraPathfinder()
 		cfg := baseDiagramConfig()
@@ -213,12 +240,12 @@ func TestDijkstraPathfinder_FindPaths(t *testing.T) {
 	t.Run("findMatchingPath with multiple paths", func(t *testing.T) {
 		// Test the findMatchingPath function directly
 		paths := layout.LayoutPaths{
-			{From: "a", To: "b", Points: layout.Points{{X: 0, Y: 0}}},
-			{From: "b", To: "c", Points: layout.Points{{X: 1, Y: 1}}},
-			{From: "a", To: "c", Points: layout.Points{{X: 2, Y: 2}}},
+			{ID: "e1", From: "a", To: "b", Points: layout.Points{{X: 0, Y: 0}}},
+			{ID: "e2", From: "b", To: "c", Points: layout.Points{{X: 1, Y: 1}}},
+			{ID: "e3", From: "a", To: "c", Points: layout.Points{{X: 2, Y: 2}}},
 		}
 
-		edge := domain.Edge{From: "a", To: "c"}
+		edge := domain.Edge{ID: "e3", From: "a", To: "c"}
 		result := findMatchingPath(paths, edge)
 
 		require.NotNil(t, result)
@@ -230,13 +257,176 @@ func TestDijkstraPathfinder_FindPaths(t *testing.T) {
 
 	t.Run("findMatchingPath with no match", func(t *testing.T) {
 		paths := layout.LayoutPaths{
-			{From: "a", To: "b", Points: layout.Points{{X: 0, Y: 0}}},
-			{From: "b", To: "c", Points: layout.Points{{X: 1, Y: 1}}},
+			{ID: "e1", From: "a", To: "b", Points: layout.Points{{X: 0, Y: 0}}},
+			{ID: "e2", From: "b", To: "c", Points: layout.Points{{X: 1, Y: 1}}},
 		}
 
-		edge := domain.Edge{From: "x", To: "y"}
+		edge := domain.Edge{ID: "e3", From: "a", To: "b"}
 		result := findMatchingPath(paths, edge)
 
 		assert.Nil(t, result)
 	})
+
+	t.Run("findMatchingPath with parallel paths", func(t *testing.T) {
+		paths := layout.LayoutPaths{
+			{ID: "request", From: "a", To: "b", Points: layout.Points{{X: 0, Y: 0}}},
+			{ID: "callback", From: "a", To: "b", Points: layout.Points{{X: 1, Y: 1}}},
+		}
+
+		result := findMatchingPath(paths, domain.Edge{ID: "callback", From: "a", To: "b"})
+
+		require.NotNil(t, result)
+		assert.Equal(t, "callback", result.ID)
+		assert.Equal(t, 1.0, result.Points[0].X)
+	})
+}
+
+func TestDijkstraPathfinder_RoutesOnArrangedPositions(t *testing.T) {
+	for _, lt := range []domain.LayoutType{domain.LayoutFlowSquare, domain.LayoutRandomShortest} {
+		t.Run(string(lt), func(t *testing.T) {
+			cfg := baseDiagramConfig()
+			cfg.LayoutType = lt
+
+			diagram := &domain.Diagram{
+				Config: cfg,
+				Nodes: []domain.Node{
+					{ID: "a", Contents: "A", Width: 9},
+					{ID: "b", Contents: "B"},
+					{ID: "c", Contents: "C", Height: 5},
+					{ID: "d", Contents: "D"},
+					{ID: "e", Contents: "E"},
+				},
+				Edges: []domain.Edge{
+					{ID: "e1", From: "a", To: "b"},
+					{ID: "e2", From: "b", To: "c"},
+					{ID: "e3", From: "c", To: "d"},
+					{ID: "e4", From: "d", To: "e"},
+					{ID: "e5", From: "e", To: "a"},
+				},
+			}
+
+			require.NoError(t, layoutadapter.NewLayoutAdapter().Arrange(diagram))
+			arranged := make([]domain.Node, len(diagram.Nodes))
+			copy(arranged, diagram.Nodes)
+
+			require.NoError(t, NewDijkstraPathfinder().FindPaths(diagram))
+
+			assert.Equal(t, arranged, diagram.Nodes, "pathfinding must not move nodes")
+
+			byID := map[string]domain.Node{}
+			for _, n := range diagram.Nodes {
+				byID[n.ID] = n
+			}
+
+			for _, e := range diagram.Edges {
+				require.NotNil(t, e.Path, e.ID)
+				points := e.Path.Points
+				require.GreaterOrEqual(t, len(points), 4, e.ID)
+
+				from := byID[e.From]
+				to := byID[e.To]
+				assert.Equal(t, from.Center(), points[0], "%s starts at the centre of %s", e.ID, e.From)
+				assert.Equal(t, to.Center(), points[len(points)-1], "%s ends at the centre of %s", e.ID, e.To)
+
+				fromNode := layout.NewLayoutNode(from.ID, "", from.Position.X, from.Position.Y, from.Width, from.Height, "", "")
+				toNode := layout.NewLayoutNode(to.ID, "", to.Position.X, to.Position.Y, to.Width, to.Height, "", "")
+				start := points[1]
+				end := points[len(points)-2]
+				assert.True(t, fromNode.IsPort(start.X, start.Y), "%s leaves %s through a port", e.ID, e.From)
+				assert.True(t, toNode.IsPort(end.X, end.Y), "%s enters %s through a port", e.ID, e.To)
+			}
+		})
+	}
+}
+
+func TestDijkstraPathfinder_BendPenaltyReducesCorners(t *testing.T) {
+	corners := func(penalty int) int {
+		cfg := baseDiagramConfig()
+		cfg.Margin = 1
+		cfg.Pathfinding.BendPenalty = penalty
+
+		diagram := &domain.Diagram{
+			Config: cfg,
+			Nodes: []domain.Node{
+				{ID: "a", Contents: "A", Position: domain.Position{X: 3, Y: 3}, Width: 5, Height: 3},
+				{ID: "b", Contents: "B", Position: domain.Position{X: 15, Y: 3}, Width: 5, Height: 3},
+				{ID: "c", Contents: "C", Position: domain.Position{X: 9, Y: 15}, Width: 5, Height: 3},
+			},
+			Edges: []domain.Edge{
+				{ID: "e1", From: "a", To: "c"},
+				{ID: "e2", From: "b", To: "c"},
+			},
+		}
+
+		require.NoError(t, NewDijkstraPathfinder().FindPaths(diagram))
+
+		total := 0
+		for _, e := range diagram.Edges {
+			total += e.Path.Corners()
+		}
+		return total
+	}
+
+	assert.Less(t, corners(300), corners(0))
+}
+
+func TestCreatePathfinder_BendPenalty(t *testing.T) {
+	start, end := layout.Point{X: 1, Y: 1}, layout.Point{X: 5, Y: 5}
+
+	assert.IsType(t, &dijkstra.BendPathFinder{}, createPathfinder(start, end, layout.ConfigPath{BendPenalty: 300}))
+	assert.IsType(t, &dijkstra.PathFinder{}, createPathfinder(start, end, layout.ConfigPath{BendPenalty: 0}),
+		"a bend penalty of 0 uses the plain pathfinder")
+}
+
+func TestDijkstraPathfinder_ParallelEdgesGetTheirOwnPaths(t *testing.T) {
+	diagram := &domain.Diagram{
+		Config: baseDiagramConfig(),
+		Nodes: []domain.Node{
+			{ID: "a", Contents: "A", Position: domain.Position{X: 3, Y: 3}, Width: 5, Height: 3},
+			{ID: "b", Contents: "B", Position: domain.Position{X: 12, Y: 3}, Width: 5, Height: 3},
+		},
+		Edges: []domain.Edge{
+			{ID: "request", From: "a", To: "b"},
+			{ID: "callback", From: "a", To: "b"},
+			{ID: "reply", From: "b", To: "a"},
+		},
+	}
+
+	require.NoError(t, NewDijkstraPathfinder().FindPaths(diagram))
+
+	// Every grid point that a path is drawn over, between the ports at
+	// either end
+	cells := func(p *domain.Path) map[domain.Position]bool {
+		drawn := p.Points[1 : len(p.Points)-1]
+		found := map[domain.Position]bool{}
+		for i := 1; i < len(drawn); i++ {
+			from, to := drawn[i-1], drawn[i]
+			dx, dy := sign(to.X-from.X), sign(to.Y-from.Y)
+			for at := from; at != to; at = (domain.Position{X: at.X + dx, Y: at.Y + dy}) {
+				found[at] = true
+			}
+			found[to] = true
+		}
+		return found
+	}
+
+	used := map[domain.Position]string{}
+	for _, e := range diagram.Edges {
+		require.NotNil(t, e.Path, e.ID)
+		for c := range cells(e.Path) {
+			other, taken := used[c]
+			assert.False(t, taken, "%s and %s both use %v", e.ID, other, c)
+			used[c] = e.ID
+		}
+	}
+}
+
+func sign(v int) int {
+	switch {
+	case v > 0:
+		return 1
+	case v < 0:
+		return -1
+	}
+	return 0
 }
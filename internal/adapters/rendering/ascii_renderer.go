@@ -0,0 +1,360 @@
+package rendering
+
+import (
+	"strings"
+	"unicode/utf8"
+
+	"github.com/dnnrly/layli/internal/domain"
+	"github.com/dnnrly/layli/internal/usecases"
+)
+
+var _ usecases.Renderer = (*ASCIIRenderer)(nil)
+
+// ASCIIRenderer draws a diagram as box-drawing text so that it can be pasted
+// in to code comments, READMEs and terminal output. Nodes and paths are drawn
+// on the same grid that the layout engine uses, with each grid unit taking
+// one row and a few columns of text.
+type ASCIIRenderer struct {
+	writer usecases.FileWriter
+}
+
+func NewASCIIRenderer(writer usecases.FileWriter) *ASCIIRenderer {
+	return &ASCIIRenderer{writer: writer}
+}
+
+func (r *ASCIIRenderer) Render(diagram *domain.Diagram, outputPath string) error {
+	return r.writer.Write(outputPath, []byte(DrawASCII(diagram)))
+}
+
+const (
+	minColumnsPerUnit = 2
+	maxColumnsPerUnit = 6
+)
+
+// Directions that a line leaves a cell in, combined as a bit mask.
+const (
+	lineUp = 1 << iota
+	lineDown
+	lineLeft
+	lineRight
+)
+
+var lineRunes = map[int]rune{
+	lineUp:                                   '│',
+	lineDown:                                 '│',
+	lineUp | lineDown:                        '│',
+	lineLeft:                                 '─',
+	lineRight:                                '─',
+	lineLeft | lineRight:                     '─',
+	lineDown | lineRight:                     '┌',
+	lineDown | lineLeft:                      '┐',
+	lineUp | lineRight:                       '└',
+	lineUp | lineLeft:                        '┘',
+	lineUp | lineDown | lineRight:            '├',
+	lineUp | lineDown | lineLeft:             '┤',
+	lineLeft | lineRight | lineDown:          '┬',
+	lineLeft | lineRight | lineUp:            '┴',
+	lineUp | lineDown | lineLeft | lineRight: '┼',
+}
+
+var arrowRunes = map[int]rune{
+	lineUp:    '▲',
+	lineDown:  '▼',
+	lineLeft:  '◀',
+	lineRight: '▶',
+}
+
+// textCanvas is a grid of runes along with the line directions that have
+// been drawn in to each cell, so that crossing lines join up correctly.
+type textCanvas struct {
+	cells [][]rune
+	lines [][]int
+}
+
+func newTextCanvas(width, height int) *textCanvas {
+	c := &textCanvas{
+		cells: make([][]rune, height),
+		lines: make([][]int, height),
+	}
+	for y := range c.cells {
+		c.cells[y] = []rune(strings.Repeat(" ", width))
+		c.lines[y] = make([]int, width)
+	}
+	return c
+}
+
+func (c *textCanvas) inside(x, y int) bool {
+	return y >= 0 && y < len(c.cells) && x >= 0 && x < len(c.cells[y])
+}
+
+func (c *textCanvas) set(x, y int, r rune) {
+	if c.inside(x, y) {
+		c.cells[y][x] = r
+	}
+}
+
+func (c *textCanvas) addLine(x, y, dir int) {
+	if !c.inside(x, y) {
+		return
+	}
+	c.lines[y][x] |= dir
+	c.cells[y][x] = lineRunes[c.lines[y][x]]
+}
+
+// line draws a horizontal or vertical line between 2 cells
+func (c *textCanvas) line(x1, y1, x2, y2 int) {
+	for x1 != x2 || y1 != y2 {
+		dx, dy := step(x2-x1), step(y2-y1)
+		c.addLine(x1, y1, direction(dx, dy))
+		c.addLine(x1+dx, y1+dy, direction(-dx, -dy))
+		x1 += dx
+		y1 += dy
+	}
+}
+
+func (c *textCanvas) box(left, top, right, bottom int) {
+	for x := left + 1; x < right; x++ {
+		c.set(x, top, '─')
+		c.set(x, bottom, '─')
+	}
+	for y := top + 1; y < bottom; y++ {
+		c.set(left, y, '│')
+		c.set(right, y, '│')
+		for x := left + 1; x < right; x++ {
+			c.set(x, y, ' ')
+		}
+	}
+	c.set(left, top, '┌')
+	c.set(right, top, '┐')
+	c.set(left, bottom, '└')
+	c.set(right, bottom, '┘')
+}
+
+func (c *textCanvas) text(x, y int, t string) {
+	for _, r := range t {
+		c.set(x, y, r)
+		x++
+	}
+}
+
+// String returns the drawn cells cropped to the area that has been drawn on
+func (c *textCanvas) String() string {
+	lines := []string{}
+	minX := -1
+	for _, row := range c.cells {
+		line := strings.TrimRight(string(row), " ")
+		lines = append(lines, line)
+		if line == "" {
+			continue
+		}
+		indent := len(row) - len([]rune(strings.TrimLeft(string(row), " ")))
+		if minX == -1 || indent < minX {
+			minX = indent
+		}
+	}
+
+	for len(lines) > 0 && lines[0] == "" {
+		lines = lines[1:]
+	}
+	for len(lines) > 0 && lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	if len(lines) == 0 {
+		return ""
+	}
+
+	for i, l := range lines {
+		r := []rune(l)
+		if len(r) >= minX {
+			lines[i] = string(r[minX:])
+		}
+	}
+
+	return strings.Join(lines, "\n") + "\n"
+}
+
+// DrawASCII returns the diagram drawn with Unicode box-drawing characters.
+func DrawASCII(diagram *domain.Diagram) string {
+	scale := columnsPerUnit(diagram)
+
+	width, height := 0, 0
+	for _, n := range diagram.Nodes {
+		b := nodeBounds(diagram, n)
+		width = max(width, b.Max.X+1)
+		height = max(height, b.Max.Y+1)
+	}
+	for _, e := range diagram.Edges {
+		if e.Path == nil {
+			continue
+		}
+		for _, p := range e.Path.Points {
+			width = max(width, p.X+1)
+			height = max(height, p.Y+1)
+		}
+	}
+
+	c := newTextCanvas(width*scale+1, height+1)
+
+	for _, e := range diagram.Edges {
+		points := drawnPoints(e)
+		for i := 1; i < len(points); i++ {
+			c.line(
+				points[i-1].X*scale, points[i-1].Y,
+				points[i].X*scale, points[i].Y,
+			)
+		}
+	}
+
+	for _, n := range diagram.Nodes {
+		b := nodeBounds(diagram, n)
+		left, right := b.Min.X*scale, b.Max.X*scale
+		c.box(left, b.Min.Y, right, b.Max.Y)
+
+		lines := strings.Split(n.Contents, "\n")
+		interior := right - left - 3
+
+		// Show that there are more lines than fit with an ellipsis on the
+		// last row
+		if rows := b.Max.Y - b.Min.Y - 1; rows > 0 && len(lines) > rows {
+			lines = lines[:rows]
+			last := []rune(lines[rows-1])
+			lines[rows-1] = string(last[:min(len(last), max(interior-1, 0))]) + "…"
+		}
+
+		top := b.Min.Y + 1 + (b.Max.Y-b.Min.Y-1-len(lines))/2
+		for i, l := range lines {
+			y := top + i
+			if y <= b.Min.Y || y >= b.Max.Y {
+				continue
+			}
+			l = truncate(l, interior)
+			x := left + 1 + (right-left-1-utf8.RuneCountInString(l))/2
+			c.text(x, y, l)
+		}
+	}
+
+	for _, e := range diagram.Edges {
+		points := drawnPoints(e)
+		if len(points) < 2 {
+			continue
+		}
+
+		// Show where the path leaves the source node
+		start, end := points[0], points[len(points)-1]
+		c.port(start, points[1], scale)
+		if e.ArrowAtStart() {
+			c.arrow(start, points[1], scale)
+		}
+
+		// Point the arrow at the destination node
+		if e.ArrowAtEnd() {
+			c.arrow(end, points[len(points)-2], scale)
+		} else {
+			c.port(end, points[len(points)-2], scale)
+		}
+	}
+
+	return c.String()
+}
+
+// port joins the path to the side of the node that it meets at the port,
+// where next is the point along the path from the port
+func (c *textCanvas) port(port, next domain.Position, scale int) {
+	x, y := port.X*scale, port.Y
+	side := lineLeft | lineRight
+	if c.inside(x, y) && c.cells[y][x] == '│' {
+		side = lineUp | lineDown
+	}
+	c.set(x, y, lineRunes[side|direction(step(next.X-port.X), step(next.Y-port.Y))])
+}
+
+// arrow draws an arrow head just outside the port, pointing at the node,
+// where from is the point along the path from the port
+func (c *textCanvas) arrow(port, from domain.Position, scale int) {
+	dx, dy := step(port.X-from.X), step(port.Y-from.Y)
+	c.set(port.X*scale-dx, port.Y-dy, arrowRunes[direction(dx, dy)])
+}
+
+// columnsPerUnit works out how many columns of text to use for each grid
+// unit so that the contents of every node fits inside it.
+func columnsPerUnit(diagram *domain.Diagram) int {
+	scale := minColumnsPerUnit
+	for _, n := range diagram.Nodes {
+		b := nodeBounds(diagram, n)
+		units := b.Max.X - b.Min.X
+		if units <= 0 {
+			continue
+		}
+		for _, l := range strings.Split(n.Contents, "\n") {
+			// Leave a space either side of the text, inside the border
+			need := utf8.RuneCountInString(l) + 3
+			for scale < maxColumnsPerUnit && units*scale < need {
+				scale++
+			}
+		}
+	}
+	return scale
+}
+
+// nodeBounds returns the grid cells on the border of the node, inclusive
+func nodeBounds(diagram *domain.Diagram, n domain.Node) domain.Bounds {
+	width := n.Width
+	if width == 0 {
+		width = diagram.Config.NodeWidth
+	}
+	height := n.Height
+	if height == 0 {
+		height = diagram.Config.NodeHeight
+	}
+	return domain.Bounds{
+		Min: n.Position,
+		Max: domain.Position{
+			X: n.Position.X + width - 1,
+			Y: n.Position.Y + height - 1,
+		},
+	}
+}
+
+// drawnPoints returns the points of the path that are drawn, leaving out the
+// centres of the nodes at either end.
+func drawnPoints(e domain.Edge) []domain.Position {
+	if e.Path == nil || len(e.Path.Points) < 4 {
+		return nil
+	}
+	return e.Path.Points[1 : len(e.Path.Points)-1]
+}
+
+func truncate(s string, width int) string {
+	r := []rune(s)
+	if len(r) <= width {
+		return s
+	}
+	if width <= 0 {
+		return ""
+	}
+	return string(r[:width-1]) + "…"
+}
+
+func step(d int) int {
+	switch {
+	case d > 0:
+		return 1
+	case d < 0:
+		return -1
+	}
+	return 0
+}
+
+func direction(dx, dy int) int {
+	switch {
+	case dx > 0:
+		return lineRight
+	case dx < 0:
+		return lineLeft
+	case dy > 0:
+		return lineDown
+	case dy < 0:
+		return lineUp
+	}
+	return 0
+}
@@ -0,0 +1,150 @@
+package rendering
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/dnnrly/layli/internal/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestASCIIRenderer_Render(t *testing.T) {
+	t.Run("draws nodes and an arrow between them", func(t *testing.T) {
+		writer := &mockFileWriter{written: map[string][]byte{}}
+		renderer := NewASCIIRenderer(writer)
+
+		diagram := newTestDiagram(
+			[]domain.Node{
+				{ID: "a", Contents: "A", Position: domain.Position{X: 3, Y: 3}, Width: 5, Height: 3},
+				{ID: "b", Contents: "B", Position: domain.Position{X: 10, Y: 3}, Width: 5, Height: 3},
+			},
+			[]domain.Edge{
+				{
+					ID: "e1", From: "a", To: "b",
+					Path: &domain.Path{Points: []domain.Position{
+						{X: 5, Y: 4}, {X: 7, Y: 4}, {X: 10, Y: 4}, {X: 12, Y: 4},
+					}},
+				},
+			},
+		)
+
+		err := renderer.Render(diagram, "output.txt")
+		require.NoError(t, err)
+
+		assert.Equal(t, ""+
+			"┌───────┐     ┌───────┐\n"+
+			"│   A   ├────▶│   B   │\n"+
+			"└───────┘     └───────┘\n",
+			string(writer.written["output.txt"]))
+	})
+
+	t.Run("joins paths that turn corners", func(t *testing.T) {
+		diagram := newTestDiagram(
+			[]domain.Node{
+				{ID: "a", Contents: "A", Position: domain.Position{X: 3, Y: 3}, Width: 5, Height: 3},
+				{ID: "b", Contents: "B", Position: domain.Position{X: 10, Y: 9}, Width: 5, Height: 3},
+			},
+			[]domain.Edge{
+				{
+					ID: "e1", From: "a", To: "b",
+					Path: &domain.Path{Points: []domain.Position{
+						{X: 5, Y: 4}, {X: 5, Y: 5}, {X: 5, Y: 7}, {X: 12, Y: 7}, {X: 12, Y: 9}, {X: 12, Y: 10},
+					}},
+				},
+			},
+		)
+
+		assert.Equal(t, ""+
+			"┌───────┐\n"+
+			"│   A   │\n"+
+			"└───┬───┘\n"+
+			"    │\n"+
+			"    └─────────────┐\n"+
+			"                  ▼\n"+
+			"              ┌───────┐\n"+
+			"              │   B   │\n"+
+			"              └───────┘\n",
+			DrawASCII(diagram))
+	})
+
+	t.Run("puts arrow heads on the ends the edge asks for", func(t *testing.T) {
+		draw := func(arrow domain.ArrowDirection) string {
+			return DrawASCII(newTestDiagram(
+				[]domain.Node{
+					{ID: "a", Contents: "A", Position: domain.Position{X: 3, Y: 3}, Width: 5, Height: 3},
+					{ID: "b", Contents: "B", Position: domain.Position{X: 10, Y: 3}, Width: 5, Height: 3},
+				},
+				[]domain.Edge{
+					{
+						ID: "e1", From: "a", To: "b", Arrow: arrow,
+						Path: &domain.Path{Points: []domain.Position{
+							{X: 5, Y: 4}, {X: 7, Y: 4}, {X: 10, Y: 4}, {X: 12, Y: 4},
+						}},
+					},
+				},
+			))
+		}
+
+		assert.Contains(t, draw(domain.ArrowForward), "│   A   ├────▶│   B   │")
+		assert.Contains(t, draw(domain.ArrowBackward), "│   A   ├◀────┤   B   │")
+		assert.Contains(t, draw(domain.ArrowBoth), "│   A   ├◀───▶│   B   │")
+		assert.Contains(t, draw(domain.ArrowNone), "│   A   ├─────┤   B   │")
+	})
+
+	t.Run("widens the grid to fit contents", func(t *testing.T) {
+		diagram := newTestDiagram(
+			[]domain.Node{
+				{ID: "a", Contents: "A long label", Position: domain.Position{X: 3, Y: 3}, Width: 5, Height: 3},
+			},
+			nil,
+		)
+
+		assert.Equal(t, ""+
+			"┌───────────────┐\n"+
+			"│ A long label  │\n"+
+			"└───────────────┘\n",
+			DrawASCII(diagram))
+	})
+
+	t.Run("truncates contents that cannot fit", func(t *testing.T) {
+		diagram := newTestDiagram(
+			[]domain.Node{
+				{ID: "a", Contents: "This label is far too long to fit", Position: domain.Position{X: 3, Y: 3}, Width: 3, Height: 3},
+			},
+			nil,
+		)
+
+		assert.Contains(t, DrawASCII(diagram), "…")
+	})
+
+	t.Run("shows that there are more lines than rows", func(t *testing.T) {
+		diagram := newTestDiagram(
+			[]domain.Node{
+				{ID: "a", Contents: "First\nSecond", Position: domain.Position{X: 3, Y: 3}, Width: 5, Height: 3},
+			},
+			nil,
+		)
+
+		assert.Equal(t, ""+
+			"┌───────────┐\n"+
+			"│  First…   │\n"+
+			"└───────────┘\n",
+			DrawASCII(diagram))
+	})
+
+	t.Run("empty diagram", func(t *testing.T) {
+		assert.Equal(t, "", DrawASCII(newTestDiagram(nil, nil)))
+	})
+
+	t.Run("write error is propagated", func(t *testing.T) {
+		writer := &mockFileWriter{
+			written: map[string][]byte{},
+			err:     fmt.Errorf("disk full"),
+		}
+		renderer := NewASCIIRenderer(writer)
+
+		err := renderer.Render(newTestDiagram(nil, nil), "fail.txt")
+		assert.ErrorContains(t, err, "disk full")
+	})
+}
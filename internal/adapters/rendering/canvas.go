@@ -0,0 +1,514 @@
+package rendering
+
+import (
+	"fmt"
+	"image/color"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"golang.org/x/image/colornames"
+
+	"github.com/dnnrly/layli/layout"
+)
+
+// The canvases in this file draw the layout in formats other than SVG. The
+// layout makes the same calls and passes the same attributes as it does
+// for the SVG, so the styles of each element are worked out from them in
+// the same way as they are in a browser.
+
+// canvasDefaults are the styles that everything starts with, which are the
+// same as the styles on the group that the SVG is drawn in
+var canvasDefaults = map[string]string{
+	"fill":         "none",
+	"stroke":       "black",
+	"stroke-width": "1",
+	"font-size":    "10px",
+	"text-anchor":  "middle",
+}
+
+// canvasPoint is a point in the coordinates of the SVG
+type canvasPoint struct {
+	x, y float64
+}
+
+// canvasSubpath is a line through a list of points, which may be closed by
+// joining the end back to the start. A point is reached along a cubic Bézier
+// curve instead of a straight line when controls has its 2 control points.
+type canvasSubpath struct {
+	points   []canvasPoint
+	controls map[int][2]canvasPoint
+	closed   bool
+}
+
+// curveTo adds a cubic Bézier curve to the point given
+func (sp *canvasSubpath) curveTo(c1, c2, to canvasPoint) {
+	if sp.controls == nil {
+		sp.controls = map[int][2]canvasPoint{}
+	}
+	sp.controls[len(sp.points)] = [2]canvasPoint{c1, c2}
+	sp.points = append(sp.points, to)
+}
+
+// ellipseTo adds curves around an ellipse, turned by the rotation, from the
+// start angle through the sweep. Each curve turns through a right angle at
+// most so that it stays close to the ellipse.
+func (sp *canvasSubpath) ellipseTo(centre canvasPoint, rx, ry, rotation, start, sweep float64) {
+	cos, sin := math.Cos(rotation), math.Sin(rotation)
+	at := func(x, y float64) canvasPoint {
+		return canvasPoint{centre.x + cos*rx*x - sin*ry*y, centre.y + sin*rx*x + cos*ry*y}
+	}
+
+	n := max(1, int(math.Ceil(math.Abs(sweep)/(math.Pi/2)-1e-9)))
+	step := sweep / float64(n)
+	k := 4.0 / 3 * math.Tan(step/4)
+	for i := 0; i < n; i++ {
+		a, b := start+step*float64(i), start+step*float64(i+1)
+		sp.curveTo(
+			at(math.Cos(a)-k*math.Sin(a), math.Sin(a)+k*math.Cos(a)),
+			at(math.Cos(b)+k*math.Sin(b), math.Sin(b)-k*math.Cos(b)),
+			at(math.Cos(b), math.Sin(b)),
+		)
+	}
+}
+
+// flatten returns the subpath with its curves turned in to straight lines,
+// for drawing on canvases that can only draw those
+func (sp canvasSubpath) flatten() canvasSubpath {
+	if len(sp.controls) == 0 {
+		return sp
+	}
+
+	flat := canvasSubpath{closed: sp.closed}
+	for i, p := range sp.points {
+		if c, curve := sp.controls[i]; curve {
+			from := sp.points[i-1]
+			for j := 1; j < curveSegments; j++ {
+				t := float64(j) / curveSegments
+				u := 1 - t
+				flat.points = append(flat.points, canvasPoint{
+					u*u*u*from.x + 3*u*u*t*c[0].x + 3*u*t*t*c[1].x + t*t*t*p.x,
+					u*u*u*from.y + 3*u*u*t*c[0].y + 3*u*t*t*c[1].y + t*t*t*p.y,
+				})
+			}
+		}
+		flat.points = append(flat.points, p)
+	}
+	return flat
+}
+
+// transform returns the subpath with each of its points moved
+func (sp canvasSubpath) transform(move func(canvasPoint) canvasPoint) canvasSubpath {
+	moved := canvasSubpath{points: make([]canvasPoint, len(sp.points)), closed: sp.closed}
+	for i, p := range sp.points {
+		moved.points[i] = move(p)
+	}
+	for i, c := range sp.controls {
+		if moved.controls == nil {
+			moved.controls = map[int][2]canvasPoint{}
+		}
+		moved.controls[i] = [2]canvasPoint{move(c[0]), move(c[1])}
+	}
+	return moved
+}
+
+// canvasElement is something that is drawn, with its attributes and the
+// styles that apply to it
+type canvasElement struct {
+	attrs map[string]string
+	css   map[string]string
+}
+
+// readElement reads the attributes passed to a drawing call. Anything
+// without an = is a style, in the same way that the SVG canvas treats it.
+func readElement(styles map[string]string, s []string) canvasElement {
+	attrs := map[string]string{}
+	inline := []string{}
+	for _, a := range s {
+		name, value, found := strings.Cut(a, "=")
+		if !found {
+			inline = append(inline, a)
+			continue
+		}
+		attrs[strings.TrimSpace(name)] = strings.Trim(strings.TrimSpace(value), `"`)
+	}
+	if style := attrs["style"]; style != "" {
+		inline = append([]string{style}, inline...)
+	}
+
+	css := map[string]string{}
+	for k, v := range canvasDefaults {
+		css[k] = v
+	}
+	for _, k := range []string{"fill", "stroke", "stroke-width", "stroke-dasharray"} {
+		if v, found := attrs[k]; found {
+			css[k] = v
+		}
+	}
+	for k, v := range resolveCSS(styles, attrs["id"], strings.Fields(attrs["class"]), strings.Join(inline, ";")) {
+		css[k] = v
+	}
+
+	return canvasElement{attrs: attrs, css: css}
+}
+
+// textColour is the colour that text is written in. Text in the SVG has no
+// fill and is outlined by the stroke, so that is used when there is no fill.
+func (el canvasElement) textColour() color.Color {
+	if c := cssPaint(el.css["fill"], nil); c != nil {
+		return c
+	}
+	return cssPaint(el.css["stroke"], color.Black)
+}
+
+var pathTokens = regexp.MustCompile(`[A-Za-z]|[-+]?(?:\d+\.?\d*|\.\d+)(?:[eE][-+]?\d+)?`)
+
+// parsePathData reads the data of an SVG path in to subpaths, turning arcs
+// in to lines and curves. It understands the absolute commands that layli
+// draws with.
+func parsePathData(d string) ([]canvasSubpath, error) {
+	tokens := pathTokens.FindAllString(d, -1)
+	subpaths := []canvasSubpath{}
+	var current *canvasSubpath
+	pos := canvasPoint{}
+
+	next := 0
+	numbers := func(command string, n int) ([]float64, error) {
+		values := make([]float64, n)
+		for i := range values {
+			if next >= len(tokens) {
+				return nil, fmt.Errorf("path %q: %s needs %d numbers", d, command, n)
+			}
+			v, err := strconv.ParseFloat(tokens[next], 64)
+			if err != nil {
+				return nil, fmt.Errorf("path %q: %s needs %d numbers", d, command, n)
+			}
+			values[i] = v
+			next++
+		}
+		return values, nil
+	}
+	// subpath returns the subpath being drawn, starting a new one where the
+	// last one ended when it has been closed
+	subpath := func() *canvasSubpath {
+		if current == nil {
+			subpaths = append(subpaths, canvasSubpath{points: []canvasPoint{pos}})
+			current = &subpaths[len(subpaths)-1]
+		}
+		return current
+	}
+	lineTo := func(p canvasPoint) {
+		sp := subpath()
+		sp.points = append(sp.points, p)
+		pos = p
+	}
+
+	command := ""
+	for next < len(tokens) {
+		if _, err := strconv.ParseFloat(tokens[next], 64); err != nil {
+			command = tokens[next]
+			next++
+		} else if command == "" || command == "Z" {
+			return nil, fmt.Errorf("path %q: expected a command but found %s", d, tokens[next])
+		}
+
+		switch command {
+		case "M":
+			v, err := numbers(command, 2)
+			if err != nil {
+				return nil, err
+			}
+			pos = canvasPoint{v[0], v[1]}
+			subpaths = append(subpaths, canvasSubpath{points: []canvasPoint{pos}})
+			current = &subpaths[len(subpaths)-1]
+			// Any more points after a move are lines
+			command = "L"
+		case "L":
+			v, err := numbers(command, 2)
+			if err != nil {
+				return nil, err
+			}
+			lineTo(canvasPoint{v[0], v[1]})
+		case "H":
+			v, err := numbers(command, 1)
+			if err != nil {
+				return nil, err
+			}
+			lineTo(canvasPoint{v[0], pos.y})
+		case "V":
+			v, err := numbers(command, 1)
+			if err != nil {
+				return nil, err
+			}
+			lineTo(canvasPoint{pos.x, v[0]})
+		case "A":
+			v, err := numbers(command, 7)
+			if err != nil {
+				return nil, err
+			}
+			to := canvasPoint{v[5], v[6]}
+			if to != pos {
+				subpath().arcTo(v[0], v[1], v[2], v[3] != 0, v[4] != 0, to)
+				pos = to
+			}
+		case "Z", "z":
+			command = "Z"
+			if current != nil {
+				current.closed = true
+				pos = current.points[0]
+				current = nil
+			}
+		default:
+			return nil, fmt.Errorf("path %q: unsupported command %s", d, command)
+		}
+	}
+
+	return subpaths, nil
+}
+
+// curveSegments is the number of straight lines that each curve is drawn
+// with when it is flattened
+const curveSegments = 16
+
+// ellipseSubpath returns the outline of an ellipse
+func ellipseSubpath(centre canvasPoint, rx, ry float64) canvasSubpath {
+	sp := canvasSubpath{points: []canvasPoint{{centre.x + rx, centre.y}}, closed: true}
+	sp.ellipseTo(centre, rx, ry, 0, 0, 2*math.Pi)
+	return sp
+}
+
+// roundrectSubpath returns the outline of a rectangle with rounded corners
+func roundrectSubpath(x, y, w, h, rx, ry int) canvasSubpath {
+	x0, y0, x1, y1 := float64(x), float64(y), float64(x+w), float64(y+h)
+	rX := math.Min(float64(rx), float64(w)/2)
+	rY := math.Min(float64(ry), float64(h)/2)
+	if rX <= 0 || rY <= 0 {
+		return canvasSubpath{points: []canvasPoint{{x1, y0}, {x1, y1}, {x0, y1}, {x0, y0}}, closed: true}
+	}
+
+	sp := canvasSubpath{points: []canvasPoint{{x1 - rX, y0}}, closed: true}
+	sp.ellipseTo(canvasPoint{x1 - rX, y0 + rY}, rX, rY, 0, -math.Pi/2, math.Pi/2)
+	sp.points = append(sp.points, canvasPoint{x1, y1 - rY})
+	sp.ellipseTo(canvasPoint{x1 - rX, y1 - rY}, rX, rY, 0, 0, math.Pi/2)
+	sp.points = append(sp.points, canvasPoint{x0 + rX, y1})
+	sp.ellipseTo(canvasPoint{x0 + rX, y1 - rY}, rX, rY, 0, math.Pi/2, math.Pi/2)
+	sp.points = append(sp.points, canvasPoint{x0, y0 + rY})
+	sp.ellipseTo(canvasPoint{x0 + rX, y0 + rY}, rX, rY, 0, math.Pi, math.Pi/2)
+	return sp
+}
+
+// arcTo adds an SVG arc from the end of the subpath. The centre of the
+// ellipse is worked out as described in the SVG specification.
+func (sp *canvasSubpath) arcTo(rx, ry, rotation float64, large, sweep bool, to canvasPoint) {
+	from := sp.points[len(sp.points)-1]
+	rx, ry = math.Abs(rx), math.Abs(ry)
+	if rx == 0 || ry == 0 {
+		sp.points = append(sp.points, to)
+		return
+	}
+
+	phi := rotation * math.Pi / 180
+	cos, sin := math.Cos(phi), math.Sin(phi)
+	dx, dy := (from.x-to.x)/2, (from.y-to.y)/2
+	x1, y1 := cos*dx+sin*dy, -sin*dx+cos*dy
+
+	// Radii that are too small to reach are made big enough
+	if l := x1*x1/(rx*rx) + y1*y1/(ry*ry); l > 1 {
+		rx, ry = rx*math.Sqrt(l), ry*math.Sqrt(l)
+	}
+
+	num := rx*rx*ry*ry - rx*rx*y1*y1 - ry*ry*x1*x1
+	den := rx*rx*y1*y1 + ry*ry*x1*x1
+	co := math.Sqrt(math.Max(0, num/den))
+	if large == sweep {
+		co = -co
+	}
+	cx1, cy1 := co*rx*y1/ry, -co*ry*x1/rx
+	cx := cos*cx1 - sin*cy1 + (from.x+to.x)/2
+	cy := sin*cx1 + cos*cy1 + (from.y+to.y)/2
+
+	start := math.Atan2((y1-cy1)/ry, (x1-cx1)/rx)
+	delta := math.Atan2((-y1-cy1)/ry, (-x1-cx1)/rx) - start
+	if sweep && delta < 0 {
+		delta += 2 * math.Pi
+	} else if !sweep && delta > 0 {
+		delta -= 2 * math.Pi
+	}
+
+	sp.ellipseTo(canvasPoint{cx, cy}, rx, ry, phi, start, delta)
+	sp.points[len(sp.points)-1] = to
+}
+
+// canvasMarker is an arrow head that has been placed on the end of a path
+type canvasMarker struct {
+	outline []canvasSubpath
+	fill    color.Color
+	stroke  color.Color
+	width   float64
+}
+
+// pathMarkers returns the arrow heads on the ends of a path. They are the
+// same as the markers in the SVG, which are 10 units square and scaled by
+// the width of the path.
+func pathMarkers(subpaths []canvasSubpath, el canvasElement) []canvasMarker {
+	colour := cssPaint(el.css["stroke"], color.Black)
+	if len(subpaths) == 0 || colour == nil {
+		return nil
+	}
+	width := cssLength(el.css["stroke-width"], 1)
+
+	markers := []canvasMarker{}
+	if el.attrs["marker-start"] != "" {
+		if tip, back, found := pathEnd(subpaths[0].flatten().points); found {
+			markers = append(markers, placeMarker(tip, back, el.attrs["data-head"], colour, width))
+		}
+	}
+	if el.attrs["marker-end"] != "" {
+		points := subpaths[len(subpaths)-1].flatten().points
+		reversed := make([]canvasPoint, len(points))
+		for i, p := range points {
+			reversed[len(points)-1-i] = p
+		}
+		if tip, back, found := pathEnd(reversed); found {
+			markers = append(markers, placeMarker(tip, back, el.attrs["data-head"], colour, width))
+		}
+	}
+	return markers
+}
+
+// pathEnd returns the first point and the next point that is different to it
+func pathEnd(points []canvasPoint) (canvasPoint, canvasPoint, bool) {
+	for i := 1; i < len(points); i++ {
+		if points[i] != points[0] {
+			return points[0], points[i], true
+		}
+	}
+	return canvasPoint{}, canvasPoint{}, false
+}
+
+// placeMarker places an arrow head with its tip at the end of a path,
+// pointing away from the point before it
+func placeMarker(tip, back canvasPoint, head string, colour color.Color, width float64) canvasMarker {
+	angle := math.Atan2(tip.y-back.y, tip.x-back.x)
+	cos, sin := math.Cos(angle), math.Sin(angle)
+	k := 0.7 * width
+	refX := 10.0
+	if head == layout.HeadCircle {
+		refX = 9
+	}
+
+	at := func(p canvasPoint) canvasPoint {
+		x, y := (p.x-refX)*k, (p.y-5)*k
+		return canvasPoint{tip.x + x*cos - y*sin, tip.y + x*sin + y*cos}
+	}
+	place := func(points ...canvasPoint) []canvasPoint {
+		placed := make([]canvasPoint, len(points))
+		for i, p := range points {
+			placed[i] = at(p)
+		}
+		return placed
+	}
+
+	m := canvasMarker{fill: colour, stroke: colour, width: k}
+	switch head {
+	case layout.HeadOpenArrow:
+		m.fill = nil
+		m.outline = []canvasSubpath{{points: place(canvasPoint{0, 1}, canvasPoint{10, 5}, canvasPoint{0, 9})}}
+	case layout.HeadDiamond, layout.HeadHollowDiamond:
+		if head == layout.HeadHollowDiamond {
+			m.fill = color.White
+		}
+		m.outline = []canvasSubpath{{points: place(canvasPoint{0, 5}, canvasPoint{5, 1}, canvasPoint{10, 5}, canvasPoint{5, 9}), closed: true}}
+	case layout.HeadCircle:
+		m.fill = color.White
+		m.outline = []canvasSubpath{ellipseSubpath(canvasPoint{5, 5}, 4, 4).transform(at)}
+	case layout.HeadCrowsFoot:
+		m.fill = nil
+		m.outline = []canvasSubpath{
+			{points: place(canvasPoint{0, 5}, canvasPoint{10, 1})},
+			{points: place(canvasPoint{0, 5}, canvasPoint{10, 5})},
+			{points: place(canvasPoint{0, 5}, canvasPoint{10, 9})},
+		}
+	default:
+		m.outline = []canvasSubpath{{points: place(canvasPoint{0, 0}, canvasPoint{10, 5}, canvasPoint{0, 10}), closed: true}}
+	}
+	return m
+}
+
+// cssDashes reads a stroke-dasharray. Lists with an odd number of lengths
+// are repeated, in the same way as SVG.
+func cssDashes(s string) []float64 {
+	fields := strings.FieldsFunc(s, func(r rune) bool { return r == ',' || r == ' ' })
+	dashes := []float64{}
+	total := 0.0
+	for _, f := range fields {
+		v, err := strconv.ParseFloat(strings.TrimSuffix(f, "px"), 64)
+		if err != nil || v < 0 {
+			return nil
+		}
+		dashes = append(dashes, v)
+		total += v
+	}
+	if total <= 0 {
+		return nil
+	}
+	if len(dashes)%2 == 1 {
+		dashes = append(dashes, dashes...)
+	}
+	return dashes
+}
+
+// cssLength reads a length in pixels, returning the default if there isn't
+// one
+func cssLength(s string, def float64) float64 {
+	v, err := strconv.ParseFloat(strings.TrimSuffix(strings.TrimSpace(s), "px"), 64)
+	if err != nil {
+		return def
+	}
+	return v
+}
+
+// cssPaint reads a CSS colour. Paint that is none or transparent is nil, and
+// colours that can't be read are the default instead.
+func cssPaint(s string, def color.Color) color.Color {
+	s = strings.ToLower(strings.TrimSpace(s))
+	switch {
+	case s == "none" || s == "transparent":
+		return nil
+	case strings.HasPrefix(s, "#"):
+		hex := s[1:]
+		if len(hex) == 3 {
+			hex = string([]byte{hex[0], hex[0], hex[1], hex[1], hex[2], hex[2]})
+		}
+		v, err := strconv.ParseUint(hex, 16, 32)
+		if len(hex) != 6 || err != nil {
+			return def
+		}
+		return color.RGBA{R: uint8(v >> 16), G: uint8(v >> 8), B: uint8(v), A: 0xff}
+	case strings.HasPrefix(s, "rgb(") && strings.HasSuffix(s, ")"):
+		parts := strings.Split(s[4:len(s)-1], ",")
+		if len(parts) != 3 {
+			return def
+		}
+		rgb := [3]uint8{}
+		for i, p := range parts {
+			v, err := strconv.Atoi(strings.TrimSpace(p))
+			if err != nil {
+				return def
+			}
+			rgb[i] = uint8(min(255, max(0, v)))
+		}
+		return color.RGBA{R: rgb[0], G: rgb[1], B: rgb[2], A: 0xff}
+	}
+
+	if c, found := colornames.Map[s]; found {
+		return c
+	}
+	return def
+}
+
+// formatNumber formats a number with at most 2 decimal places
+func formatNumber(f float64) string {
+	return strconv.FormatFloat(math.Round(f*100)/100, 'f', -1, 64)
+}
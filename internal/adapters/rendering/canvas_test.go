@@ -0,0 +1,50 @@
+package rendering
+
+import (
+	"image/color"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParsePathData(t *testing.T) {
+	t.Run("lines and arcs", func(t *testing.T) {
+		subpaths, err := parsePathData("M 0 0 L 10 0 10 10 z M 20 0 A 5 5 0 0 1 30 0")
+		require.NoError(t, err)
+
+		require.Len(t, subpaths, 2)
+		assert.Equal(t, canvasSubpath{points: []canvasPoint{{0, 0}, {10, 0}, {10, 10}}, closed: true}, subpaths[0])
+		arc := subpaths[1].points
+		require.Len(t, arc, 3, "half a circle is 2 curves")
+		assert.Equal(t, canvasPoint{30, 0}, arc[2])
+		assert.InDelta(t, 25, arc[1].x, 0.01)
+		assert.InDelta(t, -5, arc[1].y, 0.01, "the arc goes over the top")
+		assert.Len(t, subpaths[1].controls, 2)
+
+		flat := subpaths[1].flatten()
+		assert.Len(t, flat.points, 2*curveSegments+1)
+		assert.Empty(t, flat.controls)
+		for _, p := range flat.points {
+			assert.InDelta(t, 5, math.Hypot(p.x-25, p.y), 0.01, "the curves stay on the circle")
+		}
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		_, err := parsePathData("M 0 0 Q 1 1 2 2")
+		assert.EqualError(t, err, `path "M 0 0 Q 1 1 2 2": unsupported command Q`)
+
+		_, err = parsePathData("M 0 0 L 1")
+		assert.EqualError(t, err, `path "M 0 0 L 1": L needs 2 numbers`)
+	})
+}
+
+func TestCSSPaint(t *testing.T) {
+	assert.Equal(t, color.RGBA{0x11, 0x22, 0x33, 0xff}, cssPaint("#123", nil))
+	assert.Equal(t, color.RGBA{0xab, 0xcd, 0xef, 0xff}, cssPaint("#ABCDEF", nil))
+	assert.Equal(t, color.RGBA{1, 2, 255, 0xff}, cssPaint("rgb(1, 2, 300)", nil))
+	assert.Equal(t, color.RGBA{0xff, 0xa5, 0x00, 0xff}, cssPaint("orange", nil))
+	assert.Nil(t, cssPaint("none", color.Black))
+	assert.Equal(t, color.Black, cssPaint("not-a-colour", color.Black))
+}
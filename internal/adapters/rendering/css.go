@@ -0,0 +1,47 @@
+package rendering
+
+import (
+	"strings"
+)
+
+// cssDeclaration is a single property and its value from a block of CSS
+type cssDeclaration struct {
+	property string
+	value    string
+}
+
+// parseCSS splits a block of CSS declarations in to properties and values,
+// keeping them in order
+func parseCSS(css string) []cssDeclaration {
+	decls := []cssDeclaration{}
+	for _, d := range strings.Split(css, ";") {
+		property, value, found := strings.Cut(d, ":")
+		if !found || strings.TrimSpace(property) == "" {
+			continue
+		}
+		decls = append(decls, cssDeclaration{
+			property: strings.TrimSpace(property),
+			value:    strings.TrimSpace(value),
+		})
+	}
+	return decls
+}
+
+// resolveCSS works out the properties that apply to an element from the
+// styles for its classes, then its ID and then its own style, in the same
+// order that they win in the SVG. Later values replace earlier ones.
+func resolveCSS(styles map[string]string, id string, classes []string, style string) map[string]string {
+	props := map[string]string{}
+	apply := func(css string) {
+		for _, d := range parseCSS(css) {
+			props[d.property] = d.value
+		}
+	}
+
+	for _, c := range classes {
+		apply(styles["."+c])
+	}
+	apply(styles["#"+id])
+	apply(style)
+	return props
+}
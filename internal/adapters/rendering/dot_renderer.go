@@ -0,0 +1,225 @@
+package rendering
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/dnnrly/layli/internal/domain"
+	"github.com/dnnrly/layli/internal/usecases"
+)
+
+var _ usecases.Renderer = (*DOTRenderer)(nil)
+
+// DOTRenderer writes a diagram as a Graphviz DOT file so that it can be used
+// by tools that only understand DOT. The positions that layli chose are kept
+// as pos hints, which Graphviz uses when it is run with neato -n.
+type DOTRenderer struct {
+	writer usecases.FileWriter
+}
+
+func NewDOTRenderer(writer usecases.FileWriter) *DOTRenderer {
+	return &DOTRenderer{writer: writer}
+}
+
+func (r *DOTRenderer) Render(diagram *domain.Diagram, outputPath string) error {
+	return r.writer.Write(outputPath, []byte(ToDOT(diagram)))
+}
+
+// pointsPerInch is the number of Graphviz points in an inch. Sizes are
+// given in inches and positions in points, with 1 point for each pixel.
+const pointsPerInch = 72
+
+// dotShapes maps layli shapes on to Graphviz shapes
+var dotShapes = map[domain.NodeShape]string{
+	domain.ShapeRect:     "box",
+	domain.ShapeEllipse:  "ellipse",
+	domain.ShapeDiamond:  "diamond",
+	domain.ShapeCylinder: "cylinder",
+	domain.ShapeHexagon:  "hexagon",
+	domain.ShapeNote:     "note",
+}
+
+// dotArrowHeads maps layli arrow heads on to Graphviz arrow shapes
+var dotArrowHeads = map[domain.ArrowHead]string{
+	domain.HeadOpenArrow:     "vee",
+	domain.HeadDiamond:       "diamond",
+	domain.HeadHollowDiamond: "odiamond",
+	domain.HeadCircle:        "dot",
+	domain.HeadCrowsFoot:     "crow",
+}
+
+// ToDOT returns the diagram as a Graphviz digraph
+func ToDOT(diagram *domain.Diagram) string {
+	out := &strings.Builder{}
+	out.WriteString("digraph {\n")
+
+	spacing := float64(diagram.Config.Spacing)
+	bottom := 0
+	for _, n := range diagram.Nodes {
+		if b := n.Position.Y + n.Height; b > bottom {
+			bottom = b
+		}
+	}
+
+	for _, n := range diagram.Nodes {
+		attrs := [][2]string{{"label", n.Contents}}
+
+		shape, found := dotShapes[n.Shape]
+		styles := []string{}
+		if !found {
+			// Graphviz can't draw people, so they get the default box
+			shape = "box"
+			if n.Shape != domain.ShapePerson {
+				styles = append(styles, "rounded")
+			}
+		}
+		attrs = append(attrs, [2]string{"shape", shape})
+
+		css := resolveCSS(diagram.Config.Styles, n.ID, strings.Fields(n.Class), n.Style)
+		if css["fill"] != "" && css["fill"] != "none" {
+			styles = append(styles, "filled")
+		}
+		attrs = append(attrs, dotStyleAttrs(css, styles)...)
+		if n.Class != "" {
+			attrs = append(attrs, [2]string{"class", n.Class})
+		}
+
+		// Graphviz measures from the bottom left corner to the middle of
+		// the node
+		x := (float64(n.Position.X) + float64(n.Width-1)/2) * spacing
+		y := (float64(bottom) - float64(n.Position.Y) - float64(n.Height-1)/2) * spacing
+		attrs = append(attrs,
+			[2]string{"pos", fmt.Sprintf("%s,%s!", formatNumber(x), formatNumber(y))},
+			[2]string{"width", formatNumber(float64(n.Width-1) * spacing / pointsPerInch)},
+			[2]string{"height", formatNumber(float64(n.Height-1) * spacing / pointsPerInch)},
+		)
+
+		fmt.Fprintf(out, "    %s%s;\n", dotQuote(n.ID), dotAttrs(attrs))
+	}
+
+	writeDOTClusters(out, diagram.Groups, "    ")
+
+	for _, e := range diagram.Edges {
+		attrs := [][2]string{{"id", e.ID}}
+		if e.Label != "" {
+			attrs = append(attrs, [2]string{"label", e.Label})
+		}
+
+		head, found := dotArrowHeads[e.Head]
+		switch {
+		case !e.ArrowAtStart() && !e.ArrowAtEnd():
+			attrs = append(attrs, [2]string{"dir", "none"})
+		case e.ArrowAtStart() && e.ArrowAtEnd():
+			attrs = append(attrs, [2]string{"dir", "both"})
+			if found {
+				attrs = append(attrs, [2]string{"arrowhead", head}, [2]string{"arrowtail", head})
+			}
+		case e.ArrowAtStart():
+			attrs = append(attrs, [2]string{"dir", "back"})
+			if found {
+				attrs = append(attrs, [2]string{"arrowtail", head})
+			}
+		case found:
+			attrs = append(attrs, [2]string{"arrowhead", head})
+		}
+
+		if p := dotCompass(e.FromPort); p != "" {
+			attrs = append(attrs, [2]string{"tailport", p})
+		}
+		if p := dotCompass(e.ToPort); p != "" {
+			attrs = append(attrs, [2]string{"headport", p})
+		}
+
+		classes := append([]string{"path-line"}, strings.Fields(e.Class)...)
+		css := resolveCSS(diagram.Config.Styles, e.ID, classes, e.Style)
+		attrs = append(attrs, dotStyleAttrs(css, nil)...)
+		if e.Class != "" {
+			attrs = append(attrs, [2]string{"class", e.Class})
+		}
+
+		fmt.Fprintf(out, "    %s -> %s%s;\n", dotQuote(e.From), dotQuote(e.To), dotAttrs(attrs))
+	}
+
+	out.WriteString("}\n")
+	return out.String()
+}
+
+// writeDOTClusters writes groups as clusters. Graphviz only draws a box
+// around subgraphs whose names start with cluster.
+func writeDOTClusters(out *strings.Builder, groups []domain.Group, indent string) {
+	for _, g := range groups {
+		name := g.ID
+		if !strings.HasPrefix(name, "cluster") {
+			name = "cluster_" + name
+		}
+
+		fmt.Fprintf(out, "%ssubgraph %s {\n", indent, dotQuote(name))
+		if g.Label != "" {
+			fmt.Fprintf(out, "%s    label=%s;\n", indent, dotQuote(g.Label))
+		}
+		for _, id := range g.Nodes {
+			fmt.Fprintf(out, "%s    %s;\n", indent, dotQuote(id))
+		}
+		writeDOTClusters(out, g.Groups, indent+"    ")
+		fmt.Fprintf(out, "%s}\n", indent)
+	}
+}
+
+// dotStyleAttrs turns the CSS properties that Graphviz has attributes for
+// in to those attributes. The styles given are added to the style
+// attribute, such as the rounded corners of a node.
+func dotStyleAttrs(css map[string]string, styles []string) [][2]string {
+	attrs := [][2]string{}
+	if c := css["stroke"]; c != "" {
+		attrs = append(attrs, [2]string{"color", c})
+	}
+	if c := css["fill"]; c != "" && c != "none" {
+		attrs = append(attrs, [2]string{"fillcolor", c})
+	}
+	if w := strings.TrimSuffix(css["stroke-width"], "px"); w != "" {
+		attrs = append(attrs, [2]string{"penwidth", w})
+	}
+	if d := css["stroke-dasharray"]; d != "" && d != "none" {
+		styles = append(styles, "dashed")
+	}
+	if len(styles) != 0 {
+		attrs = append(attrs, [2]string{"style", strings.Join(styles, ",")})
+	}
+	return attrs
+}
+
+// dotCompass returns the compass point for a side of a node. Named ports
+// don't exist in Graphviz so they are left out.
+func dotCompass(port string) string {
+	switch port {
+	case "north":
+		return "n"
+	case "south":
+		return "s"
+	case "east":
+		return "e"
+	case "west":
+		return "w"
+	}
+	return ""
+}
+
+func dotAttrs(attrs [][2]string) string {
+	if len(attrs) == 0 {
+		return ""
+	}
+	parts := make([]string, len(attrs))
+	for i, a := range attrs {
+		parts[i] = a[0] + "=" + dotQuote(a[1])
+	}
+	return " [" + strings.Join(parts, ", ") + "]"
+}
+
+// dotQuote quotes a string so that it can be used as an ID, keeping line
+// breaks as the escape that Graphviz uses for them
+func dotQuote(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	s = strings.ReplaceAll(s, `"`, `\"`)
+	s = strings.ReplaceAll(s, "\n", `\n`)
+	return `"` + s + `"`
+}
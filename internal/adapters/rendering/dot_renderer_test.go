@@ -0,0 +1,114 @@
+package rendering
+
+import (
+	"testing"
+
+	"github.com/dnnrly/layli/internal/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDOTRenderer_Render(t *testing.T) {
+	t.Run("writes nodes and edges with their positions", func(t *testing.T) {
+		writer := &mockFileWriter{written: map[string][]byte{}}
+		renderer := NewDOTRenderer(writer)
+
+		diagram := newTestDiagram(
+			[]domain.Node{
+				{ID: "a", Contents: "A", Position: domain.Position{X: 3, Y: 3}, Width: 5, Height: 3},
+				{ID: "b", Contents: "B\nsecond", Position: domain.Position{X: 10, Y: 7}, Width: 5, Height: 3, Shape: domain.ShapeCylinder},
+			},
+			[]domain.Edge{
+				{ID: "e1", From: "a", To: "b", Label: "calls"},
+			},
+		)
+
+		err := renderer.Render(diagram, "output.dot")
+		require.NoError(t, err)
+
+		assert.Equal(t, ""+
+			"digraph {\n"+
+			"    \"a\" [label=\"A\", shape=\"box\", style=\"rounded\", pos=\"100,120!\", width=\"1.11\", height=\"0.56\"];\n"+
+			"    \"b\" [label=\"B\\nsecond\", shape=\"cylinder\", pos=\"240,40!\", width=\"1.11\", height=\"0.56\"];\n"+
+			"    \"a\" -> \"b\" [id=\"e1\", label=\"calls\"];\n"+
+			"}\n",
+			string(writer.written["output.dot"]))
+	})
+
+	t.Run("turns styles in to attributes", func(t *testing.T) {
+		diagram := newTestDiagram(
+			[]domain.Node{
+				{ID: "a", Contents: "A", Width: 5, Height: 3, Shape: domain.ShapeRect, Class: "store", Style: "stroke-width: 2px"},
+				{ID: "b", Contents: "B", Width: 5, Height: 3, Shape: domain.ShapePerson},
+			},
+			[]domain.Edge{
+				{ID: "e1", From: "a", To: "b", Class: "async"},
+			},
+		)
+		diagram.Config.Styles = map[string]string{
+			".store":     "fill: #eef; stroke: #336;",
+			"#a":         "stroke: red;",
+			".path-line": "stroke: #333;",
+			".async":     "stroke-dasharray: 4;",
+			".unrelated": "fill: green;",
+		}
+
+		dot := ToDOT(diagram)
+		assert.Contains(t, dot, `"a" [label="A", shape="box", color="red", fillcolor="#eef", penwidth="2", style="filled", class="store",`)
+		assert.Contains(t, dot, `"b" [label="B", shape="box", pos=`, "people are drawn as plain boxes")
+		assert.Contains(t, dot, `"a" -> "b" [id="e1", color="#333", style="dashed", class="async"];`)
+	})
+
+	t.Run("arrows and ports", func(t *testing.T) {
+		diagram := newTestDiagram(
+			[]domain.Node{
+				{ID: "a", Contents: "A", Width: 5, Height: 3},
+				{ID: "b", Contents: "B", Width: 5, Height: 3},
+			},
+			[]domain.Edge{
+				{ID: "e1", From: "a", To: "b", Arrow: domain.ArrowNone},
+				{ID: "e2", From: "a", To: "b", Arrow: domain.ArrowBoth, Head: domain.HeadHollowDiamond},
+				{ID: "e3", From: "a", To: "b", Arrow: domain.ArrowBackward, Head: domain.HeadCrowsFoot},
+				{ID: "e4", From: "a", To: "b", Head: domain.HeadOpenArrow, FromPort: "south", ToPort: "west"},
+				{ID: "e5", From: "a", To: "b", FromPort: "http"},
+			},
+		)
+
+		dot := ToDOT(diagram)
+		assert.Contains(t, dot, `"a" -> "b" [id="e1", dir="none"];`)
+		assert.Contains(t, dot, `"a" -> "b" [id="e2", dir="both", arrowhead="odiamond", arrowtail="odiamond"];`)
+		assert.Contains(t, dot, `"a" -> "b" [id="e3", dir="back", arrowtail="crow"];`)
+		assert.Contains(t, dot, `"a" -> "b" [id="e4", arrowhead="vee", tailport="s", headport="w"];`)
+		assert.Contains(t, dot, `"a" -> "b" [id="e5"];`, "named ports are left out")
+	})
+
+	t.Run("groups are written as clusters", func(t *testing.T) {
+		diagram := newTestDiagram(
+			[]domain.Node{
+				{ID: "a", Contents: "A", Width: 5, Height: 3},
+				{ID: "b", Contents: "B", Width: 5, Height: 3},
+			},
+			nil,
+		)
+		diagram.Groups = []domain.Group{
+			{ID: "outer", Label: "Outer", Nodes: []string{"a"}, Groups: []domain.Group{
+				{ID: "cluster_inner", Nodes: []string{"b"}},
+			}},
+		}
+
+		assert.Contains(t, ToDOT(diagram), ""+
+			"    subgraph \"cluster_outer\" {\n"+
+			"        label=\"Outer\";\n"+
+			"        \"a\";\n"+
+			"        subgraph \"cluster_inner\" {\n"+
+			"            \"b\";\n"+
+			"        }\n"+
+			"    }\n")
+	})
+
+	t.Run("returns write errors", func(t *testing.T) {
+		writer := &mockFileWriter{written: map[string][]byte{}, err: assert.AnError}
+		err := NewDOTRenderer(writer).Render(newTestDiagram(nil, nil), "output.dot")
+		assert.ErrorIs(t, err, assert.AnError)
+	})
+}
@@ -0,0 +1,335 @@
+package rendering
+
+import (
+	"encoding/xml"
+	"fmt"
+	"strings"
+
+	"github.com/dnnrly/layli/internal/domain"
+	"github.com/dnnrly/layli/internal/usecases"
+	"github.com/dnnrly/layli/layout"
+)
+
+var _ usecases.Renderer = (*DrawIORenderer)(nil)
+
+// DrawIORenderer writes a diagram as a draw.io (diagrams.net) file so that it
+// can be changed by hand. Nodes and groups are placed where layli put them
+// and each edge follows its path, so the diagram opens looking the same.
+type DrawIORenderer struct {
+	writer usecases.FileWriter
+}
+
+func NewDrawIORenderer(writer usecases.FileWriter) *DrawIORenderer {
+	return &DrawIORenderer{writer: writer}
+}
+
+func (r *DrawIORenderer) Render(diagram *domain.Diagram, outputPath string) error {
+	out, err := ToDrawIO(diagram)
+	if err != nil {
+		return fmt.Errorf("rendering draw.io: %w", err)
+	}
+	return r.writer.Write(outputPath, out)
+}
+
+// The cells that every draw.io diagram starts with, which everything else is
+// drawn on
+const (
+	drawIORoot  = "layli-root"
+	drawIOLayer = "layli-layer"
+)
+
+// drawIOID returns the ID of the cell for a node, edge or group. The kind is
+// put in front of the ID as they can share IDs in layli, but not in draw.io.
+func drawIOID(kind, id string) string {
+	return kind + "-" + id
+}
+
+// drawIOShapes maps layli shapes on to draw.io styles
+var drawIOShapes = map[domain.NodeShape]string{
+	domain.ShapeRect:     "rounded=0",
+	domain.ShapeEllipse:  "ellipse",
+	domain.ShapeDiamond:  "rhombus",
+	domain.ShapeCylinder: "shape=cylinder3;boundedLbl=1",
+	domain.ShapeHexagon:  "shape=hexagon;perimeter=hexagonPerimeter2",
+	domain.ShapeNote:     "shape=note;size=10",
+	domain.ShapePerson:   "shape=actor;verticalLabelPosition=bottom;verticalAlign=top",
+}
+
+// drawIOArrowHeads maps layli arrow heads on to draw.io arrows and whether
+// they are filled in
+var drawIOArrowHeads = map[domain.ArrowHead]struct {
+	arrow  string
+	filled bool
+}{
+	domain.HeadTriangle:      {"block", true},
+	domain.HeadOpenArrow:     {"open", false},
+	domain.HeadDiamond:       {"diamond", true},
+	domain.HeadHollowDiamond: {"diamond", false},
+	domain.HeadCircle:        {"oval", true},
+	domain.HeadCrowsFoot:     {"ERmany", false},
+}
+
+type drawIOFile struct {
+	XMLName xml.Name      `xml:"mxfile"`
+	Host    string        `xml:"host,attr"`
+	Diagram drawIODiagram `xml:"diagram"`
+}
+
+type drawIODiagram struct {
+	ID    string           `xml:"id,attr"`
+	Name  string           `xml:"name,attr"`
+	Model drawIOGraphModel `xml:"mxGraphModel"`
+}
+
+type drawIOGraphModel struct {
+	Grid     int          `xml:"grid,attr"`
+	GridSize int          `xml:"gridSize,attr"`
+	Cells    []drawIOCell `xml:"root>mxCell"`
+}
+
+type drawIOCell struct {
+	ID       string          `xml:"id,attr"`
+	Value    string          `xml:"value,attr,omitempty"`
+	Style    string          `xml:"style,attr,omitempty"`
+	Vertex   string          `xml:"vertex,attr,omitempty"`
+	Edge     string          `xml:"edge,attr,omitempty"`
+	Parent   string          `xml:"parent,attr,omitempty"`
+	Source   string          `xml:"source,attr,omitempty"`
+	Target   string          `xml:"target,attr,omitempty"`
+	Geometry *drawIOGeometry `xml:"mxGeometry"`
+}
+
+type drawIOGeometry struct {
+	X        string        `xml:"x,attr,omitempty"`
+	Y        string        `xml:"y,attr,omitempty"`
+	Width    string        `xml:"width,attr,omitempty"`
+	Height   string        `xml:"height,attr,omitempty"`
+	Relative string        `xml:"relative,attr,omitempty"`
+	As       string        `xml:"as,attr"`
+	Points   *drawIOPoints `xml:"Array"`
+}
+
+type drawIOPoints struct {
+	As     string        `xml:"as,attr"`
+	Points []drawIOPoint `xml:"mxPoint"`
+}
+
+type drawIOPoint struct {
+	X string `xml:"x,attr"`
+	Y string `xml:"y,attr"`
+}
+
+// ToDrawIO returns the diagram as an uncompressed draw.io file. Nodes sit
+// inside the groups that they belong to so that they move with them.
+func ToDrawIO(diagram *domain.Diagram) ([]byte, error) {
+	l := buildLayout(diagram)
+	spacing := diagram.Config.Spacing
+
+	cells := []drawIOCell{
+		{ID: drawIORoot},
+		{ID: drawIOLayer, Parent: drawIORoot},
+	}
+
+	// Cells inside a group are placed relative to it
+	parents := groupParents(l.Groups)
+	origins := map[string]canvasPoint{drawIOLayer: {}}
+	place := func(cell, id string, x, y float64) (string, canvasPoint) {
+		parent := drawIOLayer
+		if g, found := parents[id]; found {
+			parent = drawIOID("group", g)
+		}
+		origins[cell] = canvasPoint{x, y}
+		return parent, canvasPoint{x - origins[parent].x, y - origins[parent].y}
+	}
+
+	for _, g := range l.Groups {
+		x := float64(g.Left()*spacing - spacing/2)
+		y := float64(g.Top()*spacing - spacing/2)
+		id := drawIOID("group", g.Id)
+		parent, at := place(id, g.Id, x, y)
+
+		css := resolveCSS(diagram.Config.Styles, g.Id, []string{"group"}, "")
+		if css["stroke-dasharray"] == "" {
+			css["stroke-dasharray"] = "6 3"
+		}
+		if css["fill"] == "" {
+			css["fill"] = "none"
+		}
+		style := []string{"rounded=1", "absoluteArcSize=1", "arcSize=12", "container=1", "collapsible=0",
+			"verticalAlign=top", "align=left", "spacingLeft=4", "whiteSpace=wrap"}
+
+		cells = append(cells, drawIOCell{
+			ID:     id,
+			Value:  g.Label,
+			Style:  drawIOStyle(append(style, drawIOStyleProps(css)...)),
+			Vertex: "1",
+			Parent: parent,
+			Geometry: &drawIOGeometry{
+				X:      formatNumber(at.x),
+				Y:      formatNumber(at.y),
+				Width:  formatNumber(float64((g.Right() - g.Left() + 1) * spacing)),
+				Height: formatNumber(float64((g.Bottom() - g.Top() + 1) * spacing)),
+				As:     "geometry",
+			},
+		})
+	}
+
+	for _, n := range diagram.Nodes {
+		ln := l.Nodes.ByID(n.ID)
+		if ln == nil {
+			continue
+		}
+		id := drawIOID("node", n.ID)
+		parent, at := place(id, n.ID, float64(ln.Left()*spacing), float64(ln.Top()*spacing))
+
+		shape, found := drawIOShapes[n.Shape]
+		if !found {
+			shape = "rounded=1;absoluteArcSize=1;arcSize=6"
+		}
+		css := resolveCSS(diagram.Config.Styles, n.ID, strings.Fields(n.Class), n.Style)
+		style := append([]string{shape, "whiteSpace=wrap"}, drawIOStyleProps(css)...)
+
+		cells = append(cells, drawIOCell{
+			ID:     id,
+			Value:  n.Contents,
+			Style:  drawIOStyle(style),
+			Vertex: "1",
+			Parent: parent,
+			Geometry: &drawIOGeometry{
+				X:      formatNumber(at.x),
+				Y:      formatNumber(at.y),
+				Width:  formatNumber(float64((ln.Width() - 1) * spacing)),
+				Height: formatNumber(float64((ln.Height() - 1) * spacing)),
+				As:     "geometry",
+			},
+		})
+	}
+
+	for _, e := range diagram.Edges {
+		cells = append(cells, drawIOEdge(diagram, l.Nodes, e))
+	}
+
+	file := drawIOFile{
+		Host: "layli",
+		Diagram: drawIODiagram{
+			ID:   "layli",
+			Name: "Page-1",
+			Model: drawIOGraphModel{
+				Grid:     1,
+				GridSize: spacing,
+				Cells:    cells,
+			},
+		},
+	}
+
+	out, err := xml.MarshalIndent(file, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(out, '\n'), nil
+}
+
+// drawIOEdge returns an orthogonal edge that leaves and enters its nodes at
+// the same ports as its path, bending at the same corners
+func drawIOEdge(diagram *domain.Diagram, nodes layout.LayoutNodes, e domain.Edge) drawIOCell {
+	spacing := diagram.Config.Spacing
+	style := []string{"edgeStyle=orthogonalEdgeStyle", "rounded=0", "html=0"}
+
+	geometry := &drawIOGeometry{Relative: "1", As: "geometry"}
+	if route := edgeRoute(e); route != nil {
+		if x, y, found := drawIOPort(nodes.ByID(e.From), route[0]); found {
+			style = append(style, "exitX="+x, "exitY="+y, "exitDx=0", "exitDy=0")
+		}
+		if x, y, found := drawIOPort(nodes.ByID(e.To), route[len(route)-1]); found {
+			style = append(style, "entryX="+x, "entryY="+y, "entryDx=0", "entryDy=0")
+		}
+
+		points := []drawIOPoint{}
+		for _, p := range route[1 : len(route)-1] {
+			points = append(points, drawIOPoint{
+				X: formatNumber(float64(p.X * spacing)),
+				Y: formatNumber(float64(p.Y * spacing)),
+			})
+		}
+		if len(points) != 0 {
+			geometry.Points = &drawIOPoints{As: "points", Points: points}
+		}
+	}
+
+	end, start := "none", "none"
+	head, found := drawIOArrowHeads[e.Head]
+	if !found {
+		head = drawIOArrowHeads[domain.HeadTriangle]
+	}
+	if e.ArrowAtEnd() {
+		end = head.arrow
+	}
+	if e.ArrowAtStart() {
+		start = head.arrow
+	}
+	style = append(style,
+		"endArrow="+end, fmt.Sprintf("endFill=%d", drawIOFlag(head.filled)),
+		"startArrow="+start, fmt.Sprintf("startFill=%d", drawIOFlag(head.filled)),
+	)
+
+	classes := append([]string{"path-line"}, strings.Fields(e.Class)...)
+	css := resolveCSS(diagram.Config.Styles, e.ID, classes, e.Style)
+	delete(css, "fill")
+	style = append(style, drawIOStyleProps(css)...)
+
+	return drawIOCell{
+		ID:       drawIOID("edge", e.ID),
+		Value:    e.Label,
+		Style:    drawIOStyle(style),
+		Edge:     "1",
+		Parent:   drawIOLayer,
+		Source:   drawIOID("node", e.From),
+		Target:   drawIOID("node", e.To),
+		Geometry: geometry,
+	}
+}
+
+// drawIOPort returns where a port is on the outline of a node, as fractions
+// of its width and height
+func drawIOPort(n *layout.LayoutNode, port domain.Position) (string, string, bool) {
+	if n == nil || n.Width() < 2 || n.Height() < 2 {
+		return "", "", false
+	}
+	x := float64(port.X-n.Left()) / float64(n.Width()-1)
+	y := float64(port.Y-n.Top()) / float64(n.Height()-1)
+	if x < 0 || x > 1 || y < 0 || y > 1 {
+		return "", "", false
+	}
+	return formatNumber(x), formatNumber(y), true
+}
+
+// drawIOStyleProps turns the CSS properties that draw.io has styles for in
+// to those styles
+func drawIOStyleProps(css map[string]string) []string {
+	style := []string{}
+	if c := css["stroke"]; c != "" {
+		style = append(style, "strokeColor="+c)
+	}
+	if c := css["fill"]; c != "" {
+		style = append(style, "fillColor="+c)
+	}
+	if w := strings.TrimSuffix(css["stroke-width"], "px"); w != "" {
+		style = append(style, "strokeWidth="+w)
+	}
+	if d := css["stroke-dasharray"]; d != "" && d != "none" {
+		style = append(style, "dashed=1", "dashPattern="+strings.Join(strings.Fields(strings.ReplaceAll(d, ",", " ")), " "))
+	}
+	return style
+}
+
+// drawIOStyle joins styles in to a draw.io style string
+func drawIOStyle(style []string) string {
+	return strings.Join(style, ";") + ";"
+}
+
+func drawIOFlag(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
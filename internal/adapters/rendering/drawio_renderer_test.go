@@ -0,0 +1,193 @@
+package rendering
+
+import (
+	"encoding/xml"
+	"testing"
+
+	"github.com/dnnrly/layli/internal/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// drawIOCells reads the cells back out of a draw.io file by their IDs
+func drawIOCells(t *testing.T, out []byte) map[string]drawIOCell {
+	t.Helper()
+	file := drawIOFile{}
+	require.NoError(t, xml.Unmarshal(out, &file))
+
+	cells := map[string]drawIOCell{}
+	for _, c := range file.Diagram.Model.Cells {
+		cells[c.ID] = c
+	}
+	return cells
+}
+
+func TestDrawIORenderer_Render(t *testing.T) {
+	t.Run("places nodes and edges where the layout put them", func(t *testing.T) {
+		writer := &mockFileWriter{written: map[string][]byte{}}
+		renderer := NewDrawIORenderer(writer)
+
+		diagram := newTestDiagram(
+			[]domain.Node{
+				{ID: "a", Contents: "A\nsecond", Position: domain.Position{X: 3, Y: 3}, Width: 5, Height: 3},
+				{ID: "b", Contents: "B", Position: domain.Position{X: 10, Y: 7}, Width: 5, Height: 3, Shape: domain.ShapeCylinder},
+			},
+			[]domain.Edge{
+				{
+					ID: "e1", From: "a", To: "b", Label: "calls",
+					Path: &domain.Path{Points: []domain.Position{
+						{X: 5, Y: 4}, {X: 7, Y: 4}, {X: 8, Y: 4}, {X: 9, Y: 4},
+						{X: 9, Y: 6}, {X: 9, Y: 8}, {X: 10, Y: 8}, {X: 12, Y: 8},
+					}},
+				},
+			},
+		)
+
+		err := renderer.Render(diagram, "output.drawio")
+		require.NoError(t, err)
+		cells := drawIOCells(t, writer.written["output.drawio"])
+
+		a := cells["node-a"]
+		assert.Equal(t, "A\nsecond", a.Value)
+		assert.Equal(t, "1", a.Vertex)
+		assert.Equal(t, drawIOLayer, a.Parent)
+		assert.Equal(t, "rounded=1;absoluteArcSize=1;arcSize=6;whiteSpace=wrap;", a.Style)
+		assert.Equal(t, drawIOGeometry{X: "60", Y: "60", Width: "80", Height: "40", As: "geometry"}, *a.Geometry)
+
+		b := cells["node-b"]
+		assert.Equal(t, "shape=cylinder3;boundedLbl=1;whiteSpace=wrap;", b.Style)
+		assert.Equal(t, drawIOGeometry{X: "200", Y: "140", Width: "80", Height: "40", As: "geometry"}, *b.Geometry)
+
+		e1 := cells["edge-e1"]
+		assert.Equal(t, "calls", e1.Value)
+		assert.Equal(t, "1", e1.Edge)
+		assert.Equal(t, "node-a", e1.Source)
+		assert.Equal(t, "node-b", e1.Target)
+		assert.Equal(t, ""+
+			"edgeStyle=orthogonalEdgeStyle;rounded=0;html=0;"+
+			"exitX=1;exitY=0.5;exitDx=0;exitDy=0;"+
+			"entryX=0;entryY=0.5;entryDx=0;entryDy=0;"+
+			"endArrow=block;endFill=1;startArrow=none;startFill=1;",
+			e1.Style)
+		require.NotNil(t, e1.Geometry.Points)
+		assert.Equal(t, []drawIOPoint{{X: "180", Y: "80"}, {X: "180", Y: "160"}}, e1.Geometry.Points.Points,
+			"only the corners are kept")
+	})
+
+	t.Run("turns styles in to draw.io styles", func(t *testing.T) {
+		diagram := newTestDiagram(
+			[]domain.Node{
+				{ID: "a", Contents: "A", Width: 5, Height: 3, Shape: domain.ShapeRect, Class: "store", Style: "stroke-width: 2px"},
+				{ID: "b", Contents: "B", Position: domain.Position{X: 7}, Width: 5, Height: 3, Shape: domain.ShapeDiamond},
+			},
+			[]domain.Edge{
+				{ID: "e1", From: "a", To: "b", Class: "async"},
+			},
+		)
+		diagram.Config.Styles = map[string]string{
+			".store":     "fill: #eef; stroke: #336;",
+			"#a":         "stroke: red;",
+			".path-line": "stroke: #333; fill: none;",
+			".async":     "stroke-dasharray: 4, 2;",
+		}
+
+		out, err := ToDrawIO(diagram)
+		require.NoError(t, err)
+		cells := drawIOCells(t, out)
+
+		assert.Equal(t, "rounded=0;whiteSpace=wrap;strokeColor=red;fillColor=#eef;strokeWidth=2;", cells["node-a"].Style)
+		assert.Equal(t, "rhombus;whiteSpace=wrap;", cells["node-b"].Style)
+		assert.Equal(t, ""+
+			"edgeStyle=orthogonalEdgeStyle;rounded=0;html=0;"+
+			"endArrow=block;endFill=1;startArrow=none;startFill=1;"+
+			"strokeColor=#333;dashed=1;dashPattern=4 2;",
+			cells["edge-e1"].Style, "edges without paths are routed by draw.io")
+	})
+
+	t.Run("arrows", func(t *testing.T) {
+		diagram := newTestDiagram(
+			[]domain.Node{
+				{ID: "a", Contents: "A", Width: 5, Height: 3},
+				{ID: "b", Contents: "B", Position: domain.Position{X: 7}, Width: 5, Height: 3},
+			},
+			[]domain.Edge{
+				{ID: "e1", From: "a", To: "b", Arrow: domain.ArrowNone},
+				{ID: "e2", From: "a", To: "b", Arrow: domain.ArrowBoth, Head: domain.HeadHollowDiamond},
+				{ID: "e3", From: "a", To: "b", Arrow: domain.ArrowBackward, Head: domain.HeadCrowsFoot},
+				{ID: "e4", From: "a", To: "b", Head: domain.HeadOpenArrow},
+			},
+		)
+
+		out, err := ToDrawIO(diagram)
+		require.NoError(t, err)
+		cells := drawIOCells(t, out)
+
+		assert.Contains(t, cells["edge-e1"].Style, "endArrow=none;endFill=1;startArrow=none;startFill=1;")
+		assert.Contains(t, cells["edge-e2"].Style, "endArrow=diamond;endFill=0;startArrow=diamond;startFill=0;")
+		assert.Contains(t, cells["edge-e3"].Style, "endArrow=none;endFill=0;startArrow=ERmany;startFill=0;")
+		assert.Contains(t, cells["edge-e4"].Style, "endArrow=open;endFill=0;startArrow=none;startFill=0;")
+	})
+
+	t.Run("nodes are placed inside their groups", func(t *testing.T) {
+		diagram := newTestDiagram(
+			[]domain.Node{
+				{ID: "a", Contents: "A", Position: domain.Position{X: 4, Y: 8}, Width: 5, Height: 3},
+				{ID: "b", Contents: "B", Position: domain.Position{X: 14, Y: 8}, Width: 5, Height: 3},
+			},
+			nil,
+		)
+		diagram.Groups = []domain.Group{
+			{ID: "outer", Label: "Outer", Nodes: []string{"a"}, Groups: []domain.Group{
+				{ID: "inner", Nodes: []string{"b"}},
+			}},
+		}
+
+		out, err := ToDrawIO(diagram)
+		require.NoError(t, err)
+		cells := drawIOCells(t, out)
+
+		outer := cells["group-outer"]
+		assert.Equal(t, "Outer", outer.Value)
+		assert.Equal(t, drawIOLayer, outer.Parent)
+		assert.Contains(t, outer.Style, "container=1;")
+		assert.Contains(t, outer.Style, "fillColor=none;dashed=1;dashPattern=6 3;")
+		assert.Equal(t, drawIOGeometry{X: "30", Y: "30", Width: "420", Height: "260", As: "geometry"}, *outer.Geometry)
+
+		inner := cells["group-inner"]
+		assert.Equal(t, "group-outer", inner.Parent)
+		assert.Equal(t, drawIOGeometry{X: "200", Y: "60", Width: "180", Height: "160", As: "geometry"}, *inner.Geometry)
+
+		assert.Equal(t, "group-outer", cells["node-a"].Parent)
+		assert.Equal(t, "50", cells["node-a"].Geometry.X)
+		assert.Equal(t, "group-inner", cells["node-b"].Parent)
+		assert.Equal(t, "50", cells["node-b"].Geometry.X)
+		assert.Equal(t, "70", cells["node-b"].Geometry.Y)
+	})
+
+	t.Run("nodes, edges and groups can share IDs", func(t *testing.T) {
+		diagram := newTestDiagram(
+			[]domain.Node{
+				{ID: "a", Contents: "A", Position: domain.Position{X: 4, Y: 8}, Width: 5, Height: 3},
+				{ID: "b", Contents: "B", Position: domain.Position{X: 14, Y: 8}, Width: 5, Height: 3},
+			},
+			[]domain.Edge{{ID: "a", From: "a", To: "b"}},
+		)
+		diagram.Groups = []domain.Group{{ID: "b", Nodes: []string{"b"}}}
+
+		out, err := ToDrawIO(diagram)
+		require.NoError(t, err)
+		cells := drawIOCells(t, out)
+
+		require.Len(t, cells, 6, "the root, the layer, 2 nodes, the edge and the group")
+		assert.Equal(t, "1", cells["node-a"].Vertex)
+		assert.Equal(t, "1", cells["edge-a"].Edge)
+		assert.Equal(t, "group-b", cells["node-b"].Parent)
+		assert.Equal(t, "node-b", cells["edge-a"].Target)
+	})
+
+	t.Run("returns write errors", func(t *testing.T) {
+		writer := &mockFileWriter{written: map[string][]byte{}, err: assert.AnError}
+		err := NewDrawIORenderer(writer).Render(newTestDiagram(nil, nil), "output.drawio")
+		assert.ErrorIs(t, err, assert.AnError)
+	})
+}
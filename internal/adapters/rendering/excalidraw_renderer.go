@@ -0,0 +1,372 @@
+package rendering
+
+import (
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"strings"
+
+	"github.com/dnnrly/layli/internal/domain"
+	"github.com/dnnrly/layli/internal/usecases"
+)
+
+var _ usecases.Renderer = (*ExcalidrawRenderer)(nil)
+
+// ExcalidrawRenderer writes a diagram as an Excalidraw scene. Each node is a
+// shape with its text bound inside it and each edge is an arrow that follows
+// its path and is bound to its nodes, so it stays attached when they move.
+type ExcalidrawRenderer struct {
+	writer usecases.FileWriter
+}
+
+func NewExcalidrawRenderer(writer usecases.FileWriter) *ExcalidrawRenderer {
+	return &ExcalidrawRenderer{writer: writer}
+}
+
+func (r *ExcalidrawRenderer) Render(diagram *domain.Diagram, outputPath string) error {
+	out, err := ToExcalidraw(diagram)
+	if err != nil {
+		return fmt.Errorf("rendering Excalidraw: %w", err)
+	}
+	return r.writer.Write(outputPath, out)
+}
+
+// excalidrawFontSize is the size of the text, the same as in the SVG
+const excalidrawFontSize = 10
+
+// excalidrawLineHeight is the height of a line of text as a multiple of the
+// font size
+const excalidrawLineHeight = 1.25
+
+// excalidrawShapes maps layli shapes on to the Excalidraw shapes that look
+// like them. Everything else is a rectangle.
+var excalidrawShapes = map[domain.NodeShape]string{
+	domain.ShapeEllipse: "ellipse",
+	domain.ShapeDiamond: "diamond",
+}
+
+// excalidrawArrowHeads maps layli arrow heads on to Excalidraw arrowheads
+var excalidrawArrowHeads = map[domain.ArrowHead]string{
+	domain.HeadTriangle:      "triangle",
+	domain.HeadOpenArrow:     "arrow",
+	domain.HeadDiamond:       "diamond",
+	domain.HeadHollowDiamond: "diamond_outline",
+	domain.HeadCircle:        "circle",
+	domain.HeadCrowsFoot:     "crowfoot_many",
+}
+
+type excalidrawFile struct {
+	Type     string              `json:"type"`
+	Version  int                 `json:"version"`
+	Source   string              `json:"source"`
+	Elements []excalidrawElement `json:"elements"`
+	AppState excalidrawAppState  `json:"appState"`
+	Files    map[string]any      `json:"files"`
+}
+
+type excalidrawAppState struct {
+	ViewBackgroundColor string `json:"viewBackgroundColor"`
+	GridSize            int    `json:"gridSize"`
+}
+
+type excalidrawElement struct {
+	ID              string               `json:"id"`
+	Type            string               `json:"type"`
+	X               float64              `json:"x"`
+	Y               float64              `json:"y"`
+	Width           float64              `json:"width"`
+	Height          float64              `json:"height"`
+	Angle           float64              `json:"angle"`
+	StrokeColor     string               `json:"strokeColor"`
+	BackgroundColor string               `json:"backgroundColor"`
+	FillStyle       string               `json:"fillStyle"`
+	StrokeWidth     float64              `json:"strokeWidth"`
+	StrokeStyle     string               `json:"strokeStyle"`
+	Roughness       int                  `json:"roughness"`
+	Opacity         int                  `json:"opacity"`
+	GroupIDs        []string             `json:"groupIds"`
+	FrameID         *string              `json:"frameId"`
+	Roundness       *excalidrawRoundness `json:"roundness"`
+	Seed            uint32               `json:"seed"`
+	Version         int                  `json:"version"`
+	VersionNonce    uint32               `json:"versionNonce"`
+	IsDeleted       bool                 `json:"isDeleted"`
+	BoundElements   []excalidrawBound    `json:"boundElements"`
+	Updated         int                  `json:"updated"`
+	Link            *string              `json:"link"`
+	Locked          bool                 `json:"locked"`
+
+	// Text
+	Text          string  `json:"text,omitempty"`
+	OriginalText  string  `json:"originalText,omitempty"`
+	FontSize      float64 `json:"fontSize,omitempty"`
+	FontFamily    int     `json:"fontFamily,omitempty"`
+	TextAlign     string  `json:"textAlign,omitempty"`
+	VerticalAlign string  `json:"verticalAlign,omitempty"`
+	ContainerID   *string `json:"containerId,omitempty"`
+	LineHeight    float64 `json:"lineHeight,omitempty"`
+
+	// Arrows
+	Points         [][2]float64       `json:"points,omitempty"`
+	StartBinding   *excalidrawBinding `json:"startBinding,omitempty"`
+	EndBinding     *excalidrawBinding `json:"endBinding,omitempty"`
+	StartArrowhead *string            `json:"startArrowhead,omitempty"`
+	EndArrowhead   *string            `json:"endArrowhead,omitempty"`
+}
+
+type excalidrawRoundness struct {
+	Type int `json:"type"`
+}
+
+type excalidrawBound struct {
+	ID   string `json:"id"`
+	Type string `json:"type"`
+}
+
+type excalidrawBinding struct {
+	ElementID string  `json:"elementId"`
+	Focus     float64 `json:"focus"`
+	Gap       float64 `json:"gap"`
+}
+
+// excalidrawID returns the ID of an element, with its kind in front so that
+// nodes, edges, groups and the text inside them can't share an ID
+func excalidrawID(kind, id string) string {
+	return kind + "-" + id
+}
+
+// ToExcalidraw returns the diagram as an Excalidraw scene. Groups are drawn
+// as dashed boxes and everything inside them is grouped with them, so they
+// can be moved together.
+func ToExcalidraw(diagram *domain.Diagram) ([]byte, error) {
+	l := buildLayout(diagram)
+	spacing := float64(diagram.Config.Spacing)
+
+	// Excalidraw lists the groups that an element is in from the innermost
+	// out, using the IDs of the boxes that they are drawn as
+	parents := groupParents(l.Groups)
+	groupIDs := func(id string) []string {
+		ids := []string{}
+		for parent, found := parents[id]; found; parent, found = parents[parent] {
+			ids = append(ids, excalidrawID("group", parent))
+		}
+		return ids
+	}
+
+	elements := []excalidrawElement{}
+	index := map[string]int{}
+	add := func(el excalidrawElement) {
+		index[el.ID] = len(elements)
+		elements = append(elements, el)
+	}
+	bind := func(id string, bound excalidrawBound) {
+		if i, found := index[id]; found {
+			elements[i].BoundElements = append(elements[i].BoundElements, bound)
+		}
+	}
+
+	for _, g := range l.Groups {
+		css := resolveCSS(diagram.Config.Styles, g.Id, []string{"group"}, "")
+		if css["stroke-dasharray"] == "" {
+			css["stroke-dasharray"] = "6 3"
+		}
+
+		box := newExcalidrawElement(excalidrawID("group", g.Id), "rectangle", css)
+		box.X = float64(g.Left())*spacing - spacing/2
+		box.Y = float64(g.Top())*spacing - spacing/2
+		box.Width = float64(g.Right()-g.Left()+1) * spacing
+		box.Height = float64(g.Bottom()-g.Top()+1) * spacing
+		box.Roundness = &excalidrawRoundness{Type: 3}
+		box.GroupIDs = append([]string{box.ID}, groupIDs(g.Id)...)
+		add(box)
+
+		if g.Label != "" {
+			title := newExcalidrawText(excalidrawID("text", box.ID), g.Label, box.X+4, box.Y+2, box.Width-8)
+			title.TextAlign = "left"
+			title.VerticalAlign = "top"
+			title.GroupIDs = box.GroupIDs
+			add(title)
+		}
+	}
+
+	for _, n := range diagram.Nodes {
+		ln := l.Nodes.ByID(n.ID)
+		if ln == nil {
+			continue
+		}
+
+		shape, found := excalidrawShapes[n.Shape]
+		if !found {
+			shape = "rectangle"
+		}
+		css := resolveCSS(diagram.Config.Styles, n.ID, strings.Fields(n.Class), n.Style)
+		node := newExcalidrawElement(excalidrawID("node", n.ID), shape, css)
+		node.X = float64(ln.Left()) * spacing
+		node.Y = float64(ln.Top()) * spacing
+		node.Width = float64(ln.Width()-1) * spacing
+		node.Height = float64(ln.Height()-1) * spacing
+		if shape == "rectangle" && n.Shape != domain.ShapeRect {
+			node.Roundness = &excalidrawRoundness{Type: 3}
+		}
+		node.GroupIDs = groupIDs(n.ID)
+		add(node)
+
+		if n.Contents != "" {
+			text := newExcalidrawText(excalidrawID("text", node.ID), n.Contents, node.X, node.Y, node.Width)
+			text.Y += (node.Height - text.Height) / 2
+			text.ContainerID = &node.ID
+			text.GroupIDs = node.GroupIDs
+			add(text)
+			bind(node.ID, excalidrawBound{ID: text.ID, Type: "text"})
+		}
+	}
+
+	for _, e := range diagram.Edges {
+		route := edgeRoute(e)
+		if route == nil {
+			continue
+		}
+
+		classes := append([]string{"path-line"}, strings.Fields(e.Class)...)
+		css := resolveCSS(diagram.Config.Styles, e.ID, classes, e.Style)
+		delete(css, "fill")
+		arrow := newExcalidrawElement(excalidrawID("edge", e.ID), "arrow", css)
+
+		// Points are relative to the start of the arrow
+		arrow.X = float64(route[0].X) * spacing
+		arrow.Y = float64(route[0].Y) * spacing
+		minX, minY, maxX, maxY := 0.0, 0.0, 0.0, 0.0
+		for _, p := range route {
+			x, y := float64(p.X)*spacing-arrow.X, float64(p.Y)*spacing-arrow.Y
+			arrow.Points = append(arrow.Points, [2]float64{x, y})
+			minX, minY = min(minX, x), min(minY, y)
+			maxX, maxY = max(maxX, x), max(maxY, y)
+		}
+		arrow.Width = maxX - minX
+		arrow.Height = maxY - minY
+
+		head, found := excalidrawArrowHeads[e.Head]
+		if !found {
+			head = excalidrawArrowHeads[domain.HeadTriangle]
+		}
+		if e.ArrowAtStart() {
+			arrow.StartArrowhead = &head
+		}
+		if e.ArrowAtEnd() {
+			arrow.EndArrowhead = &head
+		}
+
+		from, to := excalidrawID("node", e.From), excalidrawID("node", e.To)
+		if _, found := index[from]; found {
+			arrow.StartBinding = &excalidrawBinding{ElementID: from}
+			bind(from, excalidrawBound{ID: arrow.ID, Type: "arrow"})
+		}
+		if _, found := index[to]; found {
+			arrow.EndBinding = &excalidrawBinding{ElementID: to}
+			bind(to, excalidrawBound{ID: arrow.ID, Type: "arrow"})
+		}
+		add(arrow)
+
+		if e.Label != "" {
+			x, y := excalidrawMidpoint(arrow)
+			label := newExcalidrawText(excalidrawID("text", arrow.ID), e.Label, x, y, 0)
+			label.Y -= label.Height / 2
+			label.ContainerID = &arrow.ID
+			add(label)
+			bind(arrow.ID, excalidrawBound{ID: label.ID, Type: "text"})
+		}
+	}
+
+	file := excalidrawFile{
+		Type:     "excalidraw",
+		Version:  2,
+		Source:   "https://github.com/dnnrly/layli",
+		Elements: elements,
+		AppState: excalidrawAppState{
+			ViewBackgroundColor: "#ffffff",
+			GridSize:            diagram.Config.Spacing,
+		},
+		Files: map[string]any{},
+	}
+
+	out, err := json.MarshalIndent(file, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(out, '\n'), nil
+}
+
+// newExcalidrawElement returns an element drawn with the CSS properties that
+// Excalidraw has settings for. Elements are drawn with clean lines, like the
+// SVG, rather than looking hand drawn.
+func newExcalidrawElement(id, kind string, css map[string]string) excalidrawElement {
+	el := excalidrawElement{
+		ID:              id,
+		Type:            kind,
+		StrokeColor:     "#000000",
+		BackgroundColor: "transparent",
+		FillStyle:       "solid",
+		StrokeWidth:     1,
+		StrokeStyle:     "solid",
+		Opacity:         100,
+		GroupIDs:        []string{},
+		Seed:            excalidrawSeed(id),
+		Version:         1,
+		VersionNonce:    excalidrawSeed(id + "-nonce"),
+		BoundElements:   []excalidrawBound{},
+		Updated:         1,
+	}
+
+	if c := css["stroke"]; c != "" && c != "none" {
+		el.StrokeColor = c
+	}
+	if c := css["fill"]; c != "" && c != "none" {
+		el.BackgroundColor = c
+	}
+	if w := cssLength(css["stroke-width"], 0); w > 0 {
+		el.StrokeWidth = w
+	}
+	if d := css["stroke-dasharray"]; d != "" && d != "none" {
+		el.StrokeStyle = "dashed"
+	}
+	return el
+}
+
+// newExcalidrawText returns a block of text with its top left corner at x
+// and y. Text inside a container is lined up in the middle of it.
+func newExcalidrawText(id, text string, x, y, width float64) excalidrawElement {
+	el := newExcalidrawElement(id, "text", nil)
+	el.Text = text
+	el.OriginalText = text
+	el.FontSize = excalidrawFontSize
+	el.FontFamily = 2
+	el.TextAlign = "center"
+	el.VerticalAlign = "middle"
+	el.LineHeight = excalidrawLineHeight
+	el.X, el.Y = x, y
+	el.Width = width
+	el.Height = float64(strings.Count(text, "\n")+1) * excalidrawFontSize * excalidrawLineHeight
+	return el
+}
+
+// excalidrawMidpoint returns the point half way along an arrow that
+// Excalidraw puts its label on, which is the middle point or the middle of
+// the middle segment
+func excalidrawMidpoint(arrow excalidrawElement) (float64, float64) {
+	n := len(arrow.Points)
+	p := arrow.Points[n/2]
+	if n%2 == 0 {
+		q := arrow.Points[n/2-1]
+		p = [2]float64{(p[0] + q[0]) / 2, (p[1] + q[1]) / 2}
+	}
+	return arrow.X + p[0], arrow.Y + p[1]
+}
+
+// excalidrawSeed returns the seed that Excalidraw draws an element's lines
+// with. It comes from the ID so that the same diagram is written the same
+// way every time.
+func excalidrawSeed(id string) uint32 {
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(id))
+	return h.Sum32() & 0x7fffffff
+}
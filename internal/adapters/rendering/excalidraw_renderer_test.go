@@ -0,0 +1,196 @@
+package rendering
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/dnnrly/layli/internal/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// excalidrawElements reads the elements back out of an Excalidraw scene by
+// their IDs
+func excalidrawElements(t *testing.T, out []byte) map[string]excalidrawElement {
+	t.Helper()
+	file := excalidrawFile{}
+	require.NoError(t, json.Unmarshal(out, &file))
+	assert.Equal(t, "excalidraw", file.Type)
+
+	elements := map[string]excalidrawElement{}
+	for _, el := range file.Elements {
+		elements[el.ID] = el
+	}
+	return elements
+}
+
+func TestExcalidrawRenderer_Render(t *testing.T) {
+	t.Run("binds text to nodes and arrows to the nodes they join", func(t *testing.T) {
+		writer := &mockFileWriter{written: map[string][]byte{}}
+		renderer := NewExcalidrawRenderer(writer)
+
+		diagram := newTestDiagram(
+			[]domain.Node{
+				{ID: "a", Contents: "A\nsecond", Position: domain.Position{X: 3, Y: 3}, Width: 5, Height: 3},
+				{ID: "b", Contents: "B", Position: domain.Position{X: 10, Y: 7}, Width: 5, Height: 3, Shape: domain.ShapeEllipse},
+			},
+			[]domain.Edge{
+				{
+					ID: "e1", From: "a", To: "b", Label: "calls",
+					Path: &domain.Path{Points: []domain.Position{
+						{X: 5, Y: 4}, {X: 7, Y: 4}, {X: 8, Y: 4}, {X: 9, Y: 4},
+						{X: 9, Y: 6}, {X: 9, Y: 8}, {X: 10, Y: 8}, {X: 12, Y: 8},
+					}},
+				},
+			},
+		)
+
+		err := renderer.Render(diagram, "output.excalidraw")
+		require.NoError(t, err)
+		elements := excalidrawElements(t, writer.written["output.excalidraw"])
+
+		a := elements["node-a"]
+		assert.Equal(t, "rectangle", a.Type)
+		assert.Equal(t, []float64{60, 60, 80, 40}, []float64{a.X, a.Y, a.Width, a.Height})
+		assert.Equal(t, &excalidrawRoundness{Type: 3}, a.Roundness)
+		assert.Equal(t, []excalidrawBound{{ID: "text-node-a", Type: "text"}, {ID: "edge-e1", Type: "arrow"}}, a.BoundElements)
+
+		text := elements["text-node-a"]
+		assert.Equal(t, "A\nsecond", text.Text)
+		require.NotNil(t, text.ContainerID)
+		assert.Equal(t, "node-a", *text.ContainerID)
+		assert.Equal(t, []float64{60, 67.5, 80, 25}, []float64{text.X, text.Y, text.Width, text.Height})
+
+		b := elements["node-b"]
+		assert.Equal(t, "ellipse", b.Type)
+		assert.Equal(t, []float64{200, 140, 80, 40}, []float64{b.X, b.Y, b.Width, b.Height})
+
+		e1 := elements["edge-e1"]
+		assert.Equal(t, "arrow", e1.Type)
+		assert.Equal(t, []float64{140, 80, 60, 80}, []float64{e1.X, e1.Y, e1.Width, e1.Height})
+		assert.Equal(t, [][2]float64{{0, 0}, {40, 0}, {40, 80}, {60, 80}}, e1.Points, "only the corners are kept")
+		assert.Equal(t, &excalidrawBinding{ElementID: "node-a"}, e1.StartBinding)
+		assert.Equal(t, &excalidrawBinding{ElementID: "node-b"}, e1.EndBinding)
+		assert.Nil(t, e1.StartArrowhead)
+		require.NotNil(t, e1.EndArrowhead)
+		assert.Equal(t, "triangle", *e1.EndArrowhead)
+		assert.Equal(t, []excalidrawBound{{ID: "text-edge-e1", Type: "text"}}, e1.BoundElements)
+
+		label := elements["text-edge-e1"]
+		assert.Equal(t, "calls", label.Text)
+		assert.Equal(t, []float64{180, 113.75}, []float64{label.X, label.Y}, "half way along the arrow")
+	})
+
+	t.Run("turns styles in to element settings", func(t *testing.T) {
+		diagram := newTestDiagram(
+			[]domain.Node{
+				{ID: "a", Contents: "A", Position: domain.Position{X: 3, Y: 3}, Width: 5, Height: 3, Shape: domain.ShapeRect, Class: "store", Style: "stroke-width: 2px"},
+				{ID: "b", Contents: "B", Position: domain.Position{X: 10, Y: 3}, Width: 5, Height: 3},
+			},
+			[]domain.Edge{
+				{
+					ID: "e1", From: "a", To: "b", Class: "async", Arrow: domain.ArrowBoth, Head: domain.HeadCrowsFoot,
+					Path: &domain.Path{Points: []domain.Position{{X: 5, Y: 4}, {X: 7, Y: 4}, {X: 10, Y: 4}, {X: 12, Y: 4}}},
+				},
+				{ID: "e2", From: "a", To: "b"},
+			},
+		)
+		diagram.Config.Styles = map[string]string{
+			".store":     "fill: #eef; stroke: #336;",
+			"#a":         "stroke: red;",
+			".path-line": "stroke: #333; fill: none;",
+			".async":     "stroke-dasharray: 4 2;",
+		}
+
+		out, err := ToExcalidraw(diagram)
+		require.NoError(t, err)
+		elements := excalidrawElements(t, out)
+
+		a := elements["node-a"]
+		assert.Equal(t, "red", a.StrokeColor)
+		assert.Equal(t, "#eef", a.BackgroundColor)
+		assert.Equal(t, 2.0, a.StrokeWidth)
+		assert.Nil(t, a.Roundness)
+
+		e1 := elements["edge-e1"]
+		assert.Equal(t, "#333", e1.StrokeColor)
+		assert.Equal(t, "transparent", e1.BackgroundColor)
+		assert.Equal(t, "dashed", e1.StrokeStyle)
+		assert.Equal(t, "crowfoot_many", *e1.StartArrowhead)
+		assert.Equal(t, "crowfoot_many", *e1.EndArrowhead)
+
+		assert.NotContains(t, elements, "e2", "edges without paths are left out")
+	})
+
+	t.Run("nodes are grouped with the groups they are in", func(t *testing.T) {
+		diagram := newTestDiagram(
+			[]domain.Node{
+				{ID: "a", Contents: "A", Position: domain.Position{X: 4, Y: 8}, Width: 5, Height: 3},
+				{ID: "b", Contents: "B", Position: domain.Position{X: 14, Y: 8}, Width: 5, Height: 3},
+			},
+			nil,
+		)
+		diagram.Groups = []domain.Group{
+			{ID: "outer", Label: "Outer", Nodes: []string{"a"}, Groups: []domain.Group{
+				{ID: "inner", Nodes: []string{"b"}},
+			}},
+		}
+
+		out, err := ToExcalidraw(diagram)
+		require.NoError(t, err)
+		elements := excalidrawElements(t, out)
+
+		outer := elements["group-outer"]
+		assert.Equal(t, []float64{30, 30, 420, 260}, []float64{outer.X, outer.Y, outer.Width, outer.Height})
+		assert.Equal(t, "dashed", outer.StrokeStyle)
+		assert.Equal(t, []string{"group-outer"}, outer.GroupIDs)
+		assert.Equal(t, "Outer", elements["text-group-outer"].Text)
+		assert.Equal(t, []string{"group-outer"}, elements["text-group-outer"].GroupIDs)
+
+		assert.Equal(t, []string{"group-inner", "group-outer"}, elements["group-inner"].GroupIDs)
+		assert.Equal(t, []string{"group-outer"}, elements["node-a"].GroupIDs)
+		assert.Equal(t, []string{"group-inner", "group-outer"}, elements["node-b"].GroupIDs)
+		assert.Equal(t, []string{"group-inner", "group-outer"}, elements["text-node-b"].GroupIDs)
+	})
+
+	t.Run("nodes, edges, groups and text can share IDs", func(t *testing.T) {
+		diagram := newTestDiagram(
+			[]domain.Node{
+				{ID: "x", Contents: "X", Position: domain.Position{X: 4, Y: 8}, Width: 5, Height: 3},
+				{ID: "x-text", Contents: "Y", Position: domain.Position{X: 14, Y: 8}, Width: 5, Height: 3},
+			},
+			[]domain.Edge{{
+				ID: "x", From: "x", To: "x-text", Label: "to",
+				Path: &domain.Path{Points: []domain.Position{{X: 6, Y: 9}, {X: 8, Y: 9}, {X: 14, Y: 9}, {X: 16, Y: 9}}},
+			}},
+		)
+		diagram.Groups = []domain.Group{{ID: "g", Label: "Group", Nodes: []string{"x-text"}}}
+
+		out, err := ToExcalidraw(diagram)
+		require.NoError(t, err)
+		elements := excalidrawElements(t, out)
+
+		require.Len(t, elements, 8, "every element has its own ID")
+		assert.Equal(t, "X", elements["text-node-x"].Text)
+		assert.Equal(t, "Y", elements["text-node-x-text"].Text)
+		assert.Equal(t, "to", elements["text-edge-x"].Text)
+		assert.Equal(t, "Group", elements["text-group-g"].Text)
+		assert.Equal(t, &excalidrawBinding{ElementID: "node-x-text"}, elements["edge-x"].EndBinding)
+	})
+
+	t.Run("is written the same way every time", func(t *testing.T) {
+		diagram := newTestDiagram([]domain.Node{{ID: "a", Contents: "A", Width: 5, Height: 3}}, nil)
+
+		first, err := ToExcalidraw(diagram)
+		require.NoError(t, err)
+		second, err := ToExcalidraw(diagram)
+		require.NoError(t, err)
+		assert.Equal(t, string(first), string(second))
+	})
+
+	t.Run("returns write errors", func(t *testing.T) {
+		writer := &mockFileWriter{written: map[string][]byte{}, err: assert.AnError}
+		err := NewExcalidrawRenderer(writer).Render(newTestDiagram(nil, nil), "output.excalidraw")
+		assert.ErrorIs(t, err, assert.AnError)
+	})
+}
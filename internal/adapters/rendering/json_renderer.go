@@ -0,0 +1,289 @@
+package rendering
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/dnnrly/layli/internal/domain"
+	"github.com/dnnrly/layli/internal/usecases"
+)
+
+var _ usecases.Renderer = (*JSONRenderer)(nil)
+
+// JSONRenderer writes the arranged diagram as JSON so that other programs can
+// use the positions that layli worked out. The schema is described in
+// docs/LAYOUT_JSON.md.
+type JSONRenderer struct {
+	writer usecases.FileWriter
+}
+
+func NewJSONRenderer(writer usecases.FileWriter) *JSONRenderer {
+	return &JSONRenderer{writer: writer}
+}
+
+func (r *JSONRenderer) Render(diagram *domain.Diagram, outputPath string) error {
+	out, err := ToJSON(diagram)
+	if err != nil {
+		return fmt.Errorf("rendering JSON: %w", err)
+	}
+	return r.writer.Write(outputPath, out)
+}
+
+// JSONSchema names the schema of the JSON and JSONSchemaVersion is its
+// version. The version only goes up when a change would break programs that
+// read the JSON, such as removing or renaming a field.
+const (
+	JSONSchema        = "layli-layout"
+	JSONSchemaVersion = 1
+)
+
+type jsonLayout struct {
+	Schema    string         `json:"schema"`
+	Version   int            `json:"version"`
+	Config    jsonConfig     `json:"config"`
+	Bounds    jsonBounds     `json:"bounds"`
+	Nodes     []jsonNode     `json:"nodes"`
+	Edges     []jsonEdge     `json:"edges"`
+	Groups    []jsonGroup    `json:"groups"`
+	Crossings []jsonCrossing `json:"crossings"`
+}
+
+type jsonConfig struct {
+	Layout         string            `json:"layout"`
+	LayoutAttempts int               `json:"layoutAttempts"`
+	NodeWidth      int               `json:"nodeWidth"`
+	NodeHeight     int               `json:"nodeHeight"`
+	Border         int               `json:"border"`
+	Margin         int               `json:"margin"`
+	Spacing        int               `json:"spacing"`
+	PathAttempts   int               `json:"pathAttempts"`
+	PathStrategy   string            `json:"pathStrategy"`
+	Pathfinding    jsonPathfinding   `json:"pathfinding"`
+	Styles         map[string]string `json:"styles"`
+	Seed           int64             `json:"seed"`
+}
+
+type jsonPathfinding struct {
+	Algorithm   string `json:"algorithm"`
+	Heuristic   string `json:"heuristic"`
+	BendPenalty int    `json:"bendPenalty"`
+}
+
+// jsonBounds is a box measured both on the grid and in the pixels of the SVG
+type jsonBounds struct {
+	Grid   jsonBox `json:"grid"`
+	Pixels jsonBox `json:"pixels"`
+}
+
+type jsonBox struct {
+	X      int `json:"x"`
+	Y      int `json:"y"`
+	Width  int `json:"width"`
+	Height int `json:"height"`
+}
+
+type jsonPoint struct {
+	X int `json:"x"`
+	Y int `json:"y"`
+}
+
+type jsonNode struct {
+	ID       string     `json:"id"`
+	Contents string     `json:"contents"`
+	Shape    string     `json:"shape"`
+	Class    string     `json:"class,omitempty"`
+	Style    string     `json:"style,omitempty"`
+	Ports    []jsonPort `json:"ports,omitempty"`
+	Bounds   jsonBounds `json:"bounds"`
+}
+
+type jsonPort struct {
+	Name   string `json:"name"`
+	Side   string `json:"side"`
+	Offset int    `json:"offset"`
+}
+
+type jsonEdge struct {
+	ID             string              `json:"id"`
+	From           string              `json:"from"`
+	To             string              `json:"to"`
+	FromPort       string              `json:"fromPort,omitempty"`
+	ToPort         string              `json:"toPort,omitempty"`
+	Class          string              `json:"class,omitempty"`
+	Style          string              `json:"style,omitempty"`
+	Arrow          string              `json:"arrow"`
+	Head           string              `json:"head"`
+	Label          string              `json:"label,omitempty"`
+	LabelPlacement *jsonLabelPlacement `json:"labelPlacement,omitempty"`
+	Points         []jsonPoint         `json:"points"`
+	Corners        []jsonPoint         `json:"corners"`
+	Length         float64             `json:"length"`
+}
+
+type jsonLabelPlacement struct {
+	At   jsonPoint `json:"at"`
+	Side string    `json:"side"`
+}
+
+type jsonGroup struct {
+	ID     string     `json:"id"`
+	Label  string     `json:"label,omitempty"`
+	Parent string     `json:"parent,omitempty"`
+	Nodes  []string   `json:"nodes"`
+	Groups []string   `json:"groups"`
+	Bounds jsonBounds `json:"bounds"`
+}
+
+type jsonCrossing struct {
+	Edges [2]string `json:"edges"`
+	At    jsonPoint `json:"at"`
+}
+
+// ToJSON returns the arranged diagram as JSON in the layli-layout schema
+func ToJSON(diagram *domain.Diagram) ([]byte, error) {
+	l := buildLayout(diagram)
+	cfg := diagram.Config
+	spacing := cfg.Spacing
+
+	// bounds measures a box of grid points, which is one grid square
+	// smaller in pixels as the outline goes through the outer points
+	bounds := func(x, y, width, height int) jsonBounds {
+		return jsonBounds{
+			Grid:   jsonBox{X: x, Y: y, Width: width, Height: height},
+			Pixels: jsonBox{X: x * spacing, Y: y * spacing, Width: (width - 1) * spacing, Height: (height - 1) * spacing},
+		}
+	}
+
+	styles := cfg.Styles
+	if styles == nil {
+		styles = map[string]string{}
+	}
+	out := jsonLayout{
+		Schema:  JSONSchema,
+		Version: JSONSchemaVersion,
+		Config: jsonConfig{
+			Layout:         string(cfg.LayoutType),
+			LayoutAttempts: cfg.LayoutAttempts,
+			NodeWidth:      cfg.NodeWidth,
+			NodeHeight:     cfg.NodeHeight,
+			Border:         cfg.Border,
+			Margin:         cfg.Margin,
+			Spacing:        spacing,
+			PathAttempts:   cfg.PathAttempts,
+			PathStrategy:   cfg.PathStrategy,
+			Pathfinding: jsonPathfinding{
+				Algorithm:   string(cfg.Pathfinding.Algorithm),
+				Heuristic:   string(cfg.Pathfinding.Heuristic),
+				BendPenalty: cfg.Pathfinding.BendPenalty,
+			},
+			Styles: styles,
+			Seed:   cfg.Seed,
+		},
+		Bounds:    bounds(0, 0, l.LayoutWidth(), l.LayoutHeight()),
+		Nodes:     []jsonNode{},
+		Edges:     []jsonEdge{},
+		Groups:    []jsonGroup{},
+		Crossings: []jsonCrossing{},
+	}
+
+	for _, n := range diagram.Nodes {
+		ln := l.Nodes.ByID(n.ID)
+		if ln == nil {
+			continue
+		}
+
+		shape := n.Shape
+		if shape == "" {
+			shape = domain.ShapeRounded
+		}
+		node := jsonNode{
+			ID:       n.ID,
+			Contents: n.Contents,
+			Shape:    string(shape),
+			Class:    n.Class,
+			Style:    n.Style,
+			Bounds:   bounds(ln.Left(), ln.Top(), ln.Width(), ln.Height()),
+		}
+		for _, p := range n.Ports {
+			node.Ports = append(node.Ports, jsonPort{Name: p.Name, Side: p.Side, Offset: p.Offset})
+		}
+		out.Nodes = append(out.Nodes, node)
+	}
+
+	for _, e := range diagram.Edges {
+		arrow, head := e.Arrow, e.Head
+		if arrow == "" {
+			arrow = domain.ArrowForward
+		}
+		if head == "" {
+			head = domain.HeadTriangle
+		}
+
+		edge := jsonEdge{
+			ID:       e.ID,
+			From:     e.From,
+			To:       e.To,
+			FromPort: e.FromPort,
+			ToPort:   e.ToPort,
+			Class:    e.Class,
+			Style:    e.Style,
+			Arrow:    string(arrow),
+			Head:     string(head),
+			Label:    e.Label,
+			Points:   []jsonPoint{},
+			Corners:  []jsonPoint{},
+		}
+		if e.LabelPlacement != nil {
+			edge.LabelPlacement = &jsonLabelPlacement{
+				At:   jsonPoint(e.LabelPlacement.Position),
+				Side: string(e.LabelPlacement.Side),
+			}
+		}
+		if e.Path != nil {
+			for _, p := range e.Path.Points {
+				edge.Points = append(edge.Points, jsonPoint(p))
+			}
+		}
+		if route := edgeRoute(e); route != nil {
+			for _, p := range route[1 : len(route)-1] {
+				edge.Corners = append(edge.Corners, jsonPoint(p))
+			}
+			edge.Length = (&domain.Path{Points: route}).Length()
+		}
+		out.Edges = append(out.Edges, edge)
+	}
+
+	parents := groupParents(l.Groups)
+	for _, g := range l.Groups {
+		// Groups are drawn half a grid square outside of their grid points
+		b := bounds(g.Left(), g.Top(), g.Right()-g.Left()+1, g.Bottom()-g.Top()+1)
+		b.Pixels = jsonBox{
+			X:      g.Left()*spacing - spacing/2,
+			Y:      g.Top()*spacing - spacing/2,
+			Width:  b.Grid.Width * spacing,
+			Height: b.Grid.Height * spacing,
+		}
+
+		out.Groups = append(out.Groups, jsonGroup{
+			ID:     g.Id,
+			Label:  g.Label,
+			Parent: parents[g.Id],
+			Nodes:  append([]string{}, g.Nodes...),
+			Groups: append([]string{}, g.Groups...),
+			Bounds: b,
+		})
+	}
+
+	for _, c := range diagram.Crossings {
+		out.Crossings = append(out.Crossings, jsonCrossing{
+			Edges: [2]string{c.EdgeA, c.EdgeB},
+			At:    jsonPoint(c.At),
+		})
+	}
+
+	data, err := json.MarshalIndent(out, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(data, '\n'), nil
+}
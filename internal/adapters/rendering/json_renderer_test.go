@@ -0,0 +1,164 @@
+package rendering
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/dnnrly/layli/internal/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestJSONRenderer_Render(t *testing.T) {
+	t.Run("writes the arranged diagram", func(t *testing.T) {
+		writer := &mockFileWriter{written: map[string][]byte{}}
+		renderer := NewJSONRenderer(writer)
+
+		diagram := newTestDiagram(
+			[]domain.Node{
+				{ID: "a", Contents: "A", Position: domain.Position{X: 3, Y: 3}, Width: 5, Height: 3, Class: "store",
+					Ports: []domain.Port{{Name: "http", Side: "east", Offset: 1}}},
+				{ID: "b", Contents: "B", Position: domain.Position{X: 10, Y: 7}, Width: 5, Height: 3, Shape: domain.ShapeCylinder},
+			},
+			[]domain.Edge{
+				{
+					ID: "e1", From: "a", To: "b", Label: "calls", FromPort: "http",
+					LabelPlacement: &domain.LabelPlacement{Position: domain.Position{X: 9, Y: 6}, Side: domain.LabelRight},
+					Path: &domain.Path{Points: []domain.Position{
+						{X: 5, Y: 4}, {X: 7, Y: 4}, {X: 8, Y: 4}, {X: 9, Y: 4},
+						{X: 9, Y: 6}, {X: 9, Y: 8}, {X: 10, Y: 8}, {X: 12, Y: 8},
+					}},
+				},
+				{ID: "e2", From: "b", To: "a", Arrow: domain.ArrowBoth, Head: domain.HeadDiamond},
+			},
+		)
+		diagram.Config.LayoutType = domain.LayoutFlowSquare
+		diagram.Config.Pathfinding = domain.PathfindingConfig{Algorithm: domain.PathfindingDijkstra, BendPenalty: 300}
+		diagram.Config.Styles = map[string]string{".store": "fill: #eef"}
+		diagram.Config.Seed = 1234
+		diagram.Crossings = []domain.Crossing{{EdgeA: "e1", EdgeB: "e2", At: domain.Position{X: 9, Y: 5}}}
+
+		err := renderer.Render(diagram, "output.json")
+		require.NoError(t, err)
+
+		assert.JSONEq(t, `{
+			"schema": "layli-layout",
+			"version": 1,
+			"config": {
+				"layout": "flow-square",
+				"layoutAttempts": 0,
+				"nodeWidth": 5,
+				"nodeHeight": 3,
+				"border": 1,
+				"margin": 2,
+				"spacing": 20,
+				"pathAttempts": 0,
+				"pathStrategy": "",
+				"pathfinding": {"algorithm": "dijkstra", "heuristic": "", "bendPenalty": 300},
+				"styles": {".store": "fill: #eef"},
+				"seed": 1234
+			},
+			"bounds": {
+				"grid": {"x": 0, "y": 0, "width": 18, "height": 13},
+				"pixels": {"x": 0, "y": 0, "width": 340, "height": 240}
+			},
+			"nodes": [
+				{
+					"id": "a",
+					"contents": "A",
+					"shape": "rounded",
+					"class": "store",
+					"ports": [{"name": "http", "side": "east", "offset": 1}],
+					"bounds": {
+						"grid": {"x": 3, "y": 3, "width": 5, "height": 3},
+						"pixels": {"x": 60, "y": 60, "width": 80, "height": 40}
+					}
+				},
+				{
+					"id": "b",
+					"contents": "B",
+					"shape": "cylinder",
+					"bounds": {
+						"grid": {"x": 10, "y": 7, "width": 5, "height": 3},
+						"pixels": {"x": 200, "y": 140, "width": 80, "height": 40}
+					}
+				}
+			],
+			"edges": [
+				{
+					"id": "e1",
+					"from": "a",
+					"to": "b",
+					"fromPort": "http",
+					"arrow": "forward",
+					"head": "triangle",
+					"label": "calls",
+					"labelPlacement": {"at": {"x": 9, "y": 6}, "side": "right"},
+					"points": [
+						{"x": 5, "y": 4}, {"x": 7, "y": 4}, {"x": 8, "y": 4}, {"x": 9, "y": 4},
+						{"x": 9, "y": 6}, {"x": 9, "y": 8}, {"x": 10, "y": 8}, {"x": 12, "y": 8}
+					],
+					"corners": [{"x": 9, "y": 4}, {"x": 9, "y": 8}],
+					"length": 7
+				},
+				{
+					"id": "e2",
+					"from": "b",
+					"to": "a",
+					"arrow": "both",
+					"head": "diamond",
+					"points": [],
+					"corners": [],
+					"length": 0
+				}
+			],
+			"groups": [],
+			"crossings": [{"edges": ["e1", "e2"], "at": {"x": 9, "y": 5}}]
+		}`, string(writer.written["output.json"]))
+	})
+
+	t.Run("groups", func(t *testing.T) {
+		diagram := newTestDiagram(
+			[]domain.Node{
+				{ID: "a", Contents: "A", Position: domain.Position{X: 4, Y: 8}, Width: 5, Height: 3},
+				{ID: "b", Contents: "B", Position: domain.Position{X: 14, Y: 8}, Width: 5, Height: 3},
+			},
+			nil,
+		)
+		diagram.Groups = []domain.Group{
+			{ID: "outer", Label: "Outer", Nodes: []string{"a"}, Groups: []domain.Group{
+				{ID: "inner", Nodes: []string{"b"}},
+			}},
+		}
+
+		out, err := ToJSON(diagram)
+		require.NoError(t, err)
+
+		var layout struct {
+			Groups []jsonGroup `json:"groups"`
+		}
+		require.NoError(t, json.Unmarshal(out, &layout))
+		assert.Equal(t, []jsonGroup{
+			{
+				ID: "outer", Label: "Outer", Nodes: []string{"a"}, Groups: []string{"inner"},
+				Bounds: jsonBounds{
+					Grid:   jsonBox{X: 2, Y: 2, Width: 21, Height: 13},
+					Pixels: jsonBox{X: 30, Y: 30, Width: 420, Height: 260},
+				},
+			},
+			{
+				ID: "inner", Parent: "outer", Nodes: []string{"b"}, Groups: []string{},
+				Bounds: jsonBounds{
+					Grid:   jsonBox{X: 12, Y: 5, Width: 9, Height: 8},
+					Pixels: jsonBox{X: 230, Y: 90, Width: 180, Height: 160},
+				},
+			},
+		}, layout.Groups)
+	})
+
+	t.Run("returns write errors", func(t *testing.T) {
+		writer := &mockFileWriter{written: map[string][]byte{}, err: assert.AnError}
+		err := NewJSONRenderer(writer).Render(newTestDiagram(nil, nil), "output.json")
+		assert.ErrorIs(t, err, assert.AnError)
+	})
+}
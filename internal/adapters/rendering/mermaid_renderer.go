@@ -0,0 +1,254 @@
+package rendering
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/dnnrly/layli/internal/domain"
+	"github.com/dnnrly/layli/internal/usecases"
+)
+
+var _ usecases.Renderer = (*MermaidRenderer)(nil)
+
+// MermaidRenderer writes a diagram as a Mermaid flowchart. Mermaid lays out
+// flowcharts itself, so only the nodes, edges, groups and styles are kept.
+type MermaidRenderer struct {
+	writer usecases.FileWriter
+}
+
+func NewMermaidRenderer(writer usecases.FileWriter) *MermaidRenderer {
+	return &MermaidRenderer{writer: writer}
+}
+
+func (r *MermaidRenderer) Render(diagram *domain.Diagram, outputPath string) error {
+	return r.writer.Write(outputPath, []byte(ToMermaid(diagram)))
+}
+
+// mermaidBrackets are the brackets that are put around the text of a node
+// to draw it as each shape. Shapes that Mermaid can't draw are boxes.
+var mermaidBrackets = map[domain.NodeShape][2]string{
+	"":                   {"(", ")"},
+	domain.ShapeRounded:  {"(", ")"},
+	domain.ShapeEllipse:  {"((", "))"},
+	domain.ShapeDiamond:  {"{", "}"},
+	domain.ShapeCylinder: {"[(", ")]"},
+	domain.ShapeHexagon:  {"{{", "}}"},
+}
+
+var mermaidUnsafe = regexp.MustCompile(`[^\p{L}\p{N}_-]`)
+
+// ToMermaid returns the diagram as a Mermaid flowchart
+func ToMermaid(diagram *domain.Diagram) string {
+	out := &strings.Builder{}
+	out.WriteString("flowchart TB\n")
+	ids := newMermaidIDs(diagram)
+
+	for _, n := range diagram.Nodes {
+		b, found := mermaidBrackets[n.Shape]
+		if !found {
+			b = [2]string{"[", "]"}
+		}
+		fmt.Fprintf(out, "    %s%s%s%s\n", ids.of(n.ID), b[0], mermaidQuote(n.Contents), b[1])
+	}
+
+	writeMermaidSubgraphs(out, ids, diagram.Groups, "    ")
+
+	for _, e := range diagram.Edges {
+		from, to := ids.of(e.From), ids.of(e.To)
+		link := mermaidLink(e.ArrowAtStart(), e.ArrowAtEnd(), e.Head)
+		if e.ArrowAtStart() && !e.ArrowAtEnd() {
+			// Mermaid links can't only have an arrow at the start, so it is
+			// drawn the other way round
+			from, to = to, from
+			link = mermaidLink(false, true, e.Head)
+		}
+
+		label := ""
+		if e.Label != "" {
+			label = "|" + mermaidQuote(e.Label) + "|"
+		}
+		fmt.Fprintf(out, "    %s %s%s %s\n", from, link, label, to)
+	}
+
+	writeMermaidStyles(out, ids, diagram)
+
+	return out.String()
+}
+
+// mermaidIDs are the IDs that nodes and groups are written with
+type mermaidIDs map[string]string
+
+// newMermaidIDs chooses an ID that Mermaid can read for each node and group.
+// IDs that are already safe are kept, and those that have to be changed are
+// given a number on the end if they would be the same as another ID.
+func newMermaidIDs(diagram *domain.Diagram) mermaidIDs {
+	all := []string{}
+	for _, n := range diagram.Nodes {
+		all = append(all, n.ID)
+	}
+	var addGroups func(groups []domain.Group)
+	addGroups = func(groups []domain.Group) {
+		for _, g := range groups {
+			all = append(all, g.ID)
+			addGroups(g.Groups)
+		}
+	}
+	addGroups(diagram.Groups)
+
+	ids := mermaidIDs{}
+	used := map[string]bool{}
+	for _, id := range all {
+		if mermaidNodeID(id) == id {
+			ids[id] = id
+			used[id] = true
+		}
+	}
+	for _, id := range all {
+		if _, found := ids[id]; found {
+			continue
+		}
+		clean := mermaidNodeID(id)
+		unique := clean
+		for n := 2; used[unique]; n++ {
+			unique = fmt.Sprintf("%s_%d", clean, n)
+		}
+		ids[id] = unique
+		used[unique] = true
+	}
+	return ids
+}
+
+// of returns the ID that a node or group is written with
+func (ids mermaidIDs) of(id string) string {
+	if m, found := ids[id]; found {
+		return m
+	}
+	return mermaidNodeID(id)
+}
+
+// writeMermaidSubgraphs writes groups as subgraphs. The nodes have already
+// been defined so only their IDs are needed.
+func writeMermaidSubgraphs(out *strings.Builder, ids mermaidIDs, groups []domain.Group, indent string) {
+	for _, g := range groups {
+		label := g.Label
+		if label == "" {
+			label = g.ID
+		}
+		fmt.Fprintf(out, "%ssubgraph %s [%s]\n", indent, ids.of(g.ID), mermaidQuote(label))
+		for _, id := range g.Nodes {
+			fmt.Fprintf(out, "%s    %s\n", indent, ids.of(id))
+		}
+		writeMermaidSubgraphs(out, ids, g.Groups, indent+"    ")
+		fmt.Fprintf(out, "%send\n", indent)
+	}
+}
+
+// writeMermaidStyles writes the styles for classes as class definitions and
+// the styles for IDs and single elements as styles for those elements.
+// Mermaid links don't have classes, so their class styles are added to the
+// style of each link. Classes with names that Mermaid can't read are left
+// out.
+func writeMermaidStyles(out *strings.Builder, ids mermaidIDs, diagram *domain.Diagram) {
+	selectors := make([]string, 0, len(diagram.Config.Styles))
+	for s := range diagram.Config.Styles {
+		selectors = append(selectors, s)
+	}
+	sort.Strings(selectors)
+
+	for _, s := range selectors {
+		if class, found := strings.CutPrefix(s, "."); found && class != "path-line" && mermaidSafe(class) {
+			fmt.Fprintf(out, "    classDef %s %s\n", class, mermaidCSS(diagram.Config.Styles[s]))
+		}
+	}
+	if css := diagram.Config.Styles[".path-line"]; css != "" {
+		fmt.Fprintf(out, "    linkStyle default %s\n", mermaidCSS(css))
+	}
+
+	for _, n := range diagram.Nodes {
+		for _, class := range strings.Fields(n.Class) {
+			if mermaidSafe(class) {
+				fmt.Fprintf(out, "    class %s %s\n", ids.of(n.ID), class)
+			}
+		}
+		css := resolveCSS(diagram.Config.Styles, n.ID, nil, n.Style)
+		if len(css) != 0 {
+			fmt.Fprintf(out, "    style %s %s\n", ids.of(n.ID), mermaidProps(css))
+		}
+	}
+
+	for i, e := range diagram.Edges {
+		css := resolveCSS(diagram.Config.Styles, e.ID, strings.Fields(e.Class), e.Style)
+		if len(css) != 0 {
+			fmt.Fprintf(out, "    linkStyle %d %s\n", i, mermaidProps(css))
+		}
+	}
+}
+
+// mermaidLink returns the link between 2 nodes with the arrow heads given
+func mermaidLink(start, end bool, head domain.ArrowHead) string {
+	tip := ">"
+	if head == domain.HeadCircle {
+		tip = "o"
+	}
+
+	switch {
+	case start && end && tip == ">":
+		return "<-->"
+	case start && end:
+		return tip + "--" + tip
+	case end:
+		return "--" + tip
+	}
+	return "---"
+}
+
+// mermaidSafe returns true if Mermaid can read the name as it is
+func mermaidSafe(name string) bool {
+	return mermaidUnsafe.FindString(name) == ""
+}
+
+// mermaidNodeID returns an ID that Mermaid can read, replacing any
+// characters that it can't. Mermaid reads end as the end of a subgraph, so
+// it can't be used as an ID either.
+func mermaidNodeID(id string) string {
+	id = mermaidUnsafe.ReplaceAllString(id, "_")
+	if strings.EqualFold(id, "end") {
+		id += "_"
+	}
+	return id
+}
+
+// mermaidQuote quotes text, using the entities and line breaks that Mermaid
+// understands
+func mermaidQuote(s string) string {
+	s = strings.ReplaceAll(s, `"`, "#quot;")
+	s = strings.ReplaceAll(s, "\n", "<br>")
+	return `"` + s + `"`
+}
+
+// mermaidCSS turns a block of CSS in to the comma separated properties that
+// Mermaid uses
+func mermaidCSS(css string) string {
+	decls := parseCSS(css)
+	props := make([]string, len(decls))
+	for i, d := range decls {
+		props[i] = d.property + ":" + d.value
+	}
+	return strings.Join(props, ",")
+}
+
+func mermaidProps(css map[string]string) string {
+	names := make([]string, 0, len(css))
+	for n := range css {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+
+	props := make([]string, len(names))
+	for i, n := range names {
+		props[i] = n + ":" + css[n]
+	}
+	return strings.Join(props, ",")
+}
@@ -0,0 +1,139 @@
+package rendering
+
+import (
+	"testing"
+
+	"github.com/dnnrly/layli/internal/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMermaidRenderer_Render(t *testing.T) {
+	t.Run("writes nodes and edges", func(t *testing.T) {
+		writer := &mockFileWriter{written: map[string][]byte{}}
+		renderer := NewMermaidRenderer(writer)
+
+		diagram := newTestDiagram(
+			[]domain.Node{
+				{ID: "a", Contents: "A"},
+				{ID: "b", Contents: "Say \"hi\"\nthen go", Shape: domain.ShapeDiamond},
+				{ID: "c.d", Contents: "C", Shape: domain.ShapeNote},
+				{ID: "end", Contents: "End", Shape: domain.ShapeEllipse},
+			},
+			[]domain.Edge{
+				{ID: "e1", From: "a", To: "b", Label: "yes"},
+				{ID: "e2", From: "b", To: "c.d", Arrow: domain.ArrowNone},
+				{ID: "e3", From: "b", To: "end", Arrow: domain.ArrowBackward},
+				{ID: "e4", From: "a", To: "end", Arrow: domain.ArrowBoth},
+				{ID: "e5", From: "a", To: "c.d", Head: domain.HeadCircle},
+			},
+		)
+
+		err := renderer.Render(diagram, "output.mmd")
+		require.NoError(t, err)
+
+		assert.Equal(t, ""+
+			"flowchart TB\n"+
+			"    a(\"A\")\n"+
+			"    b{\"Say #quot;hi#quot;<br>then go\"}\n"+
+			"    c_d[\"C\"]\n"+
+			"    end_((\"End\"))\n"+
+			"    a -->|\"yes\"| b\n"+
+			"    b --- c_d\n"+
+			"    end_ --> b\n"+
+			"    a <--> end_\n"+
+			"    a --o c_d\n",
+			string(writer.written["output.mmd"]))
+	})
+
+	t.Run("styles", func(t *testing.T) {
+		diagram := newTestDiagram(
+			[]domain.Node{
+				{ID: "a", Contents: "A", Class: "store main"},
+				{ID: "b", Contents: "B", Style: "fill: red;"},
+			},
+			[]domain.Edge{
+				{ID: "e1", From: "a", To: "b"},
+				{ID: "e2", From: "a", To: "b", Class: "async"},
+			},
+		)
+		diagram.Config.Styles = map[string]string{
+			".store":     "fill: #eef; stroke: #336;",
+			"#b":         "stroke: blue;",
+			".path-line": "stroke: #333;",
+			".async":     "stroke-dasharray: 4;",
+		}
+
+		assert.Contains(t, ToMermaid(diagram), ""+
+			"    classDef async stroke-dasharray:4\n"+
+			"    classDef store fill:#eef,stroke:#336\n"+
+			"    linkStyle default stroke:#333\n"+
+			"    class a store\n"+
+			"    class a main\n"+
+			"    style b fill:red,stroke:blue\n"+
+			"    linkStyle 1 stroke-dasharray:4\n")
+	})
+
+	t.Run("IDs that are the same once cleaned up are kept apart", func(t *testing.T) {
+		diagram := newTestDiagram(
+			[]domain.Node{
+				{ID: "a.b", Contents: "Dot"},
+				{ID: "a_b", Contents: "Underscore"},
+				{ID: "a b", Contents: "Space"},
+			},
+			[]domain.Edge{
+				{ID: "e1", From: "a.b", To: "a_b"},
+				{ID: "e2", From: "a b", To: "a.b"},
+			},
+		)
+		diagram.Groups = []domain.Group{{ID: "a:b", Nodes: []string{"a b"}}}
+
+		assert.Equal(t, ""+
+			"flowchart TB\n"+
+			"    a_b_2(\"Dot\")\n"+
+			"    a_b(\"Underscore\")\n"+
+			"    a_b_3(\"Space\")\n"+
+			"    subgraph a_b_4 [\"a:b\"]\n"+
+			"        a_b_3\n"+
+			"    end\n"+
+			"    a_b_2 --> a_b\n"+
+			"    a_b_3 --> a_b_2\n",
+			ToMermaid(diagram))
+	})
+
+	t.Run("classes that Mermaid can't read are left out", func(t *testing.T) {
+		diagram := newTestDiagram([]domain.Node{{ID: "a", Contents: "A", Class: "ok not:ok"}}, nil)
+		diagram.Config.Styles = map[string]string{
+			".ok":     "fill: red;",
+			".not:ok": "fill: blue;",
+		}
+
+		out := ToMermaid(diagram)
+		assert.Contains(t, out, "    classDef ok fill:red\n")
+		assert.Contains(t, out, "    class a ok\n")
+		assert.NotContains(t, out, "not:ok")
+	})
+
+	t.Run("groups are written as subgraphs", func(t *testing.T) {
+		diagram := newTestDiagram(
+			[]domain.Node{
+				{ID: "a", Contents: "A"},
+				{ID: "b", Contents: "B"},
+			},
+			nil,
+		)
+		diagram.Groups = []domain.Group{
+			{ID: "outer", Label: "Outer", Nodes: []string{"a"}, Groups: []domain.Group{
+				{ID: "inner", Nodes: []string{"b"}},
+			}},
+		}
+
+		assert.Contains(t, ToMermaid(diagram), ""+
+			"    subgraph outer [\"Outer\"]\n"+
+			"        a\n"+
+			"        subgraph inner [\"inner\"]\n"+
+			"            b\n"+
+			"        end\n"+
+			"    end\n")
+	})
+}
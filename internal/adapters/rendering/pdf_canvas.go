@@ -0,0 +1,303 @@
+package rendering
+
+import (
+	"fmt"
+	"image/color"
+	"math"
+	"strconv"
+	"strings"
+
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/gofont/goregular"
+	"golang.org/x/image/font/sfnt"
+	"golang.org/x/image/math/fixed"
+	"golang.org/x/text/encoding/charmap"
+
+	"github.com/dnnrly/layli/layout"
+)
+
+var _ layout.LayoutDrawer = (*pdfCanvas)(nil)
+
+// pdfCanvas writes the operators that draw a layout on a PDF page. The
+// operators use the coordinates of the SVG, which are turned in to the
+// coordinates of the page by the document.
+type pdfCanvas struct {
+	content strings.Builder
+	styles  map[string]string
+	font    *pdfFont
+
+	// text is the element started by Textspan that spans are drawn in
+	text *canvasElement
+
+	// err is the first error found while drawing, as the drawing calls
+	// can't return it
+	err error
+}
+
+func newPDFCanvas(styles map[string]string, f *pdfFont) *pdfCanvas {
+	return &pdfCanvas{styles: styles, font: f}
+}
+
+func (c *pdfCanvas) Circle(x int, y int, r int, s ...string) {
+	c.Ellipse(x, y, r, r, s...)
+}
+
+func (c *pdfCanvas) Ellipse(x int, y int, w int, h int, s ...string) {
+	c.draw([]canvasSubpath{ellipseSubpath(canvasPoint{float64(x), float64(y)}, float64(w), float64(h))}, readElement(c.styles, s))
+}
+
+func (c *pdfCanvas) Path(d string, s ...string) {
+	subpaths, err := parsePathData(d)
+	if err != nil {
+		c.fail(err)
+		return
+	}
+
+	el := readElement(c.styles, s)
+	c.draw(subpaths, el)
+	for _, m := range pathMarkers(subpaths, el) {
+		c.paint(m.outline, m.fill, m.stroke, m.width, nil)
+	}
+}
+
+func (c *pdfCanvas) Polygon(x []int, y []int, s ...string) {
+	points := make([]canvasPoint, len(x))
+	for i := range x {
+		points[i] = canvasPoint{float64(x[i]), float64(y[i])}
+	}
+	c.draw([]canvasSubpath{{points: points, closed: true}}, readElement(c.styles, s))
+}
+
+func (c *pdfCanvas) Rect(x int, y int, w int, h int, s ...string) {
+	c.Roundrect(x, y, w, h, 0, 0, s...)
+}
+
+func (c *pdfCanvas) Roundrect(x int, y int, w int, h int, rx int, ry int, s ...string) {
+	c.draw([]canvasSubpath{roundrectSubpath(x, y, w, h, rx, ry)}, readElement(c.styles, s))
+}
+
+func (c *pdfCanvas) Textspan(x int, y int, t string, s ...string) {
+	el := readElement(c.styles, s)
+	el.attrs["x"] = strconv.Itoa(x)
+	el.attrs["y"] = strconv.Itoa(y)
+	c.text = &el
+
+	if t != "" {
+		c.drawText(t, float64(x), float64(y), el)
+	}
+}
+
+func (c *pdfCanvas) Span(t string, s ...string) {
+	if c.text == nil {
+		return
+	}
+
+	span := readElement(c.styles, s)
+	x := cssLength(span.attrs["x"], cssLength(c.text.attrs["x"], 0))
+	y := cssLength(span.attrs["y"], cssLength(c.text.attrs["y"], 0))
+	c.drawText(t, x, y, *c.text)
+}
+
+func (c *pdfCanvas) TextEnd() {
+	c.text = nil
+}
+
+func (c *pdfCanvas) fail(err error) {
+	if c.err == nil {
+		c.err = err
+	}
+}
+
+func (c *pdfCanvas) op(format string, args ...any) {
+	fmt.Fprintf(&c.content, format+"\n", args...)
+}
+
+// draw fills and strokes the subpaths using the styles of the element
+func (c *pdfCanvas) draw(subpaths []canvasSubpath, el canvasElement) {
+	width := cssLength(el.css["stroke-width"], 1)
+	stroke := cssPaint(el.css["stroke"], color.Black)
+	if width <= 0 {
+		stroke = nil
+	}
+	c.paint(subpaths, cssPaint(el.css["fill"], nil), stroke, width, cssDashes(el.css["stroke-dasharray"]))
+}
+
+// paint fills and strokes the subpaths with the colours given, leaving out
+// the colours that are nil. The graphics state is set before the path is
+// started as it can't be changed while a path is being built.
+func (c *pdfCanvas) paint(subpaths []canvasSubpath, fill, stroke color.Color, width float64, dashes []float64) {
+	if len(subpaths) == 0 || (fill == nil && stroke == nil) {
+		return
+	}
+
+	c.op("q")
+	if fill != nil {
+		c.op("%s rg", pdfColour(fill))
+	}
+	if stroke != nil {
+		c.op("%s RG", pdfColour(stroke))
+		c.op("%s w", formatNumber(width))
+		if len(dashes) != 0 {
+			lengths := make([]string, len(dashes))
+			for i, d := range dashes {
+				lengths[i] = formatNumber(d)
+			}
+			c.op("[%s] 0 d", strings.Join(lengths, " "))
+		}
+	}
+
+	for _, sp := range subpaths {
+		for i, p := range sp.points {
+			ctrl, curve := sp.controls[i]
+			switch {
+			case i == 0:
+				c.op("%s %s m", formatNumber(p.x), formatNumber(p.y))
+			case curve:
+				c.op("%s %s %s %s %s %s c",
+					formatNumber(ctrl[0].x), formatNumber(ctrl[0].y),
+					formatNumber(ctrl[1].x), formatNumber(ctrl[1].y),
+					formatNumber(p.x), formatNumber(p.y))
+			default:
+				c.op("%s %s l", formatNumber(p.x), formatNumber(p.y))
+			}
+		}
+		if sp.closed {
+			c.op("h")
+		}
+	}
+
+	switch {
+	case fill != nil && stroke != nil:
+		c.op("B")
+	case fill != nil:
+		c.op("f")
+	default:
+		c.op("S")
+	}
+	c.op("Q")
+}
+
+// drawText writes a line of text with its baseline at y, lining it up with
+// x using the text anchor. The text is flipped back up the right way as the
+// page is upside down compared to the SVG.
+func (c *pdfCanvas) drawText(t string, x, y float64, el canvasElement) {
+	colour := el.textColour()
+	if colour == nil {
+		return
+	}
+
+	size := cssLength(el.css["font-size"], 10)
+	encoded := c.font.encode(t)
+	width := c.font.width(encoded) * size / 1000
+	switch el.css["text-anchor"] {
+	case "middle":
+		x -= width / 2
+	case "end":
+		x -= width
+	}
+
+	c.op("BT")
+	c.op("/F1 %s Tf", formatNumber(size))
+	c.op("%s rg", pdfColour(colour))
+	c.op("1 0 0 -1 %s %s Tm", formatNumber(x), formatNumber(y))
+	c.op("<%x> Tj", encoded)
+	c.op("ET")
+}
+
+// pdfColour returns the operands that set a colour
+func pdfColour(c color.Color) string {
+	r, g, b, _ := c.RGBA()
+	return fmt.Sprintf("%s %s %s",
+		formatNumber(float64(r)/0xffff),
+		formatNumber(float64(g)/0xffff),
+		formatNumber(float64(b)/0xffff),
+	)
+}
+
+// pdfFont is the font that is embedded in the PDF, so that the text looks
+// the same wherever it is opened. Text is written in the Windows code page
+// that PDF readers understand for TrueType fonts.
+type pdfFont struct {
+	ttf []byte
+
+	// widths are the advances of each character in thousandths of the font
+	// size
+	widths [256]int
+
+	bbox      [4]int
+	ascent    int
+	descent   int
+	capHeight int
+}
+
+// pdfFirstChar is the first character that has a width in the font
+const pdfFirstChar = 32
+
+func newPDFFont() (*pdfFont, error) {
+	f, err := sfnt.Parse(goregular.TTF)
+	if err != nil {
+		return nil, fmt.Errorf("loading font: %w", err)
+	}
+
+	// Measuring at the size of an em gives sizes in font units, which are
+	// turned in to thousandths
+	buf := &sfnt.Buffer{}
+	em := float64(f.UnitsPerEm())
+	ppem := fixed.Int26_6(f.UnitsPerEm()) << 6
+	units := func(v fixed.Int26_6) int {
+		return int(math.Round(float64(v) / 64 * 1000 / em))
+	}
+
+	pf := &pdfFont{ttf: goregular.TTF}
+	for code := pdfFirstChar; code < len(pf.widths); code++ {
+		glyph, err := f.GlyphIndex(buf, charmap.Windows1252.DecodeByte(byte(code)))
+		if err != nil || glyph == 0 {
+			continue
+		}
+		advance, err := f.GlyphAdvance(buf, glyph, ppem, font.HintingNone)
+		if err != nil {
+			return nil, fmt.Errorf("loading font: %w", err)
+		}
+		pf.widths[code] = units(advance)
+	}
+
+	bounds, err := f.Bounds(buf, ppem, font.HintingNone)
+	if err != nil {
+		return nil, fmt.Errorf("loading font: %w", err)
+	}
+	metrics, err := f.Metrics(buf, ppem, font.HintingNone)
+	if err != nil {
+		return nil, fmt.Errorf("loading font: %w", err)
+	}
+
+	// The font measures down the page and PDF measures up it
+	pf.bbox = [4]int{units(bounds.Min.X), -units(bounds.Max.Y), units(bounds.Max.X), -units(bounds.Min.Y)}
+	pf.ascent = units(metrics.Ascent)
+	pf.descent = -units(metrics.Descent)
+	pf.capHeight = units(metrics.CapHeight)
+	return pf, nil
+}
+
+// encode turns text in to the characters of the font, replacing anything
+// that it doesn't have with a question mark
+func (f *pdfFont) encode(s string) []byte {
+	encoded := make([]byte, 0, len(s))
+	for _, r := range s {
+		b, ok := charmap.Windows1252.EncodeRune(r)
+		if !ok || b < pdfFirstChar {
+			b = '?'
+		}
+		encoded = append(encoded, b)
+	}
+	return encoded
+}
+
+// width returns the width of the encoded text in thousandths of the font
+// size
+func (f *pdfFont) width(encoded []byte) float64 {
+	w := 0
+	for _, b := range encoded {
+		w += f.widths[b]
+	}
+	return float64(w)
+}
@@ -0,0 +1,154 @@
+package rendering
+
+import (
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"strings"
+
+	"github.com/dnnrly/layli/internal/domain"
+	"github.com/dnnrly/layli/internal/usecases"
+)
+
+var _ usecases.Renderer = (*PDFRenderer)(nil)
+
+// PDFRenderer draws the diagram as a vector PDF with the font embedded in
+// it. The page is the size of the diagram unless a paper size is chosen, in
+// which case the diagram is fitted on to the page.
+type PDFRenderer struct {
+	writer   usecases.FileWriter
+	pageSize string
+	showGrid bool
+}
+
+func NewPDFRenderer(writer usecases.FileWriter, pageSize string, showGrid bool) *PDFRenderer {
+	return &PDFRenderer{writer: writer, pageSize: strings.ToLower(pageSize), showGrid: showGrid}
+}
+
+// pdfPageSizes are the paper sizes that diagrams can be fitted on to, in
+// points when the paper is portrait
+var pdfPageSizes = map[string]canvasPoint{
+	"a4":     {595.28, 841.89},
+	"letter": {612, 792},
+}
+
+// pdfPageMargin is the space left around a diagram that is fitted on to
+// paper, in points
+const pdfPageMargin = 36
+
+// IsPageSize returns true if s is a paper size that PDFs can be fitted on to
+func IsPageSize(s string) bool {
+	_, found := pdfPageSizes[strings.ToLower(s)]
+	return found
+}
+
+func (r *PDFRenderer) Render(diagram *domain.Diagram, outputPath string) error {
+	l := buildLayout(diagram)
+	spacing := diagram.Config.Spacing
+
+	f, err := newPDFFont()
+	if err != nil {
+		return fmt.Errorf("rendering PDF: %w", err)
+	}
+
+	canvas := newPDFCanvas(diagram.Config.Styles, f)
+	if r.showGrid {
+		l.ShowGrid(canvas, spacing)
+	}
+	l.Draw(canvas, spacing)
+	if canvas.err != nil {
+		return fmt.Errorf("rendering PDF: %w", canvas.err)
+	}
+
+	page := r.page(float64((l.LayoutWidth()-1)*spacing), float64((l.LayoutHeight()-1)*spacing))
+	return r.writer.Write(outputPath, page.document(canvas.content.String(), f))
+}
+
+// pdfPage is the size of the page and where the diagram is drawn on it
+type pdfPage struct {
+	width, height float64
+	scale         float64
+	left, top     float64
+}
+
+// page works out the page that a diagram of the size given is drawn on. A
+// diagram that is fitted on to paper is turned to landscape if it is wider
+// than it is tall, shrunk if it is too big and put in the middle.
+func (r *PDFRenderer) page(width, height float64) pdfPage {
+	size, found := pdfPageSizes[r.pageSize]
+	if !found {
+		return pdfPage{width: width, height: height, scale: 1}
+	}
+
+	if width > height {
+		size = canvasPoint{size.y, size.x}
+	}
+	scale := min(1, (size.x-2*pdfPageMargin)/width, (size.y-2*pdfPageMargin)/height)
+
+	return pdfPage{
+		width:  size.x,
+		height: size.y,
+		scale:  scale,
+		left:   (size.x - width*scale) / 2,
+		top:    (size.y - height*scale) / 2,
+	}
+}
+
+// document writes a PDF with a single page showing the content. PDF pages
+// measure up from the bottom, so the content is flipped to measure down
+// from the top like the SVG.
+func (p pdfPage) document(content string, f *pdfFont) []byte {
+	content = fmt.Sprintf("q\n%s 0 0 %s %s %s cm\n%sQ\n",
+		formatNumber(p.scale), formatNumber(-p.scale),
+		formatNumber(p.left), formatNumber(p.height-p.top),
+		content,
+	)
+
+	widths := make([]string, 0, len(f.widths)-pdfFirstChar)
+	for _, w := range f.widths[pdfFirstChar:] {
+		widths = append(widths, fmt.Sprint(w))
+	}
+
+	objects := []string{
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		"<< /Type /Pages /Kids [3 0 R] /Count 1 >>",
+		fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %s %s] /Resources << /Font << /F1 5 0 R >> >> /Contents 4 0 R >>",
+			formatNumber(p.width), formatNumber(p.height)),
+		pdfStream("", []byte(content)),
+		fmt.Sprintf("<< /Type /Font /Subtype /TrueType /BaseFont /GoRegular /FirstChar %d /LastChar %d /Widths [%s] /Encoding /WinAnsiEncoding /FontDescriptor 6 0 R >>",
+			pdfFirstChar, len(f.widths)-1, strings.Join(widths, " ")),
+		fmt.Sprintf("<< /Type /FontDescriptor /FontName /GoRegular /Flags 32 /FontBBox [%d %d %d %d] /ItalicAngle 0 /Ascent %d /Descent %d /CapHeight %d /StemV 80 /FontFile2 7 0 R >>",
+			f.bbox[0], f.bbox[1], f.bbox[2], f.bbox[3], f.ascent, f.descent, f.capHeight),
+		pdfStream(fmt.Sprintf("/Length1 %d ", len(f.ttf)), f.ttf),
+	}
+
+	out := &bytes.Buffer{}
+	out.WriteString("%PDF-1.4\n%\xe2\xe3\xcf\xd3\n")
+	offsets := make([]int, len(objects))
+	for i, o := range objects {
+		offsets[i] = out.Len()
+		fmt.Fprintf(out, "%d 0 obj\n%s\nendobj\n", i+1, o)
+	}
+
+	xref := out.Len()
+	fmt.Fprintf(out, "xref\n0 %d\n0000000000 65535 f \n", len(objects)+1)
+	for _, o := range offsets {
+		fmt.Fprintf(out, "%010d 00000 n \n", o)
+	}
+	fmt.Fprintf(out, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(objects)+1, xref)
+
+	return out.Bytes()
+}
+
+// pdfStream returns a compressed stream object, with any extra entries that
+// go in its dictionary
+func pdfStream(entries string, data []byte) string {
+	compressed := &bytes.Buffer{}
+	w := zlib.NewWriter(compressed)
+	// Writing to a buffer can't fail
+	_, _ = w.Write(data)
+	_ = w.Close()
+
+	return fmt.Sprintf("<< %s/Length %d /Filter /FlateDecode >>\nstream\n%s\nendstream",
+		entries, compressed.Len(), compressed.Bytes())
+}
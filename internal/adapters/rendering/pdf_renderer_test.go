@@ -0,0 +1,125 @@
+package rendering
+
+import (
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"testing"
+
+	"github.com/dnnrly/layli/internal/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	pdfStartXref = regexp.MustCompile(`startxref\n(\d+)\n%%EOF\n$`)
+	pdfOffsets   = regexp.MustCompile(`(\d{10}) 00000 n `)
+	pdfMediaBox  = regexp.MustCompile(`/MediaBox \[([^\]]*)\]`)
+	pdfStreams   = regexp.MustCompile(`/Length (\d+) /Filter /FlateDecode >>\nstream\n`)
+)
+
+// renderPDF renders the diagram, checking that the objects are where the
+// cross reference table says they are, and returns the document with the
+// content of the page
+func renderPDF(t *testing.T, diagram *domain.Diagram, pageSize string) (string, string) {
+	t.Helper()
+	writer := &mockFileWriter{written: map[string][]byte{}}
+	err := NewPDFRenderer(writer, pageSize, false).Render(diagram, "output.pdf")
+	require.NoError(t, err)
+	doc := writer.written["output.pdf"]
+
+	require.True(t, bytes.HasPrefix(doc, []byte("%PDF-1.4\n")))
+	xref := pdfStartXref.FindSubmatch(doc)
+	require.NotNil(t, xref)
+	offset, _ := strconv.Atoi(string(xref[1]))
+	require.True(t, bytes.HasPrefix(doc[offset:], []byte("xref\n")))
+	for i, o := range pdfOffsets.FindAllSubmatch(doc, -1) {
+		offset, _ := strconv.Atoi(string(o[1]))
+		require.True(t, bytes.HasPrefix(doc[offset:], []byte(fmt.Sprintf("%d 0 obj\n", i+1))), "object %d", i+1)
+	}
+
+	stream := pdfStreams.FindSubmatchIndex(doc)
+	require.NotNil(t, stream)
+	length, _ := strconv.Atoi(string(doc[stream[2]:stream[3]]))
+	r, err := zlib.NewReader(bytes.NewReader(doc[stream[1] : stream[1]+length]))
+	require.NoError(t, err)
+	content, err := io.ReadAll(r)
+	require.NoError(t, err)
+
+	return string(doc), string(content)
+}
+
+func TestPDFRenderer_Render(t *testing.T) {
+	diagram := func() *domain.Diagram {
+		return newTestDiagram(
+			[]domain.Node{
+				{ID: "a", Contents: "A", Position: domain.Position{X: 3, Y: 3}, Width: 5, Height: 3, Shape: domain.ShapeRect},
+				{ID: "b", Contents: "Café", Position: domain.Position{X: 10, Y: 3}, Width: 5, Height: 3, Class: "store"},
+			},
+			[]domain.Edge{
+				{
+					ID: "e1", From: "a", To: "b",
+					Path: &domain.Path{Points: []domain.Position{
+						{X: 5, Y: 4}, {X: 7, Y: 4}, {X: 10, Y: 4}, {X: 12, Y: 4},
+					}},
+				},
+			},
+		)
+	}
+
+	t.Run("draws the layout on a page the size of the diagram", func(t *testing.T) {
+		doc, content := renderPDF(t, diagram(), "")
+
+		assert.Equal(t, "0 0 340 160", pdfMediaBox.FindStringSubmatch(doc)[1])
+		assert.Contains(t, content, "q\n1 0 0 -1 0 160 cm\n", "flips the page to measure from the top")
+		assert.Contains(t, content, "q\n0 0 0 RG\n1 w\n140 60 m\n140 100 l\n60 100 l\n60 60 l\nh\nS\nQ\n", "node a")
+		assert.Contains(t, content, "277 60 m\n278.66 60 280 61.34 280 63 c\n280 97 l\n", "the rounded corners of node b")
+		assert.Contains(t, content, "q\n0 0 0 RG\n1 w\n140 80 m\n200 80 l\nS\nQ\n", "the path")
+		assert.Contains(t, content, "q\n0 0 0 rg\n0 0 0 RG\n0.7 w\n193 76.5 m\n", "the arrow head")
+		assert.Contains(t, content, "<436166e9> Tj", "text is encoded for the font")
+		assert.Contains(t, doc, "/FontFile2 7 0 R")
+	})
+
+	t.Run("uses the styles of the diagram", func(t *testing.T) {
+		d := diagram()
+		d.Config.Styles = map[string]string{
+			".store":     "fill: #ff0000; stroke-dasharray: 6 3;",
+			".path-line": "stroke: blue; stroke-width: 2;",
+		}
+		_, content := renderPDF(t, d, "")
+
+		assert.Contains(t, content, "q\n1 0 0 rg\n0 0 0 RG\n1 w\n[6 3] 0 d\n277 60 m\n")
+		assert.Contains(t, content, "q\n0 0 1 RG\n2 w\n140 80 m\n")
+	})
+
+	t.Run("fits the diagram on to paper", func(t *testing.T) {
+		doc, content := renderPDF(t, diagram(), "letter")
+
+		assert.Equal(t, "0 0 792 612", pdfMediaBox.FindStringSubmatch(doc)[1], "wide diagrams are landscape")
+		assert.Contains(t, content, "q\n1 0 0 -1 226 386 cm\n", "small diagrams are in the middle of the page")
+	})
+
+	t.Run("shrinks big diagrams to fit", func(t *testing.T) {
+		d := diagram()
+		d.Config.Spacing = 200
+		doc, content := renderPDF(t, d, "A4")
+
+		assert.Equal(t, "0 0 841.89 595.28", pdfMediaBox.FindStringSubmatch(doc)[1])
+		assert.Contains(t, content, "q\n0.23 0 0 -0.23 36 ")
+	})
+
+	t.Run("returns write errors", func(t *testing.T) {
+		writer := &mockFileWriter{written: map[string][]byte{}, err: assert.AnError}
+		err := NewPDFRenderer(writer, "", false).Render(diagram(), "output.pdf")
+		assert.ErrorIs(t, err, assert.AnError)
+	})
+}
+
+func TestIsPageSize(t *testing.T) {
+	assert.True(t, IsPageSize("a4"))
+	assert.True(t, IsPageSize("Letter"))
+	assert.False(t, IsPageSize("a3"))
+}
@@ -0,0 +1,333 @@
+package rendering
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"math"
+	"strconv"
+
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/gofont/goregular"
+	"golang.org/x/image/font/opentype"
+	"golang.org/x/image/math/fixed"
+	"golang.org/x/image/vector"
+
+	"github.com/dnnrly/layli/layout"
+)
+
+var _ layout.LayoutDrawer = (*pngCanvas)(nil)
+
+// pngCanvas draws a layout on to an image
+type pngCanvas struct {
+	img    *image.RGBA
+	scale  float64
+	styles map[string]string
+
+	font  *opentype.Font
+	faces map[float64]font.Face
+
+	// text is the element started by Textspan that spans are drawn in
+	text *canvasElement
+
+	// err is the first error found while drawing, as the drawing calls
+	// can't return it
+	err error
+}
+
+// maxPNGPixels is the most pixels that an image can have, which keeps the
+// memory it needs to about 400MB
+const maxPNGPixels = 100_000_000
+
+func newPNGCanvas(width, height int, scale float64, styles map[string]string) (*pngCanvas, error) {
+	if math.IsNaN(scale) || math.IsInf(scale, 0) || scale <= 0 {
+		return nil, fmt.Errorf("scale must be more than 0: %g", scale)
+	}
+
+	// The size is checked before it is turned in to ints so that it can't
+	// overflow
+	w, h := math.Ceil(float64(width)*scale), math.Ceil(float64(height)*scale)
+	if w*h > maxPNGPixels {
+		return nil, fmt.Errorf("image of %gx%g pixels is too big, it can have at most %d pixels", w, h, maxPNGPixels)
+	}
+
+	f, err := opentype.Parse(goregular.TTF)
+	if err != nil {
+		return nil, fmt.Errorf("loading font: %w", err)
+	}
+
+	img := image.NewRGBA(image.Rect(0, 0, int(w), int(h)))
+	draw.Draw(img, img.Bounds(), image.White, image.Point{}, draw.Src)
+
+	return &pngCanvas{
+		img:    img,
+		scale:  scale,
+		styles: styles,
+		font:   f,
+		faces:  map[float64]font.Face{},
+	}, nil
+}
+
+func (c *pngCanvas) Circle(x int, y int, r int, s ...string) {
+	c.Ellipse(x, y, r, r, s...)
+}
+
+func (c *pngCanvas) Ellipse(x int, y int, w int, h int, s ...string) {
+	c.draw([]canvasSubpath{ellipseSubpath(canvasPoint{float64(x), float64(y)}, float64(w), float64(h))}, readElement(c.styles, s))
+}
+
+func (c *pngCanvas) Path(d string, s ...string) {
+	subpaths, err := parsePathData(d)
+	if err != nil {
+		c.fail(err)
+		return
+	}
+
+	el := readElement(c.styles, s)
+	c.draw(subpaths, el)
+	for _, m := range pathMarkers(subpaths, el) {
+		if m.fill != nil {
+			c.fill(m.outline, m.fill)
+		}
+		for _, sp := range m.outline {
+			c.stroke(sp, m.width, nil, m.stroke)
+		}
+	}
+}
+
+func (c *pngCanvas) Polygon(x []int, y []int, s ...string) {
+	points := make([]canvasPoint, len(x))
+	for i := range x {
+		points[i] = canvasPoint{float64(x[i]), float64(y[i])}
+	}
+	c.draw([]canvasSubpath{{points: points, closed: true}}, readElement(c.styles, s))
+}
+
+func (c *pngCanvas) Rect(x int, y int, w int, h int, s ...string) {
+	c.Roundrect(x, y, w, h, 0, 0, s...)
+}
+
+func (c *pngCanvas) Roundrect(x int, y int, w int, h int, rx int, ry int, s ...string) {
+	c.draw([]canvasSubpath{roundrectSubpath(x, y, w, h, rx, ry)}, readElement(c.styles, s))
+}
+
+func (c *pngCanvas) Textspan(x int, y int, t string, s ...string) {
+	el := readElement(c.styles, s)
+	el.attrs["x"] = strconv.Itoa(x)
+	el.attrs["y"] = strconv.Itoa(y)
+	c.text = &el
+
+	if t != "" {
+		c.drawText(t, float64(x), float64(y), el)
+	}
+}
+
+func (c *pngCanvas) Span(t string, s ...string) {
+	if c.text == nil {
+		return
+	}
+
+	span := readElement(c.styles, s)
+	x := cssLength(span.attrs["x"], cssLength(c.text.attrs["x"], 0))
+	y := cssLength(span.attrs["y"], cssLength(c.text.attrs["y"], 0))
+	c.drawText(t, x, y, *c.text)
+}
+
+func (c *pngCanvas) TextEnd() {
+	c.text = nil
+}
+
+func (c *pngCanvas) fail(err error) {
+	if c.err == nil {
+		c.err = err
+	}
+}
+
+// draw fills and then strokes the subpaths using the styles of the element.
+// Curves are drawn as straight lines that follow them closely.
+func (c *pngCanvas) draw(subpaths []canvasSubpath, el canvasElement) {
+	flat := make([]canvasSubpath, len(subpaths))
+	for i, sp := range subpaths {
+		flat[i] = sp.flatten()
+	}
+	subpaths = flat
+
+	if fill := cssPaint(el.css["fill"], nil); fill != nil {
+		c.fill(subpaths, fill)
+	}
+
+	width := cssLength(el.css["stroke-width"], 1)
+	if stroke := cssPaint(el.css["stroke"], color.Black); stroke != nil && width > 0 {
+		dashes := cssDashes(el.css["stroke-dasharray"])
+		for _, sp := range subpaths {
+			c.stroke(sp, width, dashes, stroke)
+		}
+	}
+}
+
+// fill paints the inside of the subpaths, closing any that are open
+func (c *pngCanvas) fill(subpaths []canvasSubpath, colour color.Color) {
+	polygons := [][]canvasPoint{}
+	for _, sp := range subpaths {
+		if len(sp.points) > 2 {
+			polygons = append(polygons, sp.points)
+		}
+	}
+	c.paint(polygons, colour)
+}
+
+// stroke draws a line of the width given along the subpath. Each straight
+// part is drawn on its own, reaching past the corners by half of the width
+// so that right angled corners are square.
+func (c *pngCanvas) stroke(sp canvasSubpath, width float64, dashes []float64, colour color.Color) {
+	points := sp.points
+	if sp.closed && len(points) > 1 {
+		points = append(points[:len(points):len(points)], points[0])
+	}
+	joined := sp.closed && len(dashes) == 0
+
+	for _, line := range pngDash(points, dashes) {
+		for i := 1; i < len(line); i++ {
+			a, b := line[i-1], line[i]
+			length := math.Hypot(b.x-a.x, b.y-a.y)
+			if length == 0 {
+				continue
+			}
+
+			dx, dy := (b.x-a.x)/length*width/2, (b.y-a.y)/length*width/2
+			if i > 1 || joined {
+				a = canvasPoint{a.x - dx, a.y - dy}
+			}
+			if i < len(line)-1 || joined {
+				b = canvasPoint{b.x + dx, b.y + dy}
+			}
+			c.paint([][]canvasPoint{{
+				{a.x - dy, a.y + dx},
+				{b.x - dy, b.y + dx},
+				{b.x + dy, b.y - dx},
+				{a.x + dy, a.y - dx},
+			}}, colour)
+		}
+	}
+}
+
+// paint fills the polygons with the colour. The rasterizer only covers the
+// area around the polygons, which keeps drawing small parts of big images
+// quick.
+func (c *pngCanvas) paint(polygons [][]canvasPoint, colour color.Color) {
+	if len(polygons) == 0 {
+		return
+	}
+
+	minX, minY := math.Inf(1), math.Inf(1)
+	maxX, maxY := math.Inf(-1), math.Inf(-1)
+	for _, p := range polygons {
+		for _, pt := range p {
+			minX, minY = math.Min(minX, pt.x*c.scale), math.Min(minY, pt.y*c.scale)
+			maxX, maxY = math.Max(maxX, pt.x*c.scale), math.Max(maxY, pt.y*c.scale)
+		}
+	}
+	r := image.Rect(
+		int(math.Floor(minX)), int(math.Floor(minY)),
+		int(math.Ceil(maxX)), int(math.Ceil(maxY)),
+	).Intersect(c.img.Bounds())
+	if r.Empty() {
+		return
+	}
+
+	z := vector.NewRasterizer(r.Dx(), r.Dy())
+	for _, p := range polygons {
+		for i, pt := range p {
+			x, y := float32(pt.x*c.scale-float64(r.Min.X)), float32(pt.y*c.scale-float64(r.Min.Y))
+			if i == 0 {
+				z.MoveTo(x, y)
+			} else {
+				z.LineTo(x, y)
+			}
+		}
+		z.ClosePath()
+	}
+	z.Draw(c.img, r, image.NewUniform(colour), image.Point{})
+}
+
+// drawText writes a line of text with its baseline at y, lining it up with
+// x using the text anchor
+func (c *pngCanvas) drawText(t string, x, y float64, el canvasElement) {
+	colour := el.textColour()
+	if colour == nil {
+		return
+	}
+
+	face, err := c.face(cssLength(el.css["font-size"], 10) * c.scale)
+	if err != nil {
+		c.fail(err)
+		return
+	}
+
+	x *= c.scale
+	width := float64(font.MeasureString(face, t)) / 64
+	switch el.css["text-anchor"] {
+	case "middle":
+		x -= width / 2
+	case "end":
+		x -= width
+	}
+
+	d := font.Drawer{
+		Dst:  c.img,
+		Src:  image.NewUniform(colour),
+		Face: face,
+		Dot:  fixed.Point26_6{X: fixed.Int26_6(x * 64), Y: fixed.Int26_6(y * c.scale * 64)},
+	}
+	d.DrawString(t)
+}
+
+// face returns the font at the size given, in pixels
+func (c *pngCanvas) face(size float64) (font.Face, error) {
+	if f, found := c.faces[size]; found {
+		return f, nil
+	}
+
+	f, err := opentype.NewFace(c.font, &opentype.FaceOptions{Size: size, DPI: 72, Hinting: font.HintingNone})
+	if err != nil {
+		return nil, fmt.Errorf("loading font: %w", err)
+	}
+	c.faces[size] = f
+	return f, nil
+}
+
+// pngDash splits a line in to the dashes that are drawn
+func pngDash(points []canvasPoint, dashes []float64) [][]canvasPoint {
+	if len(dashes) == 0 || len(points) < 2 {
+		return [][]canvasPoint{points}
+	}
+
+	lines := [][]canvasPoint{}
+	dash, left, on := 0, dashes[0], true
+	current := []canvasPoint{points[0]}
+	for i := 1; i < len(points); i++ {
+		a, b := points[i-1], points[i]
+		length := math.Hypot(b.x-a.x, b.y-a.y)
+		done := 0.0
+		for length-done > left {
+			done += left
+			p := canvasPoint{a.x + (b.x-a.x)*done/length, a.y + (b.y-a.y)*done/length}
+			if on {
+				lines = append(lines, append(current, p))
+			}
+			current = []canvasPoint{p}
+			on = !on
+			dash = (dash + 1) % len(dashes)
+			left = dashes[dash]
+		}
+		left -= length - done
+		if on {
+			current = append(current, b)
+		}
+	}
+	if on && len(current) > 1 {
+		lines = append(lines, current)
+	}
+	return lines
+}
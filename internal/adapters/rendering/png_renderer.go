@@ -0,0 +1,55 @@
+package rendering
+
+import (
+	"bytes"
+	"fmt"
+	"image/png"
+
+	"github.com/dnnrly/layli/internal/domain"
+	"github.com/dnnrly/layli/internal/usecases"
+)
+
+var _ usecases.Renderer = (*PNGRenderer)(nil)
+
+// PNGRenderer draws the diagram as a PNG image, for places that can't show
+// SVGs. The layout is drawn in the same way as it is for the SVG, with the
+// scale making the image bigger for high resolution screens.
+type PNGRenderer struct {
+	writer   usecases.FileWriter
+	scale    float64
+	showGrid bool
+}
+
+func NewPNGRenderer(writer usecases.FileWriter, scale float64, showGrid bool) *PNGRenderer {
+	return &PNGRenderer{writer: writer, scale: scale, showGrid: showGrid}
+}
+
+func (r *PNGRenderer) Render(diagram *domain.Diagram, outputPath string) error {
+	l := buildLayout(diagram)
+	spacing := diagram.Config.Spacing
+
+	canvas, err := newPNGCanvas(
+		(l.LayoutWidth()-1)*spacing,
+		(l.LayoutHeight()-1)*spacing,
+		r.scale,
+		diagram.Config.Styles,
+	)
+	if err != nil {
+		return fmt.Errorf("rendering PNG: %w", err)
+	}
+
+	if r.showGrid {
+		l.ShowGrid(canvas, spacing)
+	}
+	l.Draw(canvas, spacing)
+	if canvas.err != nil {
+		return fmt.Errorf("rendering PNG: %w", canvas.err)
+	}
+
+	buf := &bytes.Buffer{}
+	if err := png.Encode(buf, canvas.img); err != nil {
+		return fmt.Errorf("rendering PNG: %w", err)
+	}
+
+	return r.writer.Write(outputPath, buf.Bytes())
+}
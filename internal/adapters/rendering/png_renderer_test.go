@@ -0,0 +1,100 @@
+package rendering
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/png"
+	"math"
+	"testing"
+
+	"github.com/dnnrly/layli/internal/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func renderPNG(t *testing.T, diagram *domain.Diagram, scale float64) image.Image {
+	t.Helper()
+	writer := &mockFileWriter{written: map[string][]byte{}}
+	err := NewPNGRenderer(writer, scale, false).Render(diagram, "output.png")
+	require.NoError(t, err)
+
+	img, err := png.Decode(bytes.NewReader(writer.written["output.png"]))
+	require.NoError(t, err)
+	return img
+}
+
+func rgba(c color.Color) color.RGBA {
+	return color.RGBAModel.Convert(c).(color.RGBA)
+}
+
+func TestPNGRenderer_Render(t *testing.T) {
+	diagram := func() *domain.Diagram {
+		return newTestDiagram(
+			[]domain.Node{
+				{ID: "a", Contents: "A", Position: domain.Position{X: 3, Y: 3}, Width: 5, Height: 3},
+				{ID: "b", Contents: "B", Position: domain.Position{X: 10, Y: 3}, Width: 5, Height: 3, Class: "store"},
+			},
+			[]domain.Edge{
+				{
+					ID: "e1", From: "a", To: "b",
+					Path: &domain.Path{Points: []domain.Position{
+						{X: 5, Y: 4}, {X: 7, Y: 4}, {X: 10, Y: 4}, {X: 12, Y: 4},
+					}},
+				},
+			},
+		)
+	}
+
+	// Lines are 1 pixel wide and drawn along the grid, so they are only
+	// solid colours when the image is scaled up
+	t.Run("draws the layout on a white background", func(t *testing.T) {
+		img := renderPNG(t, diagram(), 2)
+
+		assert.Equal(t, color.RGBA{255, 255, 255, 255}, rgba(img.At(10, 10)), "background")
+		assert.Equal(t, color.RGBA{0, 0, 0, 255}, rgba(img.At(200, 120)), "top of node a")
+		assert.Equal(t, color.RGBA{0, 0, 0, 255}, rgba(img.At(340, 160)), "path between the nodes")
+		assert.Equal(t, color.RGBA{255, 255, 255, 255}, rgba(img.At(340, 150)), "beside the path")
+	})
+
+	t.Run("uses the styles of the diagram", func(t *testing.T) {
+		d := diagram()
+		d.Config.Styles = map[string]string{
+			".store":     "fill: #eeeeff; stroke: rgb(0, 0, 255);",
+			".path-line": "stroke: red;",
+		}
+		img := renderPNG(t, d, 2)
+
+		assert.Equal(t, color.RGBA{0xee, 0xee, 0xff, 255}, rgba(img.At(500, 140)), "inside node b")
+		assert.Equal(t, color.RGBA{0, 0, 255, 255}, rgba(img.At(480, 120)), "top of node b")
+		assert.Equal(t, color.RGBA{255, 0, 0, 255}, rgba(img.At(340, 160)), "path between the nodes")
+		assert.Equal(t, color.RGBA{255, 0, 0, 255}, rgba(img.At(394, 160)), "arrow head")
+	})
+
+	t.Run("scales the image", func(t *testing.T) {
+		assert.Equal(t, image.Rect(0, 0, 340, 160), renderPNG(t, diagram(), 1).Bounds())
+		assert.Equal(t, image.Rect(0, 0, 680, 320), renderPNG(t, diagram(), 2).Bounds())
+	})
+
+	t.Run("rejects scales that aren't more than 0", func(t *testing.T) {
+		for _, scale := range []float64{0, -1, math.NaN(), math.Inf(1)} {
+			writer := &mockFileWriter{written: map[string][]byte{}}
+			err := NewPNGRenderer(writer, scale, false).Render(diagram(), "output.png")
+			assert.ErrorContains(t, err, "scale must be more than 0")
+			assert.Empty(t, writer.written)
+		}
+	})
+
+	t.Run("rejects images that are too big", func(t *testing.T) {
+		writer := &mockFileWriter{written: map[string][]byte{}}
+		err := NewPNGRenderer(writer, 1000, false).Render(diagram(), "output.png")
+		assert.EqualError(t, err, "rendering PNG: image of 340000x160000 pixels is too big, it can have at most 100000000 pixels")
+		assert.Empty(t, writer.written)
+	})
+
+	t.Run("returns write errors", func(t *testing.T) {
+		writer := &mockFileWriter{written: map[string][]byte{}, err: assert.AnError}
+		err := NewPNGRenderer(writer, 1, false).Render(diagram(), "output.png")
+		assert.ErrorIs(t, err, assert.AnError)
+	})
+}
@@ -0,0 +1,43 @@
+package rendering
+
+import (
+	"github.com/dnnrly/layli/internal/domain"
+	"github.com/dnnrly/layli/layout"
+)
+
+// groupParents returns the group that each node and group sits directly
+// inside of. Nested groups come after the groups around them, so they win
+// when something is listed in both.
+func groupParents(groups layout.LayoutGroups) map[string]string {
+	parents := map[string]string{}
+	for _, g := range groups {
+		for _, id := range g.Nodes {
+			parents[id] = g.Id
+		}
+		for _, id := range g.Groups {
+			parents[id] = g.Id
+		}
+	}
+	return parents
+}
+
+// edgeRoute returns the route of an edge between its nodes, from the port
+// that it leaves by to the port that it enters by, keeping only the corners
+// in between. It is nil for edges that haven't been routed.
+func edgeRoute(e domain.Edge) []domain.Position {
+	if e.Path == nil || len(e.Path.Points) < 4 {
+		return nil
+	}
+
+	// The first and last points are inside the nodes
+	points := e.Path.Points[1 : len(e.Path.Points)-1]
+	route := []domain.Position{points[0]}
+	for i := 1; i < len(points)-1; i++ {
+		a, p, b := points[i-1], points[i], points[i+1]
+		if (a.X == p.X && p.X == b.X) || (a.Y == p.Y && p.Y == b.Y) {
+			continue
+		}
+		route = append(route, p)
+	}
+	return append(route, points[len(points)-1])
+}
@@ -3,6 +3,7 @@ package rendering
 import (
 	"fmt"
 
+	"github.com/dnnrly/layli/internal/adapters"
 	"github.com/dnnrly/layli/internal/domain"
 	"github.com/dnnrly/layli/internal/usecases"
 	"github.com/dnnrly/layli/layout"
@@ -20,27 +21,14 @@ func NewSVGRenderer(writer usecases.FileWriter, showGrid bool) *SVGRenderer {
 }
 
 func (r *SVGRenderer) Render(diagram *domain.Diagram, outputPath string) error {
-	cfg := buildConfig(diagram)
-	nodes := buildLayoutNodes(diagram)
-	paths := buildLayoutPaths(diagram)
-
-	layoutObj := layout.NewLayout(
-		nodes, paths,
-		diagram.Config.NodeWidth,
-		diagram.Config.NodeHeight,
-		diagram.Config.Margin,
-		diagram.Config.Border,
-		diagram.Config.Spacing,
-	)
-
 	var svgOutput string
 	rootDiagram := layout.Diagram{
 		Output: func(output string) error {
 			svgOutput = output
 			return nil
 		},
-		Config:   cfg,
-		Layout:   layoutObj,
+		Config:   buildConfig(diagram),
+		Layout:   buildLayout(diagram),
 		ShowGrid: r.showGrid,
 	}
 
@@ -51,6 +39,28 @@ func (r *SVGRenderer) Render(diagram *domain.Diagram, outputPath string) error {
 	return r.writer.Write(outputPath, []byte(svgOutput))
 }
 
+// buildLayout turns the diagram back in to a layout so that it can be drawn
+func buildLayout(diagram *domain.Diagram) *layout.Layout {
+	nodes := buildLayoutNodes(diagram)
+	paths := buildLayoutPaths(diagram)
+
+	layoutObj := layout.NewLayout(
+		nodes, paths,
+		diagram.Config.NodeWidth,
+		diagram.Config.NodeHeight,
+		diagram.Config.Margin,
+		diagram.Config.Border,
+		diagram.Config.Spacing,
+	)
+	layoutObj.Groups = layout.NewLayoutGroups(
+		adapters.ToLayoutGroups(diagram.Groups),
+		nodes,
+		diagram.Config.Margin,
+		diagram.Config.Spacing,
+	)
+	return layoutObj
+}
+
 func buildConfig(diagram *domain.Diagram) layout.Config {
 	styles := layout.ConfigStyles{}
 	for k, v := range diagram.Config.Styles {
@@ -64,29 +74,13 @@ func buildConfig(diagram *domain.Diagram) layout.Config {
 		Border:     diagram.Config.Border,
 		Margin:     diagram.Config.Margin,
 		Spacing:    diagram.Config.Spacing,
+		Seed:       diagram.Config.Seed,
 		Styles:     styles,
 	}
 }
 
 func buildLayoutNodes(diagram *domain.Diagram) layout.LayoutNodes {
-	nodes := make(layout.LayoutNodes, len(diagram.Nodes))
-	for i, n := range diagram.Nodes {
-		width := n.Width
-		if width == 0 {
-			width = diagram.Config.NodeWidth
-		}
-		height := n.Height
-		if height == 0 {
-			height = diagram.Config.NodeHeight
-		}
-		nodes[i] = layout.NewLayoutNode(
-			n.ID, n.Contents,
-			n.Position.X, n.Position.Y,
-			width, height,
-			n.Class, n.Style,
-		)
-	}
-	return nodes
+	return adapters.ToLayoutNodes(diagram)
 }
 
 func buildLayoutPaths(diagram *domain.Diagram) layout.LayoutPaths {
@@ -101,13 +95,27 @@ func buildLayoutPaths(diagram *domain.Diagram) layout.LayoutPaths {
 		for j, p := range e.Path.Points {
 			points[j] = layout.Point{X: float64(p.X), Y: float64(p.Y)}
 		}
+		var placement *layout.LabelPlacement
+		if e.LabelPlacement != nil {
+			placement = &layout.LabelPlacement{
+				At: layout.Point{
+					X: float64(e.LabelPlacement.Position.X),
+					Y: float64(e.LabelPlacement.Position.Y),
+				},
+				Side: string(e.LabelPlacement.Side),
+			}
+		}
 		paths = append(paths, layout.LayoutPath{
-			ID:     e.ID,
-			From:   e.From,
-			To:     e.To,
-			Points: points,
-			Class:  e.Class,
-			Style:  e.Style,
+			ID:             e.ID,
+			From:           e.From,
+			To:             e.To,
+			Points:         points,
+			Class:          e.Class,
+			Style:          e.Style,
+			Arrow:          string(e.Arrow),
+			Head:           string(e.Head),
+			Label:          e.Label,
+			LabelPlacement: placement,
 		})
 	}
 	return paths
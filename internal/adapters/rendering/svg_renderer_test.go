@@ -228,6 +228,119 @@ func TestSVGRenderer_Render(t *testing.T) {
 	})
 }
 
+func TestSVGRenderer_RendersGroups(t *testing.T) {
+	writer := &mockFileWriter{written: map[string][]byte{}}
+	renderer := NewSVGRenderer(writer, false)
+
+	diagram := newTestDiagram(
+		[]domain.Node{
+			{ID: "api", Position: domain.Position{X: 4, Y: 5}, Width: 5, Height: 3},
+			{ID: "web", Position: domain.Position{X: 15, Y: 5}, Width: 5, Height: 3},
+		},
+		nil,
+	)
+	diagram.Groups = []domain.Group{{ID: "payments", Label: "Payments", Nodes: []string{"api"}}}
+
+	err := renderer.Render(diagram, "output.svg")
+	require.NoError(t, err)
+
+	svg := string(writer.written["output.svg"])
+	assert.Contains(t, svg, `<rect x="30" y="30" width="180" height="160" rx="6" ry="6" id="payments" class="group"`)
+	assert.Contains(t, svg, `data-nodes="api"`)
+	assert.Contains(t, svg, `id="payments-title"`)
+	assert.Contains(t, svg, ">Payments<")
+}
+
+func TestSVGRenderer_RendersEdgeLabels(t *testing.T) {
+	writer := &mockFileWriter{written: map[string][]byte{}}
+	renderer := NewSVGRenderer(writer, false)
+
+	diagram := newTestDiagram(
+		[]domain.Node{
+			{ID: "a", Contents: "A", Position: domain.Position{X: 3, Y: 3}, Width: 5, Height: 3},
+			{ID: "b", Contents: "B", Position: domain.Position{X: 12, Y: 3}, Width: 5, Height: 3},
+		},
+		[]domain.Edge{
+			{
+				ID: "e1", From: "a", To: "b",
+				Label: "HTTP",
+				Path: &domain.Path{Points: []domain.Position{
+					{X: 5, Y: 4}, {X: 7, Y: 4}, {X: 12, Y: 4}, {X: 14, Y: 4},
+				}},
+				LabelPlacement: &domain.LabelPlacement{
+					Position: domain.Position{X: 9, Y: 4},
+					Side:     domain.LabelAbove,
+				},
+			},
+		},
+	)
+
+	err := renderer.Render(diagram, "labels.svg")
+	require.NoError(t, err)
+
+	svg := string(writer.written["labels.svg"])
+	assert.Regexp(t, `<text x="180" y="76" id="e1-label" class="path-label" data-side="above" [^>]*>HTTP</text>`, svg)
+}
+
+func TestSVGRenderer_RendersShapes(t *testing.T) {
+	writer := &mockFileWriter{written: map[string][]byte{}}
+	renderer := NewSVGRenderer(writer, false)
+
+	diagram := newTestDiagram(
+		[]domain.Node{
+			{ID: "a", Contents: "A", Position: domain.Position{X: 3, Y: 3}, Width: 5, Height: 3, Shape: domain.ShapeEllipse},
+			{ID: "b", Contents: "B", Position: domain.Position{X: 12, Y: 3}, Width: 5, Height: 3, Shape: domain.ShapeDiamond},
+		},
+		[]domain.Edge{
+			{
+				ID: "e1", From: "a", To: "b",
+				Path: &domain.Path{Points: []domain.Position{
+					{X: 5, Y: 4}, {X: 7, Y: 4}, {X: 12, Y: 4}, {X: 14, Y: 4},
+				}},
+			},
+		},
+	)
+
+	err := renderer.Render(diagram, "shapes.svg")
+	require.NoError(t, err)
+
+	svg := string(writer.written["shapes.svg"])
+	assert.Contains(t, svg, `<ellipse cx="100" cy="80" rx="40" ry="20" id="a"`)
+	assert.Contains(t, svg, `<polygon points="280,60 320,80 280,100 240,80" id="b"`)
+	assert.Contains(t, svg, `<path d="M 140 80 L 240 80" id="e1"`,
+		"the ports are in the middle of the sides, where the shapes meet their boxes")
+}
+
+func TestSVGRenderer_RendersArrowHeads(t *testing.T) {
+	writer := &mockFileWriter{written: map[string][]byte{}}
+	renderer := NewSVGRenderer(writer, false)
+
+	diagram := newTestDiagram(
+		[]domain.Node{
+			{ID: "a", Contents: "A", Position: domain.Position{X: 3, Y: 3}, Width: 5, Height: 3},
+			{ID: "b", Contents: "B", Position: domain.Position{X: 12, Y: 3}, Width: 5, Height: 3},
+		},
+		[]domain.Edge{
+			{
+				ID: "e1", From: "a", To: "b",
+				Arrow: domain.ArrowBackward, Head: domain.HeadDiamond,
+				Style: "stroke:red",
+				Path: &domain.Path{Points: []domain.Position{
+					{X: 5, Y: 4}, {X: 7, Y: 4}, {X: 12, Y: 4}, {X: 14, Y: 4},
+				}},
+			},
+		},
+	)
+
+	err := renderer.Render(diagram, "arrows.svg")
+	require.NoError(t, err)
+
+	svg := string(writer.written["arrows.svg"])
+	assert.Contains(t, svg, `<marker id="arrow-diamond-red" `)
+	assert.Contains(t, svg, `marker-start="url(#arrow-diamond-red)"`)
+	assert.NotContains(t, svg, `marker-end`)
+}
+
 func TestNewSVGRenderer_implements_Renderer(t *testing.T) {
 	writer := &mockFileWriter{written: map[string][]byte{}}
 	var _ interface {
@@ -0,0 +1,23 @@
+package reporting
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/dnnrly/layli/internal/usecases"
+)
+
+var _ usecases.Reporter = (*WriterReporter)(nil)
+
+// WriterReporter writes each warning on its own line, usually to stderr.
+type WriterReporter struct {
+	writer io.Writer
+}
+
+func NewWriterReporter(writer io.Writer) *WriterReporter {
+	return &WriterReporter{writer: writer}
+}
+
+func (r *WriterReporter) Warn(message string) {
+	fmt.Fprintf(r.writer, "warning: %s\n", message)
+}
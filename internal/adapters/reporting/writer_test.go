@@ -0,0 +1,18 @@
+package reporting
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWriterReporter_Warn(t *testing.T) {
+	buf := &bytes.Buffer{}
+	r := NewWriterReporter(buf)
+
+	r.Warn("first problem")
+	r.Warn("second problem")
+
+	assert.Equal(t, "warning: first problem\nwarning: second problem\n", buf.String())
+}
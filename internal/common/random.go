@@ -1,8 +1,11 @@
 package common
 
 import (
+	"fmt"
+	"math"
 	"math/rand"
 	"os"
+	"strconv"
 	"sync"
 	"time"
 )
@@ -34,16 +37,20 @@ func (s *Service) Shuffle(n int, swap func(i, j int)) {
 	s.rng.Shuffle(n, swap)
 }
 
-// defaultService is used for backward compatibility
-var defaultService = &Service{
-	rng: rand.New(rand.NewSource(time.Now().UnixNano())),
-}
-
-// Shuffle provides a global shuffle function that respects test seeding
-func Shuffle(n int, swap func(i, j int)) {
-	// Check if we're in test mode and need deterministic behavior
+// NewSeed returns a seed for when none has been chosen, so that it can be
+// recorded and the same random choices made again. LAYLI_TEST_SEED sets it
+// to make tests repeatable, otherwise it comes from the time.
+func NewSeed() (int64, error) {
 	if seedStr := os.Getenv("LAYLI_TEST_SEED"); seedStr != "" {
-		defaultService.rng = rand.New(rand.NewSource(42))
+		seed, err := strconv.ParseInt(seedStr, 10, 64)
+		if err != nil {
+			return 0, fmt.Errorf("LAYLI_TEST_SEED must be a whole number: %s", seedStr)
+		}
+		return seed, nil
 	}
-	defaultService.Shuffle(n, swap)
+
+	// Keep seeds short enough to type back in and never 0, which means
+	// that a seed hasn't been chosen
+	rng := rand.New(rand.NewSource(time.Now().UnixNano()))
+	return rng.Int63n(math.MaxInt32) + 1, nil
 }
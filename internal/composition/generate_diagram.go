@@ -1,24 +1,64 @@
 package composition
 
 import (
-	"github.com/dnnrly/layli/internal/adapters/config"
+	"io"
+
 	"github.com/dnnrly/layli/internal/adapters/filesystem"
 	"github.com/dnnrly/layli/internal/adapters/layout"
 	"github.com/dnnrly/layli/internal/adapters/pathfinding"
-	"github.com/dnnrly/layli/internal/adapters/rendering"
+	"github.com/dnnrly/layli/internal/adapters/reporting"
+	"github.com/dnnrly/layli/internal/domain"
 	"github.com/dnnrly/layli/internal/usecases"
 )
 
+// Options controls how the adapters are wired together.
+type Options struct {
+	// ShowGrid draws the path grid dots (SVG, PNG and PDF only).
+	ShowGrid bool
+	// Scale multiplies the size of PNG images, for high resolution
+	// screens. It must be more than 0, with 1 drawing them at their normal
+	// size.
+	Scale float64
+	// PageSize fits PDFs on to a paper size, such as a4 or letter. When
+	// empty, the page is the size of the diagram.
+	PageSize string
+	// Format forces the output format. When empty, the format is chosen
+	// from the extension of the output file.
+	Format string
+	// InputFormat forces the format of the input. When empty, the format is
+	// chosen from the extension of the input file.
+	InputFormat string
+	// Overrides replace config read from the input, in order, before the
+	// diagram is validated.
+	Overrides []domain.ConfigOverride
+	// Warnings receives problems that don't stop the diagram from being
+	// generated. When nil, warnings are ignored.
+	Warnings io.Writer
+}
+
 // NewGenerateDiagram wires all adapters together and returns
 // a ready-to-use GenerateDiagram use case.
-func NewGenerateDiagram(showGrid bool) *usecases.GenerateDiagram {
+func NewGenerateDiagram(opts Options) (*usecases.GenerateDiagram, error) {
 	reader := filesystem.NewOSFileReader()
 	writer := filesystem.NewOSFileWriter()
 
-	parser := config.NewYAMLParser(reader)
+	parser, err := newParser(reader, opts)
+	if err != nil {
+		return nil, err
+	}
 	layoutEngine := layout.NewLayoutAdapter()
 	pathfinder := pathfinding.NewDijkstraPathfinder()
-	renderer := rendering.NewSVGRenderer(writer, showGrid)
 
-	return usecases.NewGenerateDiagram(parser, layoutEngine, pathfinder, renderer)
+	renderer, err := newRenderer(writer, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	uc := usecases.NewGenerateDiagram(parser, layoutEngine, pathfinder, renderer).
+		WithOverrides(opts.Overrides...)
+	if opts.Warnings != nil {
+		uc.WithReporter(reporting.NewWriterReporter(opts.Warnings))
+	}
+
+	return uc, nil
 }
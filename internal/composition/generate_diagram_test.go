@@ -1,28 +1,142 @@
 package composition
 
-import "testing"
+import (
+	"math"
+	"testing"
+
+	"github.com/dnnrly/layli/internal/domain"
+)
 
 func TestNewGenerateDiagram(t *testing.T) {
 	tests := []struct {
-		name     string
-		showGrid bool
+		name string
+		opts Options
 	}{
-		{"with grid", true},
-		{"without grid", false},
+		{"with grid", Options{ShowGrid: true, Scale: 1}},
+		{"without grid", Options{ShowGrid: false, Scale: 1}},
+		{"with ascii format", Options{Format: FormatASCII, Scale: 1}},
+		{"with scaled png format", Options{Format: FormatPNG, Scale: 2}},
+		{"with pdf on a4", Options{Format: FormatPDF, PageSize: "A4", Scale: 1}},
+		{"with overrides", Options{Overrides: []domain.ConfigOverride{{Key: "layout", Value: "tarjan"}}, Scale: 1}},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			generator := NewGenerateDiagram(tt.showGrid)
+			generator, err := NewGenerateDiagram(tt.opts)
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
 
 			if generator == nil {
 				t.Fatal("Expected non-nil generator")
 			}
+		})
+	}
+}
 
-			// Verify it's a valid generator by checking it's properly constructed
-			// The Execute method should exist and be callable
-			if generator == nil {
-				t.Error("Expected generator to be properly constructed")
+func TestNewGenerateDiagram_UnknownFormat(t *testing.T) {
+	_, err := NewGenerateDiagram(Options{Format: "bmp", Scale: 1})
+	if err == nil {
+		t.Fatal("Expected error for unknown format")
+	}
+}
+
+func TestNewGenerateDiagram_InvalidScale(t *testing.T) {
+	for _, scale := range []float64{-1, 0, math.NaN(), math.Inf(1)} {
+		_, err := NewGenerateDiagram(Options{Format: FormatPNG, Scale: scale})
+		if err == nil {
+			t.Errorf("Expected error for scale %g", scale)
+		}
+	}
+}
+
+func TestNewGenerateDiagram_UnknownPageSize(t *testing.T) {
+	_, err := NewGenerateDiagram(Options{Format: FormatPDF, PageSize: "a0", Scale: 1})
+	if err == nil {
+		t.Fatal("Expected error for unknown page size")
+	}
+}
+
+func TestFormatRenderer_formatFor(t *testing.T) {
+	tests := []struct {
+		name   string
+		format string
+		output string
+		want   string
+	}{
+		{"svg extension", "", "out.svg", FormatSVG},
+		{"txt extension", "", "out.txt", FormatASCII},
+		{"png extension", "", "out.png", FormatPNG},
+		{"pdf extension", "", "out.pdf", FormatPDF},
+		{"dot extension", "", "out.dot", FormatDOT},
+		{"mmd extension", "", "out.mmd", FormatMermaid},
+		{"drawio extension", "", "out.drawio", FormatDrawIO},
+		{"excalidraw extension", "", "out.excalidraw", FormatExcalidraw},
+		{"json extension", "", "out.json", FormatJSON},
+		{"upper case extension", "", "OUT.TXT", FormatASCII},
+		{"unknown extension", "", "out", FormatSVG},
+		{"format overrides extension", FormatASCII, "out.svg", FormatASCII},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := &formatRenderer{format: tt.format}
+			if got := r.formatFor(tt.output); got != tt.want {
+				t.Errorf("formatFor(%q) = %q, want %q", tt.output, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewGenerateDiagram_UnknownInputFormat(t *testing.T) {
+	_, err := NewGenerateDiagram(Options{InputFormat: "xml", Scale: 1})
+	if err == nil {
+		t.Fatal("Expected error for unknown input format")
+	}
+}
+
+func TestFormatParser_formatFor(t *testing.T) {
+	tests := []struct {
+		name   string
+		format string
+		input  string
+		want   string
+	}{
+		{"layli extension", "", "diagram.layli", InputLayli},
+		{"dot extension", "", "graph.dot", InputDOT},
+		{"gv extension", "", "graph.GV", InputDOT},
+		{"mermaid extension", "", "flow.mmd", InputMermaid},
+		{"unknown extension", "", "diagram.yaml", InputLayli},
+		{"format overrides extension", InputDOT, "graph.txt", InputDOT},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := &formatParser{format: tt.format}
+			if got := p.formatFor(tt.input); got != tt.want {
+				t.Errorf("formatFor(%q) = %q, want %q", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestInputBase(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{"diagram.layli", "diagram"},
+		{"dir/graph.dot", "dir/graph"},
+		{"graph.gv", "graph"},
+		{"flow.mermaid", "flow"},
+		{"diagram.yaml", "diagram.yaml"},
+		{"diagram", "diagram"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			if got := InputBase(tt.input); got != tt.want {
+				t.Errorf("InputBase(%q) = %q, want %q", tt.input, got, tt.want)
 			}
 		})
 	}
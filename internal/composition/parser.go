@@ -0,0 +1,86 @@
+package composition
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/dnnrly/layli/internal/adapters/config"
+	"github.com/dnnrly/layli/internal/domain"
+	"github.com/dnnrly/layli/internal/usecases"
+)
+
+const (
+	InputLayli   = "layli"
+	InputDOT     = "dot"
+	InputMermaid = "mermaid"
+)
+
+// inputExtensions maps input formats to the file extensions they are read from.
+var inputExtensions = map[string][]string{
+	InputLayli:   {".layli"},
+	InputDOT:     {".dot", ".gv"},
+	InputMermaid: {".mmd", ".mermaid"},
+}
+
+// InputBase returns the input path without the extension of its format, so
+// that the extension of the output can be added to it.
+func InputBase(inputPath string) string {
+	ext := filepath.Ext(inputPath)
+	if _, ok := inputFormatFor(ext); ok {
+		return strings.TrimSuffix(inputPath, ext)
+	}
+	return inputPath
+}
+
+func inputFormatFor(ext string) (string, bool) {
+	ext = strings.ToLower(ext)
+	for format, exts := range inputExtensions {
+		for _, e := range exts {
+			if e == ext {
+				return format, true
+			}
+		}
+	}
+	return "", false
+}
+
+// formatParser chooses a parser for each input, using the requested format
+// or the extension of the input file when no format was requested.
+type formatParser struct {
+	format  string
+	parsers map[string]usecases.ConfigParser
+}
+
+var _ usecases.ConfigParser = (*formatParser)(nil)
+
+func newParser(reader usecases.FileReader, opts Options) (*formatParser, error) {
+	if _, ok := inputExtensions[opts.InputFormat]; opts.InputFormat != "" && !ok {
+		return nil, fmt.Errorf("unknown input format: %s", opts.InputFormat)
+	}
+
+	return &formatParser{
+		format: opts.InputFormat,
+		parsers: map[string]usecases.ConfigParser{
+			InputLayli:   config.NewYAMLParser(reader),
+			InputDOT:     config.NewDOTParser(reader),
+			InputMermaid: config.NewMermaidParser(reader),
+		},
+	}, nil
+}
+
+func (p *formatParser) Parse(path string) (*domain.Diagram, error) {
+	return p.parsers[p.formatFor(path)].Parse(path)
+}
+
+func (p *formatParser) formatFor(path string) string {
+	if p.format != "" {
+		return p.format
+	}
+
+	if format, ok := inputFormatFor(filepath.Ext(path)); ok {
+		return format
+	}
+
+	return InputLayli
+}
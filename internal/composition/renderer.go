@@ -0,0 +1,155 @@
+package composition
+
+import (
+	"fmt"
+	"math"
+	"path/filepath"
+	"strings"
+
+	"github.com/dnnrly/layli/internal/adapters/rendering"
+	"github.com/dnnrly/layli/internal/domain"
+	"github.com/dnnrly/layli/internal/usecases"
+)
+
+const (
+	FormatSVG        = "svg"
+	FormatASCII      = "ascii"
+	FormatDOT        = "dot"
+	FormatMermaid    = "mermaid"
+	FormatPNG        = "png"
+	FormatPDF        = "pdf"
+	FormatDrawIO     = "drawio"
+	FormatExcalidraw = "excalidraw"
+	FormatJSON       = "json"
+)
+
+// outputFormat is an entry in the registry of output formats, giving the
+// file extension that the format is written with and how to make its
+// renderer.
+type outputFormat struct {
+	extension   string
+	newRenderer func(writer usecases.FileWriter, opts Options) usecases.Renderer
+}
+
+// outputFormats is the registry of every format that diagrams can be
+// written in. Adding a format here makes it available to --format and to
+// output files with its extension.
+var outputFormats = map[string]outputFormat{
+	FormatSVG: {
+		extension: ".svg",
+		newRenderer: func(writer usecases.FileWriter, opts Options) usecases.Renderer {
+			return rendering.NewSVGRenderer(writer, opts.ShowGrid)
+		},
+	},
+	FormatASCII: {
+		extension: ".txt",
+		newRenderer: func(writer usecases.FileWriter, opts Options) usecases.Renderer {
+			return rendering.NewASCIIRenderer(writer)
+		},
+	},
+	FormatPNG: {
+		extension: ".png",
+		newRenderer: func(writer usecases.FileWriter, opts Options) usecases.Renderer {
+			return rendering.NewPNGRenderer(writer, opts.Scale, opts.ShowGrid)
+		},
+	},
+	FormatPDF: {
+		extension: ".pdf",
+		newRenderer: func(writer usecases.FileWriter, opts Options) usecases.Renderer {
+			return rendering.NewPDFRenderer(writer, opts.PageSize, opts.ShowGrid)
+		},
+	},
+	FormatDOT: {
+		extension: ".dot",
+		newRenderer: func(writer usecases.FileWriter, opts Options) usecases.Renderer {
+			return rendering.NewDOTRenderer(writer)
+		},
+	},
+	FormatMermaid: {
+		extension: ".mmd",
+		newRenderer: func(writer usecases.FileWriter, opts Options) usecases.Renderer {
+			return rendering.NewMermaidRenderer(writer)
+		},
+	},
+	FormatDrawIO: {
+		extension: ".drawio",
+		newRenderer: func(writer usecases.FileWriter, opts Options) usecases.Renderer {
+			return rendering.NewDrawIORenderer(writer)
+		},
+	},
+	FormatExcalidraw: {
+		extension: ".excalidraw",
+		newRenderer: func(writer usecases.FileWriter, opts Options) usecases.Renderer {
+			return rendering.NewExcalidrawRenderer(writer)
+		},
+	},
+	FormatJSON: {
+		extension: ".json",
+		newRenderer: func(writer usecases.FileWriter, opts Options) usecases.Renderer {
+			return rendering.NewJSONRenderer(writer)
+		},
+	},
+}
+
+// OutputExtension returns the file extension used for the output format.
+// An empty format is treated as SVG.
+func OutputExtension(format string) (string, error) {
+	if format == "" {
+		format = FormatSVG
+	}
+	f, ok := outputFormats[format]
+	if !ok {
+		return "", fmt.Errorf("unknown output format: %s", format)
+	}
+	return f.extension, nil
+}
+
+// formatRenderer chooses a renderer for each output, using the requested
+// format or the extension of the output file when no format was requested.
+type formatRenderer struct {
+	format    string
+	renderers map[string]usecases.Renderer
+}
+
+var _ usecases.Renderer = (*formatRenderer)(nil)
+
+func newRenderer(writer usecases.FileWriter, opts Options) (*formatRenderer, error) {
+	if _, err := OutputExtension(opts.Format); err != nil {
+		return nil, err
+	}
+	if math.IsNaN(opts.Scale) || math.IsInf(opts.Scale, 0) || opts.Scale <= 0 {
+		return nil, fmt.Errorf("scale must be more than 0: %g", opts.Scale)
+	}
+	if opts.PageSize != "" && !rendering.IsPageSize(opts.PageSize) {
+		return nil, fmt.Errorf("unknown page size: %s", opts.PageSize)
+	}
+
+	renderers := map[string]usecases.Renderer{}
+	for format, f := range outputFormats {
+		renderers[format] = f.newRenderer(writer, opts)
+	}
+
+	return &formatRenderer{
+		format:    opts.Format,
+		renderers: renderers,
+	}, nil
+}
+
+func (r *formatRenderer) Render(diagram *domain.Diagram, outputPath string) error {
+	return r.renderers[r.formatFor(outputPath)].Render(diagram, outputPath)
+}
+
+func (r *formatRenderer) formatFor(outputPath string) string {
+	if r.format != "" {
+		return r.format
+	}
+
+	ext := strings.ToLower(filepath.Ext(outputPath))
+	for format, f := range outputFormats {
+		if f.extension == ext {
+			return format
+		}
+	}
+
+	return FormatSVG
+}
@@ -17,6 +17,17 @@ const (
 	LayoutTarjan         LayoutType = "tarjan"
 	LayoutAbsolute       LayoutType = "absolute"
 	LayoutRandomShortest LayoutType = "random-shortest-square"
+	LayoutLayered        LayoutType = "layered"
+)
+
+// Direction is the way that the layered layout draws the ranks of a diagram.
+type Direction string
+
+const (
+	DirectionTopBottom Direction = "TB"
+	DirectionBottomTop Direction = "BT"
+	DirectionLeftRight Direction = "LR"
+	DirectionRightLeft Direction = "RL"
 )
 
 // PathfindingAlgorithm enumerates available pathfinding algorithms.
@@ -43,6 +54,8 @@ type DiagramConfig struct {
 	LayoutAttempts int
 	NodeWidth      int
 	NodeHeight     int
+	NodeSize       NodeSize  // for nodes that don't choose their own Size
+	Direction      Direction // only used by the layered layout, TB when empty
 	Border         int
 	Margin         int
 	Spacing        int
@@ -50,12 +63,21 @@ type DiagramConfig struct {
 	PathStrategy   string
 	Pathfinding    PathfindingConfig
 	Styles         map[string]string
+
+	// Seed starts the random choices made while arranging nodes and finding
+	// paths, so that the same diagram can be made again. When it is 0, a
+	// seed is chosen and recorded here.
+	Seed int64
 }
 
 // PathfindingConfig holds pathfinding algorithm settings.
 type PathfindingConfig struct {
 	Algorithm PathfindingAlgorithm
 	Heuristic PathfindingHeuristic
+
+	// BendPenalty is the extra cost of each corner in a path. It is only
+	// used by the Dijkstra algorithm.
+	BendPenalty int
 }
 
 // Diagram represents the complete diagram specification.
@@ -63,7 +85,30 @@ type PathfindingConfig struct {
 type Diagram struct {
 	Nodes  []Node
 	Edges  []Edge
+	Groups []Group
 	Config DiagramConfig
+
+	// Crossings are the places where edge paths could not avoid crossing
+	// each other. They are found by the Pathfinder.
+	Crossings []Crossing
+
+	// UnplacedLabels are the IDs of edges whose labels could not be placed
+	// without overlapping a node or another label, so are not drawn. They
+	// are found by the Pathfinder.
+	UnplacedLabels []string
+
+	// Warnings are things that were read but can't be drawn the way that
+	// the diagram asks for. They are found by the ConfigParser.
+	Warnings []string
+}
+
+// Group is a labelled boundary drawn around related nodes. Groups can be
+// nested inside other groups.
+type Group struct {
+	ID     string
+	Label  string
+	Nodes  []string // Node IDs
+	Groups []Group
 }
 
 // Validate ensures diagram invariants are met.
@@ -114,6 +159,14 @@ func (d *Diagram) Validate() error {
 		return fmt.Errorf("margin must be between 0 and 10")
 	}
 
+	if d.Config.Border < 0 {
+		return fmt.Errorf("border cannot be negative")
+	}
+
+	if d.Config.Pathfinding.BendPenalty < 0 {
+		return fmt.Errorf("bend penalty cannot be negative")
+	}
+
 	if d.Config.PathAttempts <= 0 || d.Config.PathAttempts > 10000 {
 		return fmt.Errorf("path attempts must be between 1 and 10000")
 	}
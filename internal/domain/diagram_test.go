@@ -174,6 +174,38 @@ func TestDiagramValidate_InvalidMargin(t *testing.T) {
 	}
 }
 
+func TestDiagramValidate_NegativeSettings(t *testing.T) {
+	tests := []struct {
+		name    string
+		change  func(c *DiagramConfig)
+		wantErr string
+	}{
+		{"negative border", func(c *DiagramConfig) { c.Border = -3 }, "border cannot be negative"},
+		{"negative bend penalty", func(c *DiagramConfig) { c.Pathfinding.BendPenalty = -5 }, "bend penalty cannot be negative"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := Diagram{
+				Nodes: []Node{
+					{ID: "a", Width: 5, Height: 5},
+				},
+				Config: DiagramConfig{
+					NodeWidth:      5,
+					NodeHeight:     5,
+					PathAttempts:   100,
+					LayoutAttempts: 100,
+				},
+			}
+			tt.change(&d.Config)
+			err := d.Validate()
+			if err == nil || err.Error() != tt.wantErr {
+				t.Fatalf("expected error %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
 func TestDiagramValidate_InvalidPathAttempts(t *testing.T) {
 	tests := []struct {
 		name         string
@@ -302,7 +334,7 @@ func TestDiagramValidate_InvalidEdge(t *testing.T) {
 			{ID: "a", Width: 5, Height: 5},
 		},
 		Edges: []Edge{
-			{ID: "e1", From: "a", To: "a"}, // Invalid: self-loop
+			{ID: "e1", From: "a", To: ""}, // Invalid: no to node
 		},
 		Config: DiagramConfig{
 			NodeWidth:      5,
@@ -312,6 +344,6 @@ func TestDiagramValidate_InvalidEdge(t *testing.T) {
 		},
 	}
 	if err := d.Validate(); err == nil {
-		t.Fatal("expected error for self-loop edge")
+		t.Fatal("expected error for edge without a to node")
 	}
 }
@@ -6,11 +6,64 @@ import "fmt"
 // Maps to: "And an edge from 'A' to 'B'" in feature files.
 type Edge struct {
 	ID    string
-	From  string  // Node ID
-	To    string  // Node ID
-	Path  *Path   // Calculated path (may be nil before pathfinding)
+	From  string // Node ID
+	To    string // Node ID
+	Path  *Path  // Calculated path (may be nil before pathfinding)
 	Class string
 	Style string
+
+	// FromPort and ToPort restrict where the path meets each node, either
+	// to a side or to one of the node's named ports
+	FromPort string
+	ToPort   string
+
+	// Arrow says which ends of the edge get an arrow head and Head is the
+	// shape that they are drawn as
+	Arrow ArrowDirection
+	Head  ArrowHead
+
+	Label          string          // Text shown alongside the path
+	LabelPlacement *LabelPlacement // Where the label sits (may be nil before pathfinding)
+}
+
+// ArrowDirection says which ends of an edge get an arrow head. Leaving it
+// empty is the same as ArrowForward.
+type ArrowDirection string
+
+const (
+	ArrowNone     ArrowDirection = "none"
+	ArrowForward  ArrowDirection = "forward"
+	ArrowBackward ArrowDirection = "backward"
+	ArrowBoth     ArrowDirection = "both"
+)
+
+// ArrowHead is the shape of an arrow head. Leaving it empty is the same as
+// HeadTriangle.
+type ArrowHead string
+
+const (
+	HeadTriangle      ArrowHead = "triangle"
+	HeadOpenArrow     ArrowHead = "open-arrow"
+	HeadDiamond       ArrowHead = "diamond"
+	HeadHollowDiamond ArrowHead = "hollow-diamond"
+	HeadCircle        ArrowHead = "circle"
+	HeadCrowsFoot     ArrowHead = "crows-foot"
+)
+
+// LabelSide is the side of a path segment that a label is drawn on.
+type LabelSide string
+
+const (
+	LabelAbove LabelSide = "above"
+	LabelBelow LabelSide = "below"
+	LabelLeft  LabelSide = "left"
+	LabelRight LabelSide = "right"
+)
+
+// LabelPlacement anchors an edge label to a point on its path.
+type LabelPlacement struct {
+	Position Position // Point on the path, in grid units
+	Side     LabelSide
 }
 
 // Validate ensures edge invariants.
@@ -19,13 +72,21 @@ func (e *Edge) Validate() error {
 		return fmt.Errorf("edge must have from and to nodes")
 	}
 
-	if e.From == e.To {
-		return fmt.Errorf("edge cannot connect node to itself")
-	}
-
 	return nil
 }
 
+// ArrowAtStart returns true if the edge has an arrow head where it leaves
+// the node it comes from.
+func (e *Edge) ArrowAtStart() bool {
+	return e.Arrow == ArrowBackward || e.Arrow == ArrowBoth
+}
+
+// ArrowAtEnd returns true if the edge has an arrow head where it enters the
+// node it goes to.
+func (e *Edge) ArrowAtEnd() bool {
+	return e.Arrow == "" || e.Arrow == ArrowForward || e.Arrow == ArrowBoth
+}
+
 // String returns a string representation of the edge.
 func (e *Edge) String() string {
 	return fmt.Sprintf("Edge(%s -> %s)", e.From, e.To)
@@ -83,3 +144,15 @@ func (p *Path) String() string {
 	}
 	return fmt.Sprintf("Path(%d points)", len(p.Points))
 }
+
+// Crossing is a point where the paths of 2 edges cross over each other.
+type Crossing struct {
+	EdgeA string
+	EdgeB string
+	At    Position
+}
+
+// String returns a string representation of the crossing.
+func (c Crossing) String() string {
+	return fmt.Sprintf("edges %s and %s cross at %s", c.EdgeA, c.EdgeB, c.At.String())
+}
@@ -57,8 +57,30 @@ func TestEdgeValidate_EmptyTo(t *testing.T) {
 
 func TestEdgeValidate_SelfLoop(t *testing.T) {
 	e := Edge{ID: "e1", From: "a", To: "a"}
-	if err := e.Validate(); err == nil {
-		t.Fatal("expected error for self-loop edge")
+	if err := e.Validate(); err != nil {
+		t.Fatalf("expected self-loop edge to be valid, got %v", err)
+	}
+}
+
+func TestEdgeArrowEnds(t *testing.T) {
+	tests := []struct {
+		arrow ArrowDirection
+		start bool
+		end   bool
+	}{
+		{arrow: "", start: false, end: true},
+		{arrow: ArrowForward, start: false, end: true},
+		{arrow: ArrowBackward, start: true, end: false},
+		{arrow: ArrowBoth, start: true, end: true},
+		{arrow: ArrowNone, start: false, end: false},
+	}
+
+	for _, tt := range tests {
+		e := Edge{ID: "e1", From: "a", To: "b", Arrow: tt.arrow}
+		if e.ArrowAtStart() != tt.start || e.ArrowAtEnd() != tt.end {
+			t.Errorf("arrow %q: got start %v end %v, want start %v end %v",
+				tt.arrow, e.ArrowAtStart(), e.ArrowAtEnd(), tt.start, tt.end)
+		}
 	}
 }
 
@@ -10,8 +10,45 @@ type Node struct {
 	Position Position
 	Width    int
 	Height   int
-	Class    string
-	Style    string
+	// Size chooses whether any dimension that isn't set is measured from
+	// the contents before the node is arranged. Leaving it empty uses the
+	// NodeSize of the diagram.
+	Size  NodeSize
+	Class string
+	Style string
+	Shape NodeShape
+	Ports []Port // Named places on the sides of the node that edges can use
+}
+
+// NodeShape is how the outline of a node is drawn. Leaving it empty is the
+// same as ShapeRounded.
+type NodeShape string
+
+const (
+	ShapeRounded  NodeShape = "rounded"
+	ShapeRect     NodeShape = "rect"
+	ShapeEllipse  NodeShape = "ellipse"
+	ShapeDiamond  NodeShape = "diamond"
+	ShapeCylinder NodeShape = "cylinder"
+	ShapeHexagon  NodeShape = "hexagon"
+	ShapeNote     NodeShape = "note"
+	ShapePerson   NodeShape = "person"
+)
+
+// NodeSize is how the dimensions of a node that aren't set are chosen.
+type NodeSize string
+
+const (
+	SizeFixed NodeSize = "fixed" // use the diagram's width and height
+	SizeAuto  NodeSize = "auto"  // measure the contents of the node
+)
+
+// Port is a named place on one side of a node. The offset is measured in
+// path units from the top or left corner of that side.
+type Port struct {
+	Name   string
+	Side   string // north, south, east or west
+	Offset int
 }
 
 // Validate ensures node invariants.
@@ -0,0 +1,133 @@
+package domain
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ConfigOverride replaces one setting of a diagram after it has been read,
+// such as a layout chosen on the command line. Keys are named the same way
+// as in layli files, with a dot between nested keys, e.g. "path.algorithm"
+// or "styles..store".
+type ConfigOverride struct {
+	Key   string
+	Value string
+}
+
+// ParseConfigOverride reads an override written as key=value.
+func ParseConfigOverride(s string) (ConfigOverride, error) {
+	key, value, found := strings.Cut(s, "=")
+	key = strings.TrimSpace(key)
+	if !found || key == "" {
+		return ConfigOverride{}, fmt.Errorf("config override must be key=value: %s", s)
+	}
+	return ConfigOverride{Key: key, Value: strings.TrimSpace(value)}, nil
+}
+
+// String returns the override as key=value.
+func (o ConfigOverride) String() string {
+	return o.Key + "=" + o.Value
+}
+
+// overrideKeys are the keys that can be overridden, other than styles
+var overrideKeys = []string{
+	"layout", "layout-attempts", "width", "height", "size", "border", "margin", "seed",
+	"path.strategy", "path.attempts", "path.algorithm", "path.heuristic", "path.bend-penalty",
+}
+
+// Override applies a config override to the diagram. Values are only
+// checked to be the right type here, their ranges are checked by Validate.
+func (d *Diagram) Override(o ConfigOverride) error {
+	cfg := &d.Config
+
+	if selector, found := strings.CutPrefix(o.Key, "styles."); found {
+		if selector == "" {
+			return fmt.Errorf("style override must name a selector: %s", o)
+		}
+		if cfg.Styles == nil {
+			cfg.Styles = map[string]string{}
+		}
+		cfg.Styles[selector] = o.Value
+		return nil
+	}
+
+	switch o.Key {
+	case "layout":
+		if !validLayoutType(LayoutType(o.Value)) {
+			return fmt.Errorf("invalid layout: %s. Valid options: flow-square, topo-sort, tarjan, absolute, random-shortest-square, layered", o.Value)
+		}
+		cfg.LayoutType = LayoutType(o.Value)
+	case "path.strategy":
+		if o.Value != "in-order" && o.Value != "random" {
+			return fmt.Errorf("invalid path strategy: %s. Valid options: in-order, random", o.Value)
+		}
+		cfg.PathStrategy = o.Value
+	case "path.algorithm":
+		switch PathfindingAlgorithm(o.Value) {
+		case PathfindingDijkstra, PathfindingAStar, PathfindingBidirectional:
+		default:
+			return fmt.Errorf("invalid pathfinding algorithm: %s. Valid options: dijkstra, astar, bidirectional", o.Value)
+		}
+		cfg.Pathfinding.Algorithm = PathfindingAlgorithm(o.Value)
+	case "path.heuristic":
+		switch PathfindingHeuristic(o.Value) {
+		case HeuristicEuclidean, HeuristicManhattan:
+		default:
+			return fmt.Errorf("invalid heuristic: %s. Valid options: euclidean, manhattan", o.Value)
+		}
+		cfg.Pathfinding.Heuristic = PathfindingHeuristic(o.Value)
+	case "size":
+		// Nodes that don't choose their own size use this when they are
+		// arranged
+		if NodeSize(o.Value) != SizeFixed && NodeSize(o.Value) != SizeAuto {
+			return fmt.Errorf("invalid node size: %s. Valid options: fixed, auto", o.Value)
+		}
+		cfg.NodeSize = NodeSize(o.Value)
+	case "seed":
+		seed, err := strconv.ParseInt(o.Value, 10, 64)
+		if err != nil {
+			return fmt.Errorf("seed must be a whole number: %s", o.Value)
+		}
+		cfg.Seed = seed
+	case "layout-attempts", "width", "height", "border", "margin", "path.attempts", "path.bend-penalty":
+		n, err := strconv.Atoi(o.Value)
+		if err != nil {
+			return fmt.Errorf("%s must be a whole number: %s", o.Key, o.Value)
+		}
+		d.overrideNumber(o.Key, n)
+	default:
+		return fmt.Errorf("unknown config key: %s. Valid options: %s, styles.<selector>", o.Key, strings.Join(overrideKeys, ", "))
+	}
+
+	return nil
+}
+
+func (d *Diagram) overrideNumber(key string, n int) {
+	cfg := &d.Config
+	switch key {
+	case "layout-attempts":
+		cfg.LayoutAttempts = n
+	case "width":
+		// Nodes without a width of their own use this when they are arranged
+		cfg.NodeWidth = n
+	case "height":
+		cfg.NodeHeight = n
+	case "border":
+		cfg.Border = n
+	case "margin":
+		cfg.Margin = n
+	case "path.attempts":
+		cfg.PathAttempts = n
+	case "path.bend-penalty":
+		cfg.Pathfinding.BendPenalty = n
+	}
+}
+
+func validLayoutType(lt LayoutType) bool {
+	switch lt {
+	case LayoutFlowSquare, LayoutTopoSort, LayoutTarjan, LayoutAbsolute, LayoutRandomShortest, LayoutLayered:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,144 @@
+package domain
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseConfigOverride(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  ConfigOverride
+	}{
+		{"simple", "layout=tarjan", ConfigOverride{Key: "layout", Value: "tarjan"}},
+		{"spaces are trimmed", " margin = 3 ", ConfigOverride{Key: "margin", Value: "3"}},
+		{"value with equals", "styles.#a=fill: url(#x=1)", ConfigOverride{Key: "styles.#a", Value: "fill: url(#x=1)"}},
+		{"empty value", "path.strategy=", ConfigOverride{Key: "path.strategy", Value: ""}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseConfigOverride(tt.input)
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestParseConfigOverride_Invalid(t *testing.T) {
+	for _, input := range []string{"layout", "=tarjan", ""} {
+		t.Run(input, func(t *testing.T) {
+			if _, err := ParseConfigOverride(input); err == nil {
+				t.Fatal("expected error")
+			}
+		})
+	}
+}
+
+func TestDiagramOverride(t *testing.T) {
+	newDiagram := func() *Diagram {
+		return &Diagram{
+			Nodes: []Node{
+				{ID: "a"},
+				{ID: "b", Width: 9, Height: 4},
+				{ID: "c", Size: SizeAuto},
+				{ID: "d", Width: 5, Height: 3},
+			},
+			Config: DiagramConfig{
+				NodeWidth:      5,
+				NodeHeight:     3,
+				Border:         1,
+				Margin:         2,
+				PathAttempts:   20,
+				LayoutAttempts: 10,
+				Pathfinding: PathfindingConfig{
+					Algorithm:   PathfindingDijkstra,
+					Heuristic:   HeuristicEuclidean,
+					BendPenalty: 300,
+				},
+				Styles: map[string]string{".store": "fill: #eef"},
+			},
+		}
+	}
+
+	tests := []struct {
+		name     string
+		override ConfigOverride
+		check    func(d *Diagram) bool
+	}{
+		{"layout", ConfigOverride{"layout", "topo-sort"}, func(d *Diagram) bool { return d.Config.LayoutType == LayoutTopoSort }},
+		{"layout attempts", ConfigOverride{"layout-attempts", "50"}, func(d *Diagram) bool { return d.Config.LayoutAttempts == 50 }},
+		{"seed", ConfigOverride{"seed", "1234"}, func(d *Diagram) bool { return d.Config.Seed == 1234 }},
+		{"size", ConfigOverride{"size", "auto"}, func(d *Diagram) bool {
+			return d.Config.NodeSize == SizeAuto && d.Nodes[0].Size == "" && d.Nodes[2].Size == SizeAuto
+		}},
+		{"border", ConfigOverride{"border", "4"}, func(d *Diagram) bool { return d.Config.Border == 4 }},
+		{"margin", ConfigOverride{"margin", "0"}, func(d *Diagram) bool { return d.Config.Margin == 0 }},
+		{"path strategy", ConfigOverride{"path.strategy", "random"}, func(d *Diagram) bool { return d.Config.PathStrategy == "random" }},
+		{"path attempts", ConfigOverride{"path.attempts", "100"}, func(d *Diagram) bool { return d.Config.PathAttempts == 100 }},
+		{"path algorithm", ConfigOverride{"path.algorithm", "astar"}, func(d *Diagram) bool { return d.Config.Pathfinding.Algorithm == PathfindingAStar }},
+		{"path heuristic", ConfigOverride{"path.heuristic", "manhattan"}, func(d *Diagram) bool { return d.Config.Pathfinding.Heuristic == HeuristicManhattan }},
+		{"bend penalty", ConfigOverride{"path.bend-penalty", "0"}, func(d *Diagram) bool { return d.Config.Pathfinding.BendPenalty == 0 }},
+		{"new style", ConfigOverride{"styles.#a", "stroke: red"}, func(d *Diagram) bool {
+			return reflect.DeepEqual(d.Config.Styles, map[string]string{".store": "fill: #eef", "#a": "stroke: red"})
+		}},
+		{"replaced style", ConfigOverride{"styles..store", "fill: red"}, func(d *Diagram) bool { return d.Config.Styles[".store"] == "fill: red" }},
+		{"node width", ConfigOverride{"width", "7"}, func(d *Diagram) bool {
+			// Only nodes without their own width use the new one, even if
+			// their own width is the same as the old default
+			return d.Config.NodeWidth == 7 && d.Nodes[0].Width == 0 && d.Nodes[1].Width == 9 && d.Nodes[3].Width == 5
+		}},
+		{"node height", ConfigOverride{"height", "5"}, func(d *Diagram) bool {
+			return d.Config.NodeHeight == 5 && d.Nodes[0].Height == 0 && d.Nodes[1].Height == 4 && d.Nodes[3].Height == 3
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := newDiagram()
+			if err := d.Override(tt.override); err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+			if !tt.check(d) {
+				t.Errorf("override %s was not applied: %+v", tt.override, d)
+			}
+		})
+	}
+}
+
+func TestDiagramOverride_Invalid(t *testing.T) {
+	tests := []struct {
+		name     string
+		override ConfigOverride
+		wantErr  string
+	}{
+		{"unknown key", ConfigOverride{"colour", "red"}, "unknown config key: colour"},
+		{"unknown layout", ConfigOverride{"layout", "spiral"}, "invalid layout: spiral"},
+		{"unknown strategy", ConfigOverride{"path.strategy", "fastest"}, "invalid path strategy: fastest"},
+		{"unknown algorithm", ConfigOverride{"path.algorithm", "bfs"}, "invalid pathfinding algorithm: bfs"},
+		{"unknown heuristic", ConfigOverride{"path.heuristic", "chebyshev"}, "invalid heuristic: chebyshev"},
+		{"unknown size", ConfigOverride{"size", "huge"}, "invalid node size: huge. Valid options: fixed, auto"},
+		{"not a number", ConfigOverride{"margin", "wide"}, "margin must be a whole number: wide"},
+		{"seed not a number", ConfigOverride{"seed", "lucky"}, "seed must be a whole number: lucky"},
+		{"style without selector", ConfigOverride{"styles.", "fill: red"}, "style override must name a selector"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := &Diagram{}
+			err := d.Override(tt.override)
+			if err == nil {
+				t.Fatal("expected error")
+			}
+			if !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
@@ -1,6 +1,10 @@
 package usecases
 
-import "fmt"
+import (
+	"fmt"
+
+	"github.com/dnnrly/layli/internal/domain"
+)
 
 // GenerateDiagram orchestrates the complete diagram generation workflow.
 // Maps to a complete Gherkin scenario: Given → When → Then
@@ -9,6 +13,8 @@ type GenerateDiagram struct {
 	layoutEngine LayoutEngine
 	pathfinder   Pathfinder
 	renderer     Renderer
+	reporter     Reporter
+	overrides    []domain.ConfigOverride
 }
 
 // NewGenerateDiagram creates a new GenerateDiagram use case.
@@ -26,15 +32,31 @@ func NewGenerateDiagram(
 	}
 }
 
+// WithReporter sets where warnings are sent while the diagram is generated.
+// Without a reporter, warnings are ignored.
+func (uc *GenerateDiagram) WithReporter(reporter Reporter) *GenerateDiagram {
+	uc.reporter = reporter
+	return uc
+}
+
+// WithOverrides sets config that replaces what was read from the config
+// file, such as settings chosen on the command line. Overrides are applied
+// in order, so later ones win.
+func (uc *GenerateDiagram) WithOverrides(overrides ...domain.ConfigOverride) *GenerateDiagram {
+	uc.overrides = append(uc.overrides, overrides...)
+	return uc
+}
+
 // Execute runs the complete diagram generation pipeline.
 //
 // Steps:
 //
 //	1. Parse configuration (Given)
-//	2. Validate diagram (Given)
-//	3. Arrange layout (When)
-//	4. Calculate paths (When)
-//	5. Render output (Then)
+//	2. Apply config overrides (Given)
+//	3. Validate diagram (Given)
+//	4. Arrange layout (When)
+//	5. Calculate paths (When)
+//	6. Render output (Then)
 func (uc *GenerateDiagram) Execute(configPath, outputPath string) error {
 	// Parse configuration
 	diagram, err := uc.configParser.Parse(configPath)
@@ -42,6 +64,13 @@ func (uc *GenerateDiagram) Execute(configPath, outputPath string) error {
 		return fmt.Errorf("parse config: %w", err)
 	}
 
+	// Apply config overrides
+	for _, o := range uc.overrides {
+		if err := diagram.Override(o); err != nil {
+			return fmt.Errorf("override config: %w", err)
+		}
+	}
+
 	// Validate diagram
 	if err := diagram.Validate(); err != nil {
 		return fmt.Errorf("validate diagram: %w", err)
@@ -56,6 +85,7 @@ func (uc *GenerateDiagram) Execute(configPath, outputPath string) error {
 	if err := uc.pathfinder.FindPaths(diagram); err != nil {
 		return fmt.Errorf("find paths: %w", err)
 	}
+	uc.warn(diagram)
 
 	// Render output
 	if err := uc.renderer.Render(diagram, outputPath); err != nil {
@@ -64,3 +94,18 @@ func (uc *GenerateDiagram) Execute(configPath, outputPath string) error {
 
 	return nil
 }
+
+func (uc *GenerateDiagram) warn(diagram *domain.Diagram) {
+	if uc.reporter == nil {
+		return
+	}
+	for _, w := range diagram.Warnings {
+		uc.reporter.Warn(w)
+	}
+	for _, c := range diagram.Crossings {
+		uc.reporter.Warn(fmt.Sprintf("could not avoid crossing: %s", c))
+	}
+	for _, id := range diagram.UnplacedLabels {
+		uc.reporter.Warn(fmt.Sprintf("could not find room for the label of edge %s", id))
+	}
+}
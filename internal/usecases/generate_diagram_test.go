@@ -293,3 +293,234 @@ func TestGenerateDiagram_CallOrder(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Equal(t, []string{"parse", "arrange", "pathfind", "render"}, callOrder)
 }
+
+func TestGenerateDiagram_Execute_ReportsCrossings(t *testing.T) {
+	newDiagram := func() *domain.Diagram {
+		return &domain.Diagram{
+			Nodes: []domain.Node{
+				{ID: "a", Width: 5, Height: 5},
+			},
+			Config: domain.DiagramConfig{
+				NodeWidth:      5,
+				NodeHeight:     5,
+				Margin:         1,
+				PathAttempts:   100,
+				LayoutAttempts: 100,
+			},
+		}
+	}
+	addCrossing := func(args mock.Arguments) {
+		d := args.Get(0).(*domain.Diagram)
+		d.Crossings = []domain.Crossing{
+			{EdgeA: "e1", EdgeB: "e2", At: domain.Position{X: 4, Y: 5}},
+		}
+	}
+
+	t.Run("crossings are sent to the reporter", func(t *testing.T) {
+		diagram := newDiagram()
+
+		mockParser := new(mocks.MockConfigParser)
+		mockLayout := new(mocks.MockLayoutEngine)
+		mockPathfinder := new(mocks.MockPathfinder)
+		mockRenderer := new(mocks.MockRenderer)
+		mockReporter := new(mocks.MockReporter)
+
+		mockParser.On("Parse", "test.layli").Return(diagram, nil)
+		mockLayout.On("Arrange", diagram).Return(nil)
+		mockPathfinder.On("FindPaths", diagram).Run(addCrossing).Return(nil)
+		mockRenderer.On("Render", diagram, "output.svg").Return(nil)
+		mockReporter.On("Warn", "could not avoid crossing: edges e1 and e2 cross at (4,5)").Return()
+
+		uc := NewGenerateDiagram(mockParser, mockLayout, mockPathfinder, mockRenderer).WithReporter(mockReporter)
+
+		err := uc.Execute("test.layli", "output.svg")
+
+		assert.NoError(t, err)
+		mockReporter.AssertExpectations(t)
+	})
+
+	t.Run("crossings are ignored without a reporter", func(t *testing.T) {
+		diagram := newDiagram()
+
+		mockParser := new(mocks.MockConfigParser)
+		mockLayout := new(mocks.MockLayoutEngine)
+		mockPathfinder := new(mocks.MockPathfinder)
+		mockRenderer := new(mocks.MockRenderer)
+
+		mockParser.On("Parse", "test.layli").Return(diagram, nil)
+		mockLayout.On("Arrange", diagram).Return(nil)
+		mockPathfinder.On("FindPaths", diagram).Run(addCrossing).Return(nil)
+		mockRenderer.On("Render", diagram, "output.svg").Return(nil)
+
+		uc := NewGenerateDiagram(mockParser, mockLayout, mockPathfinder, mockRenderer)
+
+		err := uc.Execute("test.layli", "output.svg")
+
+		assert.NoError(t, err)
+		mockRenderer.AssertExpectations(t)
+	})
+}
+
+func TestGenerateDiagram_Execute_ReportsUnplacedLabels(t *testing.T) {
+	diagram := &domain.Diagram{
+		Nodes: []domain.Node{
+			{ID: "a", Width: 5, Height: 5},
+		},
+		Config: domain.DiagramConfig{
+			NodeWidth:      5,
+			NodeHeight:     5,
+			Margin:         1,
+			PathAttempts:   100,
+			LayoutAttempts: 100,
+		},
+	}
+
+	mockParser := new(mocks.MockConfigParser)
+	mockLayout := new(mocks.MockLayoutEngine)
+	mockPathfinder := new(mocks.MockPathfinder)
+	mockRenderer := new(mocks.MockRenderer)
+	mockReporter := new(mocks.MockReporter)
+
+	mockParser.On("Parse", "test.layli").Return(diagram, nil)
+	mockLayout.On("Arrange", diagram).Return(nil)
+	mockPathfinder.On("FindPaths", diagram).Run(func(args mock.Arguments) {
+		args.Get(0).(*domain.Diagram).UnplacedLabels = []string{"e1"}
+	}).Return(nil)
+	mockRenderer.On("Render", diagram, "output.svg").Return(nil)
+	mockReporter.On("Warn", "could not find room for the label of edge e1").Return()
+
+	uc := NewGenerateDiagram(mockParser, mockLayout, mockPathfinder, mockRenderer).WithReporter(mockReporter)
+
+	err := uc.Execute("test.layli", "output.svg")
+
+	assert.NoError(t, err)
+	mockReporter.AssertExpectations(t)
+}
+
+func TestGenerateDiagram_Execute_ReportsParserWarnings(t *testing.T) {
+	diagram := &domain.Diagram{
+		Nodes: []domain.Node{
+			{ID: "a", Width: 5, Height: 5},
+		},
+		Config: domain.DiagramConfig{
+			NodeWidth:      5,
+			NodeHeight:     5,
+			Margin:         1,
+			PathAttempts:   100,
+			LayoutAttempts: 100,
+		},
+		Warnings: []string{"flowchart direction LR is not supported, drawing it from top to bottom"},
+	}
+
+	mockParser := new(mocks.MockConfigParser)
+	mockLayout := new(mocks.MockLayoutEngine)
+	mockPathfinder := new(mocks.MockPathfinder)
+	mockRenderer := new(mocks.MockRenderer)
+	mockReporter := new(mocks.MockReporter)
+
+	mockParser.On("Parse", "test.mmd").Return(diagram, nil)
+	mockLayout.On("Arrange", diagram).Return(nil)
+	mockPathfinder.On("FindPaths", diagram).Return(nil)
+	mockRenderer.On("Render", diagram, "output.svg").Return(nil)
+	mockReporter.On("Warn", "flowchart direction LR is not supported, drawing it from top to bottom").Return()
+
+	uc := NewGenerateDiagram(mockParser, mockLayout, mockPathfinder, mockRenderer).WithReporter(mockReporter)
+
+	err := uc.Execute("test.mmd", "output.svg")
+
+	assert.NoError(t, err)
+	mockReporter.AssertExpectations(t)
+}
+
+func TestGenerateDiagram_Execute_Overrides(t *testing.T) {
+	newDiagram := func() *domain.Diagram {
+		return &domain.Diagram{
+			Nodes: []domain.Node{
+				{ID: "a", Width: 5, Height: 5},
+			},
+			Config: domain.DiagramConfig{
+				NodeWidth:      5,
+				NodeHeight:     5,
+				Margin:         1,
+				PathAttempts:   100,
+				LayoutAttempts: 100,
+			},
+		}
+	}
+
+	t.Run("overrides are applied in order before the layout", func(t *testing.T) {
+		diagram := newDiagram()
+
+		mockParser := new(mocks.MockConfigParser)
+		mockLayout := new(mocks.MockLayoutEngine)
+		mockPathfinder := new(mocks.MockPathfinder)
+		mockRenderer := new(mocks.MockRenderer)
+
+		mockParser.On("Parse", "test.layli").Return(diagram, nil)
+		mockLayout.On("Arrange", diagram).Run(func(args mock.Arguments) {
+			d := args.Get(0).(*domain.Diagram)
+			assert.Equal(t, domain.LayoutTarjan, d.Config.LayoutType)
+			assert.Equal(t, 3, d.Config.Margin)
+		}).Return(nil)
+		mockPathfinder.On("FindPaths", diagram).Return(nil)
+		mockRenderer.On("Render", diagram, "output.svg").Return(nil)
+
+		uc := NewGenerateDiagram(mockParser, mockLayout, mockPathfinder, mockRenderer).WithOverrides(
+			domain.ConfigOverride{Key: "layout", Value: "topo-sort"},
+			domain.ConfigOverride{Key: "margin", Value: "3"},
+			domain.ConfigOverride{Key: "layout", Value: "tarjan"},
+		)
+
+		err := uc.Execute("test.layli", "output.svg")
+
+		assert.NoError(t, err)
+		mockLayout.AssertExpectations(t)
+	})
+
+	t.Run("overridden config is validated", func(t *testing.T) {
+		tests := []struct {
+			override domain.ConfigOverride
+			wantErr  string
+		}{
+			{domain.ConfigOverride{Key: "margin", Value: "11"}, "validate diagram: margin must be between 0 and 10"},
+			{domain.ConfigOverride{Key: "border", Value: "-3"}, "validate diagram: border cannot be negative"},
+			{domain.ConfigOverride{Key: "path.bend-penalty", Value: "-5"}, "validate diagram: bend penalty cannot be negative"},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.override.String(), func(t *testing.T) {
+				mockParser := new(mocks.MockConfigParser)
+				mockLayout := new(mocks.MockLayoutEngine)
+				mockPathfinder := new(mocks.MockPathfinder)
+				mockRenderer := new(mocks.MockRenderer)
+
+				mockParser.On("Parse", "test.layli").Return(newDiagram(), nil)
+
+				uc := NewGenerateDiagram(mockParser, mockLayout, mockPathfinder, mockRenderer).
+					WithOverrides(tt.override)
+
+				err := uc.Execute("test.layli", "output.svg")
+
+				assert.ErrorContains(t, err, tt.wantErr)
+				mockLayout.AssertNotCalled(t, "Arrange", mock.Anything)
+			})
+		}
+	})
+
+	t.Run("unknown keys are errors", func(t *testing.T) {
+		mockParser := new(mocks.MockConfigParser)
+		mockLayout := new(mocks.MockLayoutEngine)
+		mockPathfinder := new(mocks.MockPathfinder)
+		mockRenderer := new(mocks.MockRenderer)
+
+		mockParser.On("Parse", "test.layli").Return(newDiagram(), nil)
+
+		uc := NewGenerateDiagram(mockParser, mockLayout, mockPathfinder, mockRenderer).
+			WithOverrides(domain.ConfigOverride{Key: "colour", Value: "red"})
+
+		err := uc.Execute("test.layli", "output.svg")
+
+		assert.ErrorContains(t, err, "override config: unknown config key: colour")
+		mockLayout.AssertNotCalled(t, "Arrange", mock.Anything)
+	})
+}
@@ -0,0 +1,15 @@
+package mocks
+
+import (
+	"github.com/stretchr/testify/mock"
+)
+
+// MockReporter is a mock implementation of Reporter.
+type MockReporter struct {
+	mock.Mock
+}
+
+// Warn implements Reporter.Warn.
+func (m *MockReporter) Warn(message string) {
+	m.Called(message)
+}
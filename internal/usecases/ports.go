@@ -21,7 +21,9 @@ type LayoutEngine interface {
 // Pathfinder calculates edge paths between nodes.
 // Implementations: Dijkstra, A*, etc.
 type Pathfinder interface {
-	// FindPaths calculates paths for all edges in the diagram.
+	// FindPaths calculates paths for all edges in the diagram, routing
+	// against the node positions already chosen by the LayoutEngine.
+	// Implementations must not move nodes.
 	// Maps to: "And calculate paths for all edges"
 	FindPaths(diagram *domain.Diagram) error
 }
@@ -34,6 +36,15 @@ type Renderer interface {
 	Render(diagram *domain.Diagram, outputPath string) error
 }
 
+// Reporter tells the user about problems that don't stop a diagram from
+// being generated.
+// Implementations: writer (stderr)
+type Reporter interface {
+	// Warn reports a single problem.
+	// Maps to: "And the app output contains 'warning: ...'"
+	Warn(message string)
+}
+
 // FileReader abstracts file system reads (for testing).
 type FileReader interface {
 	Read(path string) ([]byte, error)
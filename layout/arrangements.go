@@ -4,11 +4,12 @@ import (
 	"errors"
 	"fmt"
 	"math"
+	"slices"
 
 	"github.com/barkimedes/go-deepcopy"
+	"github.com/dnnrly/layli/algorithms/layered"
 	"github.com/dnnrly/layli/algorithms/tarjan"
 	"github.com/dnnrly/layli/algorithms/topological"
-	"github.com/dnnrly/layli/internal/common"
 )
 
 // LayoutArrangementFunc returns a slice of nodes arranged according to the algorithm implemented
@@ -38,12 +39,22 @@ func selectArrangement(c *Config) (LayoutArrangementFunc, error) {
 
 	case "absolute":
 		return LayoutAbsolute, nil
+
+	case "layered":
+		return LayoutLayered, nil
 	}
 
 	return nil, errors.New("do not understand layout " + c.Layout)
 }
 
+// LayoutFlowSquare arranges nodes in to a square grid, filling each row in
+// turn. Each column is as wide as its widest node and each row as tall as its
+// tallest node so that nodes of different sizes never overlap.
 func LayoutFlowSquare(c *Config) (LayoutNodes, error) {
+	return arrangeGroups(c, flowSquare)
+}
+
+func flowSquare(c *Config) (LayoutNodes, error) {
 	numNodes := len(c.Nodes)
 	nodes := make(LayoutNodes, numNodes)
 
@@ -57,27 +68,26 @@ func LayoutFlowSquare(c *Config) (LayoutNodes, error) {
 		size = 2
 	}
 
+	columns := make([]int, size)
+	rows := make([]int, size)
+	for pos := range c.Nodes {
+		width, height := c.NodeSize(&c.Nodes[pos])
+		columns[pos%size] = max(columns[pos%size], width)
+		rows[pos/size] = max(rows[pos/size], height)
+	}
+
 	pos := 0
+	top := c.Border + c.Margin
 	for y := 0; y < size && pos < numNodes; y++ {
+		left := c.Border + c.Margin
 		for x := 0; x < size && pos < numNodes; x++ {
-			nodes[pos] = NewLayoutNode(
-				c.Nodes[pos].Id,
-				c.Nodes[pos].Contents,
-				c.Border+
-					c.Margin+
-					(x*c.NodeWidth)+
-					(x*(c.Margin*2)),
-				c.Border+
-					c.Margin+
-					(y*c.NodeHeight)+
-					(y*(c.Margin*2)),
-				c.NodeWidth, c.NodeHeight,
-				c.Nodes[pos].Class,
-				c.Nodes[pos].Style,
-			)
+			width, height := c.NodeSize(&c.Nodes[pos])
+			nodes[pos] = placeNode(&c.Nodes[pos], left, top, width, height)
 
+			left += columns[x] + (c.Margin * 2)
 			pos++
 		}
+		top += rows[y] + (c.Margin * 2)
 	}
 
 	return nodes, nil
@@ -85,6 +95,10 @@ func LayoutFlowSquare(c *Config) (LayoutNodes, error) {
 
 // LayoutTopologicalSort arranges nodes in a single row, sorted in topological order
 func LayoutTopologicalSort(config *Config) (LayoutNodes, error) {
+	return arrangeGroups(config, topologicalSort)
+}
+
+func topologicalSort(config *Config) (LayoutNodes, error) {
 	layoutNodes := LayoutNodes{}
 	graph := topological.NewGraph()
 
@@ -94,23 +108,14 @@ func LayoutTopologicalSort(config *Config) (LayoutNodes, error) {
 
 	rankedNodes := graph.RankNodes()
 
-	for i, id := range rankedNodes {
+	left := config.Border + config.Margin
+	for _, id := range rankedNodes {
 		c := config.Nodes.ByID(id)
+		width, height := config.NodeSize(c)
 
-		layoutNodes = append(layoutNodes, NewLayoutNode(
-			id, c.Contents,
-			config.Border+
-				config.Margin+
-				(i*config.NodeWidth)+
-				(i*(config.Margin*2)),
-			config.Border+
-				config.Margin+
-				(0*config.NodeHeight)+
-				(0*(config.Margin*2)),
-			config.NodeWidth, config.NodeHeight,
-			c.Class,
-			c.Style,
-		))
+		layoutNodes = append(layoutNodes, placeNode(c, left, config.Border+config.Margin, width, height))
+
+		left += width + (config.Margin * 2)
 	}
 
 	return layoutNodes, nil
@@ -118,6 +123,10 @@ func LayoutTopologicalSort(config *Config) (LayoutNodes, error) {
 
 // LayoutTarjan arranges nodes in multiple rows according to Tarhan's algorithm
 func LayoutTarjan(config *Config) (LayoutNodes, error) {
+	return arrangeGroups(config, tarjanRows)
+}
+
+func tarjanRows(config *Config) (LayoutNodes, error) {
 	layoutNodes := LayoutNodes{}
 	graph := tarjan.NewGraph()
 
@@ -127,41 +136,128 @@ func LayoutTarjan(config *Config) (LayoutNodes, error) {
 
 	nodes := graph.RankNodes()
 
+	// Each component is placed in its own column, so work out how wide each
+	// column needs to be and how tall each row is across all of the columns
+	widths := make([]int, len(nodes))
+	heights := []int{}
 	for row, rNodes := range nodes {
+		for col, id := range rNodes {
+			width, height := config.NodeSize(config.Nodes.ByID(id))
+			widths[row] = max(widths[row], width)
+			if col == len(heights) {
+				heights = append(heights, 0)
+			}
+			heights[col] = max(heights[col], height)
+		}
+	}
+
+	left := config.Border + config.Margin
+	for row, rNodes := range nodes {
+		top := config.Border + config.Margin
 		for col, id := range rNodes {
 			c := config.Nodes.ByID(id)
+			width, height := config.NodeSize(c)
 
-			layoutNodes = append(layoutNodes, NewLayoutNode(
-				id, c.Contents,
-				config.Border+
-					config.Margin+
-					(row*config.NodeWidth)+
-					(row*(config.Margin*2)),
-				config.Border+
-					config.Margin+
-					(col*config.NodeHeight)+
-					(col*(config.Margin*2)),
-				config.NodeWidth, config.NodeHeight,
-				c.Class,
-				c.Style,
-			))
+			layoutNodes = append(layoutNodes, placeNode(c, left, top, width, height))
+
+			top += heights[col] + (config.Margin * 2)
 		}
+		left += widths[row] + (config.Margin * 2)
+	}
+
+	return layoutNodes, nil
+}
+
+// LayoutLayered arranges nodes in rows from top to bottom so that edges point
+// down the diagram, ordering each row to reduce the number of edges that cross.
+// Where an edge skips over a row, a gap is left in that row for it to pass.
+func LayoutLayered(config *Config) (LayoutNodes, error) {
+	return arrangeGroups(config, layeredRows)
+}
+
+func layeredRows(config *Config) (LayoutNodes, error) {
+	layoutNodes := LayoutNodes{}
+	graph := layered.NewGraph()
+
+	for _, n := range config.Nodes {
+		graph.AddNode(n.Id)
+	}
+	for _, e := range config.Edges {
+		graph.AddEdge(e.From, e.To)
+	}
+
+	layers := graph.RankNodes()
+	if config.Direction == DirectionBottomTop || config.Direction == DirectionRightLeft {
+		slices.Reverse(layers)
+	}
+
+	// Ranks are laid out as rows from the top down and then turned on their
+	// side when the diagram goes across, so the sizes used are turned too
+	across := config.Direction == DirectionLeftRight || config.Direction == DirectionRightLeft
+	size := func(c *ConfigNode) (int, int) {
+		width, height := config.NodeSize(c)
+		if across {
+			return height, width
+		}
+		return width, height
+	}
+
+	// Nodes are lined up in columns across the rows, so work out how wide
+	// each column needs to be and how tall each row is
+	widths := []int{}
+	heights := make([]int, len(layers))
+	for row, rNodes := range layers {
+		for col, id := range rNodes {
+			if col == len(widths) {
+				widths = append(widths, 0)
+			}
+			if id == "" {
+				continue
+			}
+			width, height := size(config.Nodes.ByID(id))
+			widths[col] = max(widths[col], width)
+			heights[row] = max(heights[row], height)
+		}
+	}
+
+	top := config.Border + config.Margin
+	for row, rNodes := range layers {
+		left := config.Border + config.Margin
+		for col, id := range rNodes {
+			if id != "" {
+				c := config.Nodes.ByID(id)
+				width, height := config.NodeSize(c)
+
+				if across {
+					layoutNodes = append(layoutNodes, placeNode(c, top, left, width, height))
+				} else {
+					layoutNodes = append(layoutNodes, placeNode(c, left, top, width, height))
+				}
+			}
+
+			left += widths[col] + (config.Margin * 2)
+		}
+		top += heights[row] + (config.Margin * 2)
 	}
 
 	return layoutNodes, nil
 }
 
 func LayoutRandomShortestSquare(config *Config) (LayoutNodes, error) {
-	return shuffleNodes(config, LayoutFlowSquare)
+	return arrangeGroups(config, func(c *Config) (LayoutNodes, error) {
+		return shuffleNodes(c, flowSquare)
+	})
 }
 
 func shuffleNodes(config *Config, arrange LayoutArrangementFunc) (LayoutNodes, error) {
+	// The copy can't share the random source, so take it from the original
+	random := config.random()
 	c := deepcopy.MustAnything(config).(*Config)
 	var shortest LayoutNodes
 	shortestDist := math.MaxFloat64
 
 	for i := 0; i < config.LayoutAttempts; i++ {
-		common.Shuffle(len(c.Nodes), func(i, j int) { c.Nodes[i], c.Nodes[j] = c.Nodes[j], c.Nodes[i] })
+		random.Shuffle(len(c.Nodes), func(i, j int) { c.Nodes[i], c.Nodes[j] = c.Nodes[j], c.Nodes[i] })
 		nodes, _ := arrange(c)
 		dist, _ := nodes.ConnectionDistances(c.Edges)
 		if dist < shortestDist {
@@ -178,20 +274,14 @@ func LayoutAbsolute(c *Config) (LayoutNodes, error) {
 	nodes := make(LayoutNodes, numNodes)
 
 	for i, n := range c.Nodes {
+		width, height := c.NodeSize(&n)
 		if n.Position.X < c.Border || n.Position.Y < c.Border {
 			return nil, fmt.Errorf("node %s overlaps border", n.Id)
 		}
 		if n.Position.X < c.Border+c.Margin || n.Position.Y < c.Border+c.Margin {
 			return nil, fmt.Errorf("node %s margin overlaps border", n.Id)
 		}
-		nodes[i] = NewLayoutNode(
-			n.Id, n.Contents,
-			n.Position.X,
-			n.Position.Y,
-			c.NodeWidth, c.NodeHeight,
-			n.Class,
-			n.Style,
-		)
+		nodes[i] = placeNode(&n, n.Position.X, n.Position.Y, width, height)
 	}
 
 	for i, node1 := range nodes {
@@ -207,6 +297,13 @@ func LayoutAbsolute(c *Config) (LayoutNodes, error) {
 		}
 	}
 
+	// Groups are drawn around wherever their members have been put, so
+	// make sure that they don't catch anything else
+	groups := NewLayoutGroups(c.Groups, nodes, c.Margin, c.Spacing)
+	if err := groups.validate(nodes, c.Border); err != nil {
+		return nil, err
+	}
+
 	return nodes, nil
 }
 
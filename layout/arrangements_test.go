@@ -6,6 +6,7 @@ import (
 	"runtime"
 	"testing"
 
+	"github.com/dnnrly/layli/internal/common"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -47,6 +48,7 @@ func TestSelectArrangement(t *testing.T) {
 	a(LayoutTopologicalSort, Config{Layout: "topo-sort"})
 	a(LayoutRandomShortestSquare, Config{Layout: "random-shortest-square"})
 	a(LayoutAbsolute, Config{Layout: "absolute"})
+	a(LayoutLayered, Config{Layout: "layered"})
 
 	actual, err := selectArrangement(&Config{Layout: "unknown"})
 	assert.Error(t, err)
@@ -58,50 +60,65 @@ func TestLayoutFlowSquare(t *testing.T) {
 		l, _ := LayoutFlowSquare(newConfig(2, 5, 3, 1, 1))
 
 		require.Len(t, l, 2)
-		assert.EqualValues(t, LayoutNode{"1", "", 5, 3, 2, 4, 2, 6, "", ""}, l[0])
-		assert.EqualValues(t, LayoutNode{"2", "", 5, 3, 2, 4, 9, 13, "", ""}, l[1])
+		assert.EqualValues(t, LayoutNode{"1", "", 5, 3, 2, 4, 2, 6, "", "", ""}, l[0])
+		assert.EqualValues(t, LayoutNode{"2", "", 5, 3, 2, 4, 9, 13, "", "", ""}, l[1])
 	}
 
 	{
 		l, _ := LayoutFlowSquare(newConfig(4, 5, 3, 1, 1))
 
 		require.Len(t, l, 4)
-		assert.EqualValues(t, LayoutNode{"1", "", 5, 3, 2, 4, 2, 6, "", ""}, l[0])
-		assert.EqualValues(t, LayoutNode{"2", "", 5, 3, 2, 4, 9, 13, "", ""}, l[1])
-		assert.EqualValues(t, LayoutNode{"3", "", 5, 3, 7, 9, 2, 6, "", ""}, l[2])
-		assert.EqualValues(t, LayoutNode{"4", "", 5, 3, 7, 9, 9, 13, "", ""}, l[3])
+		assert.EqualValues(t, LayoutNode{"1", "", 5, 3, 2, 4, 2, 6, "", "", ""}, l[0])
+		assert.EqualValues(t, LayoutNode{"2", "", 5, 3, 2, 4, 9, 13, "", "", ""}, l[1])
+		assert.EqualValues(t, LayoutNode{"3", "", 5, 3, 7, 9, 2, 6, "", "", ""}, l[2])
+		assert.EqualValues(t, LayoutNode{"4", "", 5, 3, 7, 9, 9, 13, "", "", ""}, l[3])
 	}
 
 	{
 		l, _ := LayoutFlowSquare(newConfig(4, 5, 3, 2, 1))
 
 		require.Len(t, l, 4)
-		assert.EqualValues(t, LayoutNode{"1", "", 5, 3, 3, 5, 3, 7, "", ""}, l[0])
-		assert.EqualValues(t, LayoutNode{"2", "", 5, 3, 3, 5, 12, 16, "", ""}, l[1])
-		assert.EqualValues(t, LayoutNode{"3", "", 5, 3, 10, 12, 3, 7, "", ""}, l[2])
-		assert.EqualValues(t, LayoutNode{"4", "", 5, 3, 10, 12, 12, 16, "", ""}, l[3])
+		assert.EqualValues(t, LayoutNode{"1", "", 5, 3, 3, 5, 3, 7, "", "", ""}, l[0])
+		assert.EqualValues(t, LayoutNode{"2", "", 5, 3, 3, 5, 12, 16, "", "", ""}, l[1])
+		assert.EqualValues(t, LayoutNode{"3", "", 5, 3, 10, 12, 3, 7, "", "", ""}, l[2])
+		assert.EqualValues(t, LayoutNode{"4", "", 5, 3, 10, 12, 12, 16, "", "", ""}, l[3])
 	}
 
 	{
 		l, _ := LayoutFlowSquare(newConfig(8, 5, 3, 2, 1))
 
 		require.Len(t, l, 8)
-		assert.EqualValues(t, LayoutNode{"1", "", 5, 3, 3, 5, 3, 7, "", ""}, l[0])
-		assert.EqualValues(t, LayoutNode{"3", "", 5, 3, 3, 5, 21, 25, "", ""}, l[2])
-		assert.EqualValues(t, LayoutNode{"4", "", 5, 3, 10, 12, 3, 7, "", ""}, l[3])
-		assert.EqualValues(t, LayoutNode{"6", "", 5, 3, 10, 12, 21, 25, "", ""}, l[5])
-		assert.EqualValues(t, LayoutNode{"8", "", 5, 3, 17, 19, 12, 16, "", ""}, l[7])
+		assert.EqualValues(t, LayoutNode{"1", "", 5, 3, 3, 5, 3, 7, "", "", ""}, l[0])
+		assert.EqualValues(t, LayoutNode{"3", "", 5, 3, 3, 5, 21, 25, "", "", ""}, l[2])
+		assert.EqualValues(t, LayoutNode{"4", "", 5, 3, 10, 12, 3, 7, "", "", ""}, l[3])
+		assert.EqualValues(t, LayoutNode{"6", "", 5, 3, 10, 12, 21, 25, "", "", ""}, l[5])
+		assert.EqualValues(t, LayoutNode{"8", "", 5, 3, 17, 19, 12, 16, "", "", ""}, l[7])
 	}
 
 	{
 		l, _ := LayoutFlowSquare(newConfig(4, 5, 4, 2, 2))
 
 		require.Len(t, l, 4)
-		assert.EqualValues(t, LayoutNode{"1", "", 5, 4, 3, 6, 3, 7, "", ""}, l[0])
-		assert.EqualValues(t, LayoutNode{"4", "", 5, 4, 11, 14, 12, 16, "", ""}, l[3])
+		assert.EqualValues(t, LayoutNode{"1", "", 5, 4, 3, 6, 3, 7, "", "", ""}, l[0])
+		assert.EqualValues(t, LayoutNode{"4", "", 5, 4, 11, 14, 12, 16, "", "", ""}, l[3])
 	}
 }
 
+func TestLayoutFlowSquare_mixedSizes(t *testing.T) {
+	c := newConfig(4, 5, 3, 1, 1)
+	c.Nodes[0].Width = 9
+	c.Nodes[3].Height = 6
+
+	l, err := LayoutFlowSquare(c)
+	require.NoError(t, err)
+
+	require.Len(t, l, 4)
+	assert.EqualValues(t, LayoutNode{"1", "", 9, 3, 2, 4, 2, 10, "", "", ""}, l[0])
+	assert.EqualValues(t, LayoutNode{"2", "", 5, 3, 2, 4, 13, 17, "", "", ""}, l[1])
+	assert.EqualValues(t, LayoutNode{"3", "", 5, 3, 7, 9, 2, 6, "", "", ""}, l[2])
+	assert.EqualValues(t, LayoutNode{"4", "", 5, 6, 7, 12, 13, 17, "", "", ""}, l[3])
+}
+
 func TestLayoutTopologicalSort_simpleLine(t *testing.T) {
 	nodes, err := LayoutTopologicalSort(&Config{
 		Nodes: ConfigNodes{ConfigNode{Id: "1"}, ConfigNode{Id: "2"}, ConfigNode{Id: "3"}},
@@ -154,6 +171,106 @@ func TestLayoutTarjan(t *testing.T) {
 	assertSameColumn(t, *nodes.ByID("4"), *nodes.ByID("5"))
 }
 
+func TestLayoutLayered(t *testing.T) {
+	nodes, err := LayoutLayered(&Config{
+		Nodes: ConfigNodes{
+			ConfigNode{Id: "1"}, ConfigNode{Id: "2"}, ConfigNode{Id: "3"},
+			ConfigNode{Id: "4"}, ConfigNode{Id: "5"}, ConfigNode{Id: "6"},
+		},
+		Edges: ConfigEdges{
+			ConfigEdge{From: "1", To: "2"},
+			ConfigEdge{From: "1", To: "3"},
+			ConfigEdge{From: "2", To: "4"},
+			ConfigEdge{From: "3", To: "4"},
+			ConfigEdge{From: "4", To: "1"},
+			ConfigEdge{From: "1", To: "5"},
+			ConfigEdge{From: "5", To: "4"},
+		},
+		Border: 1, Spacing: 1,
+		NodeWidth: 1, NodeHeight: 1, Margin: 1,
+	})
+
+	assert.NoError(t, err)
+	require.Len(t, nodes, 6)
+
+	assertAbove(t, *nodes.ByID("1"), *nodes.ByID("2"))
+	assertAbove(t, *nodes.ByID("2"), *nodes.ByID("4"))
+
+	assertSameRow(t, *nodes.ByID("1"), *nodes.ByID("6"))
+	assertSameRow(t, *nodes.ByID("2"), *nodes.ByID("3"))
+	assertSameRow(t, *nodes.ByID("2"), *nodes.ByID("5"))
+
+	assertLeftOf(t, *nodes.ByID("2"), *nodes.ByID("3"))
+	assertLeftOf(t, *nodes.ByID("3"), *nodes.ByID("5"))
+}
+
+func TestLayoutLayered_leavesGapsForLongEdges(t *testing.T) {
+	nodes, err := LayoutLayered(&Config{
+		Nodes: ConfigNodes{ConfigNode{Id: "1"}, ConfigNode{Id: "2"}, ConfigNode{Id: "3"}},
+		Edges: ConfigEdges{
+			ConfigEdge{From: "1", To: "2"},
+			ConfigEdge{From: "2", To: "3"},
+			ConfigEdge{From: "1", To: "3"},
+		},
+		Border: 1, Spacing: 1,
+		NodeWidth: 3, NodeHeight: 3, Margin: 1,
+	})
+
+	assert.NoError(t, err)
+	require.Len(t, nodes, 3)
+
+	assertAbove(t, *nodes.ByID("1"), *nodes.ByID("2"))
+	assertAbove(t, *nodes.ByID("2"), *nodes.ByID("3"))
+	assertSameColumn(t, *nodes.ByID("1"), *nodes.ByID("3"))
+}
+
+func TestLayoutLayered_directions(t *testing.T) {
+	layered := func(direction string) LayoutNodes {
+		nodes, err := LayoutLayered(&Config{
+			Nodes: ConfigNodes{
+				ConfigNode{Id: "1"}, ConfigNode{Id: "2"}, ConfigNode{Id: "3"},
+			},
+			Edges: ConfigEdges{
+				ConfigEdge{From: "1", To: "2"},
+				ConfigEdge{From: "1", To: "3"},
+			},
+			Border: 1, Spacing: 1,
+			NodeWidth: 5, NodeHeight: 3, Margin: 1,
+			Direction: direction,
+		})
+		require.NoError(t, err)
+		require.Len(t, nodes, 3)
+		return nodes
+	}
+
+	t.Run("top to bottom", func(t *testing.T) {
+		nodes := layered(DirectionTopBottom)
+		assertAbove(t, *nodes.ByID("1"), *nodes.ByID("2"))
+		assertSameRow(t, *nodes.ByID("2"), *nodes.ByID("3"))
+	})
+
+	t.Run("bottom to top", func(t *testing.T) {
+		nodes := layered(DirectionBottomTop)
+		assertAbove(t, *nodes.ByID("2"), *nodes.ByID("1"))
+		assertSameRow(t, *nodes.ByID("2"), *nodes.ByID("3"))
+	})
+
+	t.Run("left to right", func(t *testing.T) {
+		nodes := layered(DirectionLeftRight)
+		assertLeftOf(t, *nodes.ByID("1"), *nodes.ByID("2"))
+		assertSameColumn(t, *nodes.ByID("2"), *nodes.ByID("3"))
+		assertAbove(t, *nodes.ByID("2"), *nodes.ByID("3"))
+		assert.Equal(t, 5, nodes.ByID("1").Width())
+		assert.Equal(t, 3, nodes.ByID("1").Height())
+	})
+
+	t.Run("right to left", func(t *testing.T) {
+		nodes := layered(DirectionRightLeft)
+		assertLeftOf(t, *nodes.ByID("2"), *nodes.ByID("1"))
+		assertSameColumn(t, *nodes.ByID("2"), *nodes.ByID("3"))
+	})
+}
+
 func shuffleConfig() *Config {
 	return &Config{
 		Nodes: ConfigNodes{
@@ -181,6 +298,24 @@ func TestLayoutRandomShortestSquare(t *testing.T) {
 	assert.NotEqual(t, expected.String(), result.String(), "but got "+result.String())
 }
 
+func TestLayoutRandomShortestSquare_repeatsWithTheSameSeed(t *testing.T) {
+	seeded := func(seed int64) string {
+		c := shuffleConfig()
+		c.Seed = seed
+		result, err := LayoutRandomShortestSquare(c)
+		require.NoError(t, err)
+		return result.String()
+	}
+
+	assert.Equal(t, seeded(7), seeded(7))
+
+	injected := shuffleConfig()
+	injected.Random = common.New(7)
+	result, err := LayoutRandomShortestSquare(injected)
+	require.NoError(t, err)
+	assert.Equal(t, seeded(7), result.String(), "the injected source is used")
+}
+
 func TestShuffleNodes_shufflesNumTimes(t *testing.T) {
 	var count int
 	lastConfig := shuffleConfig()
@@ -233,11 +368,11 @@ func TestAbsoluteArrangement(t *testing.T) {
 
 	require.NoError(t, err)
 	require.Equal(t, 5, len(l))
-	assert.EqualValues(t, LayoutNode{"1", "", 5, 4, 10, 13, 50, 54, "", ""}, l[0])
-	assert.EqualValues(t, LayoutNode{"2", "", 5, 4, 20, 23, 40, 44, "", ""}, l[1])
-	assert.EqualValues(t, LayoutNode{"3", "", 5, 4, 30, 33, 30, 34, "", ""}, l[2])
-	assert.EqualValues(t, LayoutNode{"4", "", 5, 4, 40, 43, 20, 24, "", ""}, l[3])
-	assert.EqualValues(t, LayoutNode{"5", "", 5, 4, 50, 53, 10, 14, "", ""}, l[4])
+	assert.EqualValues(t, LayoutNode{"1", "", 5, 4, 10, 13, 50, 54, "", "", ""}, l[0])
+	assert.EqualValues(t, LayoutNode{"2", "", 5, 4, 20, 23, 40, 44, "", "", ""}, l[1])
+	assert.EqualValues(t, LayoutNode{"3", "", 5, 4, 30, 33, 30, 34, "", "", ""}, l[2])
+	assert.EqualValues(t, LayoutNode{"4", "", 5, 4, 40, 43, 20, 24, "", "", ""}, l[3])
+	assert.EqualValues(t, LayoutNode{"5", "", 5, 4, 50, 53, 10, 14, "", "", ""}, l[4])
 }
 
 func TestAbsoluteArrangement_ErrorsOnOverlaps(t *testing.T) {
@@ -324,6 +459,25 @@ func TestAbsoluteArrangement_MarginOverlap(t *testing.T) {
 	assert.Equal(t, "nodes 1 and 2 margins overlap", err.Error())
 }
 
+func TestAbsoluteArrangement_ErrorsOnMixedSizeOverlaps(t *testing.T) {
+	_, err := LayoutAbsolute(&Config{
+		Layout: "absolute",
+		Nodes: ConfigNodes{
+			ConfigNode{Id: "1", Position: Position{X: 3, Y: 3}, Width: 12},
+			ConfigNode{Id: "2", Position: Position{X: 10, Y: 4}},
+		},
+
+		Spacing:    1,
+		NodeWidth:  5,
+		NodeHeight: 3,
+		Margin:     2,
+		Border:     1,
+	})
+
+	require.Error(t, err)
+	assert.Equal(t, "nodes 1 and 2 overlap", err.Error())
+}
+
 func TestNodesOverlapWithMargin(t *testing.T) {
 	tests := []struct {
 		name    string
@@ -395,6 +549,7 @@ func TestArrangementsPassClassAndStyle(t *testing.T) {
 		LayoutAbsolute,
 		LayoutTopologicalSort,
 		LayoutRandomShortestSquare,
+		LayoutLayered,
 	}
 
 	for _, f := range arrangements {
@@ -416,3 +571,103 @@ func TestArrangementsPassClassAndStyle(t *testing.T) {
 		})
 	}
 }
+
+func TestArrangementsHandleMixedSizes(t *testing.T) {
+	c := &Config{
+		Nodes: ConfigNodes{
+			ConfigNode{Id: "wide", Width: 9, Position: Position{X: 3, Y: 3}},
+			ConfigNode{Id: "tall", Height: 6, Position: Position{X: 16, Y: 3}},
+			ConfigNode{Id: "default", Position: Position{X: 3, Y: 10}},
+			ConfigNode{Id: "big", Width: 7, Height: 5, Position: Position{X: 16, Y: 14}},
+		},
+		Edges: ConfigEdges{
+			ConfigEdge{From: "wide", To: "tall"},
+			ConfigEdge{From: "tall", To: "default"},
+			ConfigEdge{From: "default", To: "big"},
+			ConfigEdge{From: "tall", To: "big"},
+		},
+		NodeWidth:      5,
+		NodeHeight:     3,
+		Border:         1,
+		Margin:         2,
+		LayoutAttempts: 3,
+	}
+	name := func(f LayoutArrangementFunc) string {
+		return runtime.FuncForPC(reflect.ValueOf(f).Pointer()).Name()
+	}
+
+	arrangements := []LayoutArrangementFunc{
+		LayoutFlowSquare,
+		LayoutTarjan,
+		LayoutAbsolute,
+		LayoutTopologicalSort,
+		LayoutRandomShortestSquare,
+		LayoutLayered,
+	}
+
+	for _, f := range arrangements {
+		t.Run(fmt.Sprintf("Checking %s", name(f)), func(t *testing.T) {
+			result, err := f(c)
+			require.NoError(t, err)
+			require.Len(t, result, 4)
+
+			for _, n := range c.Nodes {
+				node := result.ByID(n.Id)
+				require.NotNil(t, node, n.Id)
+				width, height := c.NodeSize(&n)
+				assert.Equal(t, width, node.width, "width of %s", n.Id)
+				assert.Equal(t, height, node.height, "height of %s", n.Id)
+				assert.GreaterOrEqual(t, node.left, c.Border+c.Margin, "%s is clear of the border", n.Id)
+				assert.GreaterOrEqual(t, node.top, c.Border+c.Margin, "%s is clear of the border", n.Id)
+			}
+
+			for i, n1 := range result {
+				for j, n2 := range result {
+					if i == j {
+						continue
+					}
+					assert.False(t, nodesOverlap(n1, n2), "%s (%s) overlaps %s (%s)", n1.Id, s(n1), n2.Id, s(n2))
+					assert.False(t, marginsOverlap(n1, n2, c.Margin), "%s (%s) is too close to %s (%s)", n1.Id, s(n1), n2.Id, s(n2))
+				}
+			}
+		})
+	}
+}
+
+func TestArrangementsHandleAutoSize(t *testing.T) {
+	c := &Config{
+		Nodes: ConfigNodes{
+			ConfigNode{Id: "long", Contents: "A rather long description"},
+			ConfigNode{Id: "short", Contents: "A"},
+			ConfigNode{Id: "fixed", Contents: "A rather long description", Sizing: SizingFixed},
+		},
+		Edges: ConfigEdges{
+			ConfigEdge{From: "long", To: "short"},
+			ConfigEdge{From: "short", To: "fixed"},
+		},
+		NodeWidth:      5,
+		NodeHeight:     3,
+		NodeSizing:     SizingAuto,
+		Border:         1,
+		Margin:         2,
+		Spacing:        20,
+		LayoutAttempts: 1,
+	}
+
+	result, err := LayoutFlowSquare(c)
+	require.NoError(t, err)
+
+	long := result.ByID("long")
+	assert.Equal(t, 8, long.width)
+	assert.Equal(t, 3, long.height)
+
+	short := result.ByID("short")
+	assert.Equal(t, 3, short.width)
+	assert.Equal(t, 3, short.height)
+	assertLeftOf(t, *long, *short)
+	assert.False(t, marginsOverlap(*long, *short, c.Margin))
+
+	fixed := result.ByID("fixed")
+	assert.Equal(t, 5, fixed.width)
+	assert.Equal(t, 3, fixed.height)
+}
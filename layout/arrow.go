@@ -0,0 +1,208 @@
+package layout
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	svg "github.com/ajstarks/svgo"
+)
+
+// Ends of an edge that get an arrow head
+const (
+	ArrowNone     = "none"
+	ArrowForward  = "forward"
+	ArrowBackward = "backward"
+	ArrowBoth     = "both"
+)
+
+// Shapes that an arrow head can be drawn as
+const (
+	HeadTriangle      = "triangle"
+	HeadOpenArrow     = "open-arrow"
+	HeadDiamond       = "diamond"
+	HeadHollowDiamond = "hollow-diamond"
+	HeadCircle        = "circle"
+	HeadCrowsFoot     = "crows-foot"
+)
+
+// defaultStroke is the colour that paths are drawn in unless a style says
+// otherwise
+const defaultStroke = "black"
+
+// IsArrow returns true if s says which ends of an edge get an arrow head
+func IsArrow(s string) bool {
+	return s == "" || s == ArrowNone || s == ArrowForward || s == ArrowBackward || s == ArrowBoth
+}
+
+// IsHead returns true if s is the name of an arrow head shape
+func IsHead(s string) bool {
+	switch s {
+	case "", HeadTriangle, HeadOpenArrow, HeadDiamond, HeadHollowDiamond, HeadCircle, HeadCrowsFoot:
+		return true
+	}
+	return false
+}
+
+// validateArrow checks the arrow head settings on an edge
+func validateArrow(e *ConfigEdge) error {
+	if !IsArrow(e.Arrow) {
+		return fmt.Errorf("invalid arrow for edge %s: %s. Valid options: none, forward, backward, both", e.ID, e.Arrow)
+	}
+	if !IsHead(e.Head) {
+		return fmt.Errorf("invalid head for edge %s: %s. Valid options: triangle, open-arrow, diamond, hollow-diamond, circle, crows-foot", e.ID, e.Head)
+	}
+	return nil
+}
+
+// arrowHead is a marker that is drawn at the end of a path. There is a
+// marker for each shape and colour that is used in the diagram.
+type arrowHead struct {
+	shape  string
+	colour string
+}
+
+var nonIDChars = regexp.MustCompile(`[^a-zA-Z0-9]`)
+
+// id is the ID of the marker. The black triangle keeps the name that it has
+// always had.
+func (h arrowHead) id() string {
+	if h.shape == HeadTriangle && h.colour == defaultStroke {
+		return "arrow"
+	}
+
+	id := "arrow-" + h.shape
+	if h.colour != defaultStroke {
+		id += "-" + nonIDChars.ReplaceAllString(h.colour, "_")
+	}
+	return id
+}
+
+// draw adds the marker to the defs of the SVG. Every shape points to the
+// right and is turned to follow the path.
+func (h arrowHead) draw(canvas *svg.SVG) {
+	fill := h.colour
+	switch h.shape {
+	case HeadHollowDiamond, HeadCircle:
+		fill = "white"
+	case HeadOpenArrow, HeadCrowsFoot:
+		fill = "none"
+	}
+
+	refX := 10
+	if h.shape == HeadCircle {
+		refX = 9
+	}
+
+	canvas.Marker(h.id(), refX, 5, 7, 7,
+		`viewBox="0 0 10 10"`,
+		fmt.Sprintf(`fill="%s"`, fill),
+		fmt.Sprintf(`stroke="%s"`, h.colour),
+		`orient="auto-start-reverse"`)
+	switch h.shape {
+	case HeadOpenArrow:
+		canvas.Path("M 0 1 L 10 5 L 0 9")
+	case HeadDiamond, HeadHollowDiamond:
+		canvas.Path("M 0 5 L 5 1 L 10 5 L 5 9 z")
+	case HeadCircle:
+		canvas.Circle(5, 5, 4)
+	case HeadCrowsFoot:
+		canvas.Path("M 0 5 L 10 1 M 0 5 L 10 5 M 0 5 L 10 9")
+	default:
+		canvas.Path("M 0 0 L 10 5 L 0 10 z")
+	}
+	canvas.MarkerEnd()
+}
+
+// arrowHead returns the marker drawn at the arrow ends of the path
+func (p *LayoutPath) arrowHead() arrowHead {
+	h := arrowHead{shape: p.Head, colour: p.colour}
+	if h.shape == "" {
+		h.shape = HeadTriangle
+	}
+	if h.colour == "" {
+		h.colour = defaultStroke
+	}
+	return h
+}
+
+// arrowAtStart returns true if the path has an arrow head where it leaves
+// the node it comes from
+func (p *LayoutPath) arrowAtStart() bool {
+	return p.Arrow == ArrowBackward || p.Arrow == ArrowBoth
+}
+
+// arrowAtEnd returns true if the path has an arrow head where it enters the
+// node it goes to
+func (p *LayoutPath) arrowAtEnd() bool {
+	return p.Arrow == "" || p.Arrow == ArrowForward || p.Arrow == ArrowBoth
+}
+
+// markers returns the attributes that put arrow heads on the path
+func (p *LayoutPath) markers() []string {
+	url := fmt.Sprintf(`url(#%s)`, p.arrowHead().id())
+	attrs := []string{}
+	if p.arrowAtStart() {
+		attrs = append(attrs, `marker-start="`+url+`"`)
+	}
+	if p.arrowAtEnd() {
+		attrs = append(attrs, `marker-end="`+url+`"`)
+	}
+	return attrs
+}
+
+// strokeColour works out the colour that the path is drawn in so that its
+// arrow heads can match. The path's own style wins over the styles for its
+// ID and then its classes.
+func (p *LayoutPath) strokeColour(styles ConfigStyles) string {
+	if c := cssProperty(p.Style, "stroke"); c != "" {
+		return c
+	}
+	if c := cssProperty(styles["#"+p.ID], "stroke"); c != "" {
+		return c
+	}
+
+	classes := append([]string{"path-line"}, strings.Fields(p.Class)...)
+	for i := len(classes) - 1; i >= 0; i-- {
+		if c := cssProperty(styles["."+classes[i]], "stroke"); c != "" {
+			return c
+		}
+	}
+
+	return defaultStroke
+}
+
+// arrowHeads colours the arrow heads of every path to match its stroke and
+// returns the markers that are needed to draw them, in the order that they
+// are first used
+func (paths LayoutPaths) arrowHeads(styles ConfigStyles) []arrowHead {
+	heads := []arrowHead{}
+	seen := map[arrowHead]bool{}
+	for i := range paths {
+		p := &paths[i]
+		p.colour = p.strokeColour(styles)
+		if !p.arrowAtStart() && !p.arrowAtEnd() {
+			continue
+		}
+
+		h := p.arrowHead()
+		if !seen[h] {
+			seen[h] = true
+			heads = append(heads, h)
+		}
+	}
+	return heads
+}
+
+// cssProperty returns the value of the last declaration of a property in a
+// block of CSS declarations, or an empty string if it isn't there
+func cssProperty(css, property string) string {
+	value := ""
+	for _, decl := range strings.Split(css, ";") {
+		name, v, found := strings.Cut(decl, ":")
+		if found && strings.TrimSpace(name) == property {
+			value = strings.TrimSpace(v)
+		}
+	}
+	return value
+}
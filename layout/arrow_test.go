@@ -0,0 +1,162 @@
+package layout
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/dnnrly/layli/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestArrowHead_id(t *testing.T) {
+	assert.Equal(t, "arrow", arrowHead{shape: HeadTriangle, colour: "black"}.id())
+	assert.Equal(t, "arrow-diamond", arrowHead{shape: HeadDiamond, colour: "black"}.id())
+	assert.Equal(t, "arrow-triangle-red", arrowHead{shape: HeadTriangle, colour: "red"}.id())
+	assert.Equal(t, "arrow-crows-foot-_00ff00", arrowHead{shape: HeadCrowsFoot, colour: "#00ff00"}.id())
+	assert.Equal(t, "arrow-circle-rgb_1__2__3_", arrowHead{shape: HeadCircle, colour: "rgb(1, 2, 3)"}.id())
+}
+
+func TestLayoutPath_strokeColour(t *testing.T) {
+	styles := ConfigStyles{
+		".path-line": "stroke: grey",
+		".async":     "stroke-dasharray: 4; stroke: blue",
+		".thick":     "stroke-width: 3",
+		"#special":   "stroke: orange",
+	}
+
+	tests := []struct {
+		name     string
+		path     LayoutPath
+		expected string
+	}{
+		{name: "all paths", path: LayoutPath{ID: "e"}, expected: "grey"},
+		{name: "class", path: LayoutPath{ID: "e", Class: "async thick"}, expected: "blue"},
+		{name: "ID", path: LayoutPath{ID: "special", Class: "async"}, expected: "orange"},
+		{name: "own style", path: LayoutPath{ID: "special", Style: "stroke:red;stroke-width:2"}, expected: "red"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, tt.path.strokeColour(styles))
+		})
+	}
+
+	assert.Equal(t, "black", (&LayoutPath{Class: "thick"}).strokeColour(nil))
+}
+
+func TestLayoutPaths_arrowHeads(t *testing.T) {
+	paths := LayoutPaths{
+		{ID: "a"},
+		{ID: "b", Arrow: ArrowBoth},
+		{ID: "c", Arrow: ArrowNone, Head: HeadCircle},
+		{ID: "d", Head: HeadDiamond, Style: "stroke:red"},
+		{ID: "e", Arrow: ArrowBackward, Head: HeadDiamond, Style: "stroke:red"},
+	}
+
+	assert.Equal(t, []arrowHead{
+		{shape: HeadTriangle, colour: "black"},
+		{shape: HeadDiamond, colour: "red"},
+	}, paths.arrowHeads(nil), "each marker is only needed once and edges without arrows don't need one")
+	assert.Equal(t, "red", paths[4].colour)
+}
+
+func TestLayoutPath_Draw_arrows(t *testing.T) {
+	points := Points{{X: 5.5, Y: 4.5}, {X: 8, Y: 4}, {X: 12, Y: 4}, {X: 14.5, Y: 4.5}}
+
+	t.Run("both ends", func(t *testing.T) {
+		drawer := mocks.NewLayoutDrawer(t)
+		p := LayoutPath{ID: "id", From: "a", To: "b", Points: points, Arrow: ArrowBoth, Head: HeadHollowDiamond}
+
+		drawer.On("Path", "M 80 40 L 120 40",
+			`id="id"`, `class="path-line"`, "",
+			`marker-start="url(#arrow-hollow-diamond)"`,
+			`marker-end="url(#arrow-hollow-diamond)"`,
+			`data-from="a"`, `data-to="b"`,
+			`data-arrow="both"`, `data-head="hollow-diamond"`,
+		).Once()
+
+		p.Draw(drawer, 10, 0)
+	})
+
+	t.Run("no arrows", func(t *testing.T) {
+		drawer := mocks.NewLayoutDrawer(t)
+		p := LayoutPath{ID: "id", From: "a", To: "b", Points: points, Arrow: ArrowNone}
+
+		drawer.On("Path", "M 80 40 L 120 40",
+			`id="id"`, `class="path-line"`, "",
+			`data-from="a"`, `data-to="b"`,
+			`data-arrow="none"`,
+		).Once()
+
+		p.Draw(drawer, 10, 0)
+	})
+}
+
+func TestDiagram_Draw_onlyDefinesMarkersThatAreUsed(t *testing.T) {
+	draw := func(paths LayoutPaths, styles ConfigStyles) string {
+		l := NewLayout(LayoutNodes{
+			NewLayoutNode("a", "", 3, 3, 5, 3, "", ""),
+			NewLayoutNode("b", "", 12, 3, 5, 3, "", ""),
+		}, paths, 5, 3, 2, 1, 20)
+
+		output := ""
+		d := Diagram{
+			Output: func(o string) error { output = o; return nil },
+			Config: Config{Spacing: 20, Styles: styles},
+			Layout: l,
+		}
+		require.NoError(t, d.Draw())
+		return output
+	}
+	points := Points{{X: 5.5, Y: 4.5}, {X: 8, Y: 4}, {X: 12, Y: 4}, {X: 14.5, Y: 4.5}}
+
+	svg := draw(LayoutPaths{{ID: "e", From: "a", To: "b", Points: points, Arrow: ArrowNone}}, nil)
+	assert.NotContains(t, svg, "<defs>")
+	assert.NotContains(t, svg, "marker")
+
+	svg = draw(LayoutPaths{
+		{ID: "e1", From: "a", To: "b", Points: points, Class: "async", Head: HeadCrowsFoot},
+		{ID: "e2", From: "b", To: "a", Points: points},
+	}, ConfigStyles{".async": "stroke:blue"})
+	assert.Equal(t, 2, strings.Count(svg, "<marker "))
+	assert.Contains(t, svg, `<marker id="arrow-crows-foot-blue" refX="10" refY="5" markerWidth="7" markerHeight="7" viewBox="0 0 10 10" fill="none" stroke="blue" orient="auto-start-reverse" >`)
+	assert.Contains(t, svg, `<marker id="arrow" refX="10" refY="5" markerWidth="7" markerHeight="7" viewBox="0 0 10 10" fill="black" stroke="black" orient="auto-start-reverse" >`)
+	assert.Contains(t, svg, `marker-end="url(#arrow-crows-foot-blue)"`)
+}
+
+func TestNewConfigFromFile_arrows(t *testing.T) {
+	config, err := NewConfigFromFile(strings.NewReader(`
+nodes:
+  - id: a
+  - id: b
+edges:
+  - from: a
+    to: b
+    arrow: backward
+    head: hollow-diamond
+`))
+	require.NoError(t, err)
+	assert.Equal(t, ArrowBackward, config.Edges[0].Arrow)
+	assert.Equal(t, HeadHollowDiamond, config.Edges[0].Head)
+
+	_, err = NewConfigFromFile(strings.NewReader(`
+nodes:
+  - id: a
+edges:
+  - from: a
+    to: a
+    arrow: sideways
+`))
+	assert.EqualError(t, err, "invalid arrow for edge edge-1: sideways. Valid options: none, forward, backward, both")
+
+	_, err = NewConfigFromFile(strings.NewReader(`
+nodes:
+  - id: a
+edges:
+  - from: a
+    to: a
+    head: star
+`))
+	assert.EqualError(t, err, "invalid head for edge edge-1: star. Valid options: triangle, open-arrow, diamond, hollow-diamond, circle, crows-foot")
+}
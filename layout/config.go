@@ -7,17 +7,24 @@ import (
 	"sort"
 	"strings"
 
+	"github.com/dnnrly/layli/internal/common"
 	"gopkg.in/yaml.v3"
 )
 
 type ConfigPath struct {
-	Attempts  int    `yaml:"attempts,omitempty"`
-	Strategy  string `yaml:"strategy,omitempty"`
-	Algorithm string `yaml:"algorithm,omitempty"`
-	Heuristic string `yaml:"heuristic,omitempty"`
-	Class     string `yaml:"class,omitempty"`
+	Attempts    int    `yaml:"attempts,omitempty"`
+	Strategy    string `yaml:"strategy,omitempty"`
+	Algorithm   string `yaml:"algorithm,omitempty"`
+	Heuristic   string `yaml:"heuristic,omitempty"`
+	BendPenalty int    `yaml:"bend-penalty,omitempty"`
+	Class       string `yaml:"class,omitempty"`
 }
 
+// DefaultBendPenalty is added to the cost of a path each time it changes
+// direction. It makes a corner cost the same as 3 extra grid units of path,
+// in the units used by PythagoreanDistance.
+const DefaultBendPenalty = 300
+
 type ConfigStyles map[string]string
 
 func (styles ConfigStyles) toCSS() string {
@@ -45,21 +52,42 @@ func (styles ConfigStyles) toCSS() string {
 // - For other algorithms: available for custom use (e.g., grid columns)
 // See CONTRIBUTING_LAYOUTS.md for details on using this field in new layouts.
 type Config struct {
-	Layout         string      `yaml:"layout,omitempty"`
-	LayoutAttempts int         `yaml:"layout-attempts,omitempty"`
-	Path           ConfigPath  `yaml:"path,omitempty"`
-	Nodes          ConfigNodes `yaml:"nodes"`
-	Edges          ConfigEdges `yaml:"edges"`
-	Spacing        int         `yaml:"-"`
-
-	NodeWidth  int `yaml:"width"`
-	NodeHeight int `yaml:"height"`
-	Border     int `yaml:"border"`
-	Margin     int `yaml:"margin"`
+	Layout         string       `yaml:"layout,omitempty"`
+	LayoutAttempts int          `yaml:"layout-attempts,omitempty"`
+	Path           ConfigPath   `yaml:"path,omitempty"`
+	Nodes          ConfigNodes  `yaml:"nodes"`
+	Edges          ConfigEdges  `yaml:"edges"`
+	Groups         ConfigGroups `yaml:"groups,omitempty"`
+	Spacing        int          `yaml:"-"`
+
+	// Seed starts the random choices made by the random-shortest-square
+	// layout and the random path strategy, so that a diagram can be made
+	// again exactly. Random is where those choices come from, and when it
+	// is nil a source is started from Seed.
+	Seed   int64           `yaml:"seed,omitempty"`
+	Random *common.Service `yaml:"-"`
+
+	NodeWidth  int    `yaml:"width"`
+	NodeHeight int    `yaml:"height"`
+	NodeSizing string `yaml:"size,omitempty"`
+	Border     int    `yaml:"border"`
+	Margin     int    `yaml:"margin"`
+
+	// Direction is the way that the layered layout draws its ranks. It is
+	// set by the formats that have one, such as Mermaid and DOT.
+	Direction string `yaml:"-"`
 
 	Styles ConfigStyles `yaml:"styles,omitempty"`
 }
 
+// random returns the source of random choices for the diagram
+func (config *Config) random() *common.Service {
+	if config.Random == nil {
+		config.Random = common.New(config.Seed)
+	}
+	return config.Random
+}
+
 func (config Config) String() string {
 	str, _ := yaml.Marshal(config)
 	return string(str)
@@ -74,12 +102,45 @@ type ConfigNode struct {
 	Id       string   `yaml:"id"`
 	Contents string   `yaml:"contents"`
 	Position Position `yaml:"position,omitempty"`
+	Width    int      `yaml:"width,omitempty"`
+	Height   int      `yaml:"height,omitempty"`
+	Sizing   string   `yaml:"size,omitempty"`
 	Class    string   `yaml:"class,omitempty"`
 	Style    string   `yaml:"style,omitempty"`
+	Shape    string   `yaml:"shape,omitempty"`
+
+	Ports ConfigPorts `yaml:"ports,omitempty"`
 }
 
 type ConfigNodes []ConfigNode
 
+// Ways that the size of a node can be chosen
+const (
+	SizingFixed = "fixed"
+	SizingAuto  = "auto"
+)
+
+// NodeSize returns the width and height of the node, falling back to the
+// diagram wide node size when the node doesn't set its own. Nodes that are
+// sized automatically are measured from their contents instead.
+func (config *Config) NodeSize(n *ConfigNode) (int, int) {
+	width, height := n.Width, n.Height
+	if config.autoSized(n) {
+		spacing := config.Spacing
+		if spacing == 0 {
+			spacing = defaultSpacing
+		}
+		width, height = autoSize(n.Contents, width, height, spacing)
+	}
+	if width == 0 {
+		width = config.NodeWidth
+	}
+	if height == 0 {
+		height = config.NodeHeight
+	}
+	return width, height
+}
+
 func (nodes ConfigNodes) ByID(id string) *ConfigNode {
 	for _, n := range nodes {
 		if n.Id == id {
@@ -89,23 +150,69 @@ func (nodes ConfigNodes) ByID(id string) *ConfigNode {
 	return nil
 }
 
+// autoSized returns true if the node should be sized to fit its contents,
+// either because it asks to be or because the whole diagram is
+func (config *Config) autoSized(n *ConfigNode) bool {
+	if n.Sizing != "" {
+		return n.Sizing == SizingAuto
+	}
+	return config.NodeSizing == SizingAuto
+}
+
+// nextEdgeID returns the first edge-N from n on that hasn't been taken,
+// taking it
+func nextEdgeID(n int, taken map[string]bool) string {
+	id := fmt.Sprintf("edge-%d", n)
+	for taken[id] {
+		n++
+		id = fmt.Sprintf("edge-%d", n)
+	}
+	taken[id] = true
+	return id
+}
+
+// Directions that the layered layout can draw ranks in
+const (
+	DirectionTopBottom = "TB"
+	DirectionBottomTop = "BT"
+	DirectionLeftRight = "LR"
+	DirectionRightLeft = "RL"
+)
+
+func validSizing(s string) bool {
+	return s == "" || s == SizingFixed || s == SizingAuto
+}
+
 type ConfigEdge struct {
 	ID    string `yaml:"id,omitempty"`
 	From  string `yaml:"from"`
 	To    string `yaml:"to"`
+	Label string `yaml:"label,omitempty"`
 	Class string `yaml:"class,omitempty"`
 	Style string `yaml:"style,omitempty"`
+
+	// Arrow says which ends of the edge get an arrow head and Head is the
+	// shape that they are drawn as
+	Arrow string `yaml:"arrow,omitempty"`
+	Head  string `yaml:"head,omitempty"`
+
+	// FromPort and ToPort choose where the edge meets each node, either a
+	// side or the name of a port declared on the node
+	FromPort string `yaml:"from-port,omitempty"`
+	ToPort   string `yaml:"to-port,omitempty"`
 }
 
 type ConfigEdges []ConfigEdge
 
 func NewConfigFromFile(r io.Reader) (*Config, error) {
-	config := Config{}
+	// The bend penalty is set before reading so that it is only defaulted
+	// when it is left out, as 0 turns the penalty off
+	config := Config{Path: ConfigPath{BendPenalty: DefaultBendPenalty}}
 	err := yaml.NewDecoder(r).Decode(&config)
 	if err != nil {
 		return nil, fmt.Errorf("reading config file: %w", err)
 	}
-	config.Spacing = 20
+	config.Spacing = defaultSpacing
 
 	if config.Path.Attempts == 0 {
 		config.Path.Attempts = 20
@@ -113,6 +220,9 @@ func NewConfigFromFile(r io.Reader) (*Config, error) {
 	if config.Path.Attempts > 10000 {
 		return nil, fmt.Errorf("cannot specify more that 10000 path attempts")
 	}
+	if config.Path.BendPenalty < 0 {
+		return nil, fmt.Errorf("bend penalty cannot be negative")
+	}
 
 	if config.NodeWidth == 0 {
 		config.NodeWidth = 5
@@ -136,6 +246,10 @@ func NewConfigFromFile(r io.Reader) (*Config, error) {
 		return nil, fmt.Errorf("cannot specify more that 10000 layout attempts")
 	}
 
+	if !validSizing(config.NodeSizing) {
+		return nil, fmt.Errorf("invalid node size: %s. Valid options: fixed, auto", config.NodeSizing)
+	}
+
 	if len(config.Nodes) == 0 {
 		return nil, fmt.Errorf("must specify at least 1 node")
 	}
@@ -144,22 +258,56 @@ func NewConfigFromFile(r io.Reader) (*Config, error) {
 		if n.Id == "" {
 			return nil, fmt.Errorf("all nodes must have an id")
 		}
+		if n.Width < 0 || n.Height < 0 {
+			return nil, fmt.Errorf("node %s cannot have a negative width or height", n.Id)
+		}
+		if !validSizing(n.Sizing) {
+			return nil, fmt.Errorf("invalid size for node %s: %s. Valid options: fixed, auto", n.Id, n.Sizing)
+		}
+		if !IsShape(n.Shape) {
+			return nil, fmt.Errorf("invalid shape for node %s: %s. Valid options: rounded, rect, ellipse, diamond, cylinder, hexagon, note, person", n.Id, n.Shape)
+		}
+		if err := validatePorts(&n); err != nil {
+			return nil, err
+		}
 	}
 
+	// Only the IDs that were written in the file can clash, as edges
+	// without one are given a name that isn't used
+	edgeIDs := map[string]bool{}
+	for _, e := range config.Edges {
+		if e.ID == "" {
+			continue
+		}
+		if edgeIDs[e.ID] {
+			return nil, fmt.Errorf("edge id %s is used more than once", e.ID)
+		}
+		edgeIDs[e.ID] = true
+	}
 	for i, e := range config.Edges {
 		if e.ID == "" {
-			config.Edges[i].ID = fmt.Sprintf("edge-%d", i+1)
+			config.Edges[i].ID = nextEdgeID(i+1, edgeIDs)
 		}
 		if e.From == "" || e.To == "" {
 			return nil, fmt.Errorf("all edges must have a from and a to")
 		}
-		if e.From == e.To {
-			return nil, fmt.Errorf("edges cannot have the same from and to")
+		if err := validateArrow(&config.Edges[i]); err != nil {
+			return nil, err
 		}
 
 		if config.Nodes.ByID(e.From) == nil || config.Nodes.ByID(e.To) == nil {
 			return nil, fmt.Errorf("all edges must have a from and a to that are valid node ids")
 		}
+		if err := validatePortRef(e.FromPort, config.Nodes.ByID(e.From)); err != nil {
+			return nil, err
+		}
+		if err := validatePortRef(e.ToPort, config.Nodes.ByID(e.To)); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := validateGroups(&config); err != nil {
+		return nil, err
 	}
 
 	return &config, nil
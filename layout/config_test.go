@@ -37,7 +37,8 @@ nodes:
 	require.NoError(t, err)
 	assert.Equal(t, Config{
 		Path: ConfigPath{
-			Attempts: 20,
+			Attempts:    20,
+			BendPenalty: DefaultBendPenalty,
 		},
 		Nodes: ConfigNodes{
 			ConfigNode{
@@ -102,6 +103,38 @@ edges:
 	}, config.Edges[2])
 }
 
+func TestNewConfigFromFile_noBendPenalty(t *testing.T) {
+	r := strings.NewReader(`
+path:
+  bend-penalty: 0
+nodes:
+  - id: node-1
+`)
+
+	config, err := NewConfigFromFile(r)
+	require.NoError(t, err)
+	assert.Equal(t, 0, config.Path.BendPenalty)
+}
+
+func TestNewConfigFromFile_edgeIDsSkipThoseTaken(t *testing.T) {
+	r := strings.NewReader(`
+nodes:
+  - id: a
+  - id: b
+edges:
+  - id: edge-2
+    from: a
+    to: b
+  - from: b
+    to: a
+`)
+
+	config, err := NewConfigFromFile(r)
+	require.NoError(t, err)
+	assert.Equal(t, "edge-2", config.Edges[0].ID)
+	assert.Equal(t, "edge-3", config.Edges[1].ID)
+}
+
 func TestNewConfigFromFile_FailsOnBadYaml(t *testing.T) {
 	r := strings.NewReader(`
 nodes:
@@ -136,6 +169,19 @@ edges:
       to: 00`, "all edges must have a from and a to that are valid node ids")
 	})
 
+	t.Run("Edge IDs are unique", func(t *testing.T) {
+		check(t, `nodes:
+  - id: a
+  - id: b
+edges:
+  - id: request
+    from: a
+    to: b
+  - id: request
+    from: b
+    to: a`, "edge id request is used more than once")
+	})
+
 	t.Run("Non-number for layout attempts", func(t *testing.T) {
 		check(t, `nodes:
   - id: a
@@ -164,20 +210,22 @@ edges:
       to: c`, "all nodes must have an id")
 	})
 
-	t.Run("Edges cannot have same from and to", func(t *testing.T) {
+	t.Run("Nodes cannot have a negative size", func(t *testing.T) {
 		check(t, `nodes:
-    - id: a
-    - id: b
-    - id: c
-    - id: d
-    - id: e
-edges:
-  - from: a
-    to: b
-  - from: a
-    to: a
-  - from: b
-    to: c`, "edges cannot have the same from and to")
+  - id: a
+    height: -1`, "node a cannot have a negative width or height")
+	})
+
+	t.Run("Unknown diagram size", func(t *testing.T) {
+		check(t, `size: huge
+nodes:
+  - id: a`, "invalid node size: huge")
+	})
+
+	t.Run("Unknown node size", func(t *testing.T) {
+		check(t, `nodes:
+  - id: a
+    size: tiny`, "invalid size for node a: tiny")
 	})
 
 	t.Run("Require at least 1 node", func(t *testing.T) {
@@ -194,9 +242,135 @@ edges:
     - id: a`, "cannot specify more that 10000 path attempts")
 	})
 
+	t.Run("Negative bend penalty", func(t *testing.T) {
+		check(t, `path:
+  bend-penalty: -1
+nodes:
+  - id: a`, "bend penalty cannot be negative")
+	})
+
 	t.Run("Margin too big", func(t *testing.T) {
 		check(t, `margin: 20
 nodes:
   - id: a`, "margin cannot be larger than 10")
 	})
+
+	t.Run("Port on an unknown side", func(t *testing.T) {
+		check(t, `nodes:
+  - id: a
+    ports:
+      - name: in
+        side: up
+        offset: 1`, "invalid side for port in on node a: up")
+	})
+
+	t.Run("Port without an offset", func(t *testing.T) {
+		check(t, `nodes:
+  - id: a
+    ports:
+      - name: in
+        side: west`, "port in on node a must have an offset of at least 1")
+	})
+
+	t.Run("Port named after a side", func(t *testing.T) {
+		check(t, `nodes:
+  - id: a
+    ports:
+      - name: north
+        side: west
+        offset: 1`, "port north on node a cannot be named after a side")
+	})
+
+	t.Run("Duplicate port names", func(t *testing.T) {
+		check(t, `nodes:
+  - id: a
+    ports:
+      - name: in
+        side: west
+        offset: 1
+      - name: in
+        side: east
+        offset: 1`, "node a has more than 1 port called in")
+	})
+
+	t.Run("Group without an id", func(t *testing.T) {
+		check(t, `nodes:
+  - id: a
+groups:
+  - nodes: [a]`, "all groups must have an id")
+	})
+
+	t.Run("Groups with the same id", func(t *testing.T) {
+		check(t, `nodes:
+  - id: a
+  - id: b
+groups:
+  - id: g
+    nodes: [a]
+  - id: g
+    nodes: [b]`, "group id g is already used")
+	})
+
+	t.Run("Empty group", func(t *testing.T) {
+		check(t, `nodes:
+  - id: a
+groups:
+  - id: g`, "group g must contain at least 1 node")
+	})
+
+	t.Run("Group with an unknown node", func(t *testing.T) {
+		check(t, `nodes:
+  - id: a
+groups:
+  - id: g
+    nodes: [b]`, "group g contains unknown node b")
+	})
+
+	t.Run("Node in more than 1 group", func(t *testing.T) {
+		check(t, `nodes:
+  - id: a
+groups:
+  - id: g
+    nodes: [a]
+  - id: h
+    nodes: [a]`, "node a cannot be in groups g and h")
+	})
+
+	t.Run("Edge uses an unknown port", func(t *testing.T) {
+		check(t, `nodes:
+  - id: a
+  - id: b
+    ports:
+      - name: in
+        side: west
+        offset: 1
+edges:
+  - from: a
+    to: b
+    to-port: out`, "node b does not have a port called out")
+	})
+}
+
+func TestNewConfigFromFile_ports(t *testing.T) {
+	r := strings.NewReader(`
+nodes:
+  - id: a
+  - id: b
+    ports:
+      - name: in
+        side: west
+        offset: 2
+edges:
+  - from: a
+    to: b
+    from-port: south
+    to-port: in
+`)
+
+	config, err := NewConfigFromFile(r)
+	require.NoError(t, err)
+
+	assert.Equal(t, ConfigPorts{{Name: "in", Side: PortWest, Offset: 2}}, config.Nodes[1].Ports)
+	assert.Equal(t, PortSouth, config.Edges[0].FromPort)
+	assert.Equal(t, "in", config.Edges[0].ToPort)
 }
@@ -0,0 +1,191 @@
+package layout
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/dnnrly/layli/pathfinder/dijkstra"
+)
+
+// crossingCost is added to the cost of a path each time it crosses a path
+// that has already been routed. It is high enough that any reasonable detour
+// is preferred over crossing another path.
+const crossingCost = 100000
+
+// Crossing is a point where the drawn parts of 2 paths meet
+type Crossing struct {
+	A  string
+	B  string
+	At Point
+}
+
+func (c Crossing) String() string {
+	return fmt.Sprintf("edges %s and %s cross at %.0f,%.0f", c.A, c.B, c.At.X, c.At.Y)
+}
+
+// Crossings is every place where paths cross in a layout
+type Crossings []Crossing
+
+// Directions that a path passes through a grid point in, combined as a bit
+// mask. Ports and corners are fixed as they cannot be crossed cleanly.
+const (
+	passHorizontal = 1 << iota
+	passVertical
+	passFixed
+)
+
+// cells returns every grid point that the drawn part of the path passes
+// through, in order. The centres of the nodes at either end are not drawn.
+func (p *LayoutPath) cells() Points {
+	cells := Points{}
+	for i := 1; i < len(p.Points)-2; i++ {
+		start, end := p.Points[i], p.Points[i+1]
+		dx, dy := sign(end.X-start.X), sign(end.Y-start.Y)
+
+		if len(cells) == 0 {
+			cells = append(cells, start)
+		}
+		for at := start; at != end; {
+			if at.X != end.X {
+				at.X += dx
+			}
+			if at.Y != end.Y {
+				at.Y += dy
+			}
+			cells = append(cells, at)
+		}
+	}
+	return cells
+}
+
+// passes returns how the path passes through each of the grid points that
+// it is drawn over
+func (p *LayoutPath) passes() map[Point]int {
+	passes := map[Point]int{}
+	cells := p.cells()
+	for i, c := range cells {
+		if i == 0 || i == len(cells)-1 {
+			passes[c] |= passFixed
+			continue
+		}
+
+		before, after := cells[i-1], cells[i+1]
+		switch {
+		case before.Y == c.Y && after.Y == c.Y:
+			passes[c] |= passHorizontal
+		case before.X == c.X && after.X == c.X:
+			passes[c] |= passVertical
+		default:
+			passes[c] |= passFixed
+		}
+	}
+	return passes
+}
+
+// Crossings finds every grid point where the drawn parts of 2 different
+// paths meet. Paths that leave or enter a node through the same port are
+// allowed to sit on top of each other there, so that isn't counted.
+func (paths LayoutPaths) Crossings() Crossings {
+	crossings := Crossings{}
+	cells := make([]Points, len(paths))
+	for i := range paths {
+		cells[i] = paths[i].cells()
+	}
+
+	for i := range paths {
+		for j := i + 1; j < len(paths); j++ {
+			shared := sharedPorts(cells[i], cells[j])
+			seen := map[Point]bool{}
+			for _, c := range cells[j] {
+				seen[c] = true
+			}
+			for _, c := range cells[i] {
+				if seen[c] && !shared[c] {
+					crossings = append(crossings, Crossing{A: paths[i].ID, B: paths[j].ID, At: c})
+					delete(seen, c)
+				}
+			}
+		}
+	}
+
+	return crossings
+}
+
+// sharedPorts returns the ends of 2 paths that are in the same place
+func sharedPorts(a, b Points) map[Point]bool {
+	shared := map[Point]bool{}
+	if len(a) == 0 || len(b) == 0 {
+		return shared
+	}
+	for _, pa := range []Point{a[0], a[len(a)-1]} {
+		for _, pb := range []Point{b[0], b[len(b)-1]} {
+			if pa == pb {
+				shared[pa] = true
+			}
+		}
+	}
+	return shared
+}
+
+// crossingArc connects 2 free points either side of one or more paths that
+// are crossed at right angles
+type crossingArc struct {
+	From    Point
+	To      Point
+	Crosses int
+}
+
+// crossingArcs returns the arcs that let a new path jump straight over the
+// paths that have already been routed. These are expensive so that a path
+// only crosses another when there is no other way to reach its destination.
+func (l *Layout) crossingArcs(vm VertexMap) []crossingArc {
+	passes := map[Point]int{}
+	for i := range l.Paths {
+		for c, p := range l.Paths[i].passes() {
+			passes[c] |= p
+		}
+	}
+
+	free := func(p Point) bool {
+		x, y := int(p.X), int(p.Y)
+		return x >= 0 && y >= 0 && x < vm.width && y < vm.height && vm.Get(x, y)
+	}
+
+	arcs := []crossingArc{}
+	for _, from := range vm.GetVertexPoints() {
+		for _, dir := range []struct {
+			dx, dy  float64
+			crosses int
+		}{
+			{dx: 1, crosses: passVertical},
+			{dy: 1, crosses: passHorizontal},
+		} {
+			count := 0
+			at := Point{X: from.X + dir.dx, Y: from.Y + dir.dy}
+			for passes[at] == dir.crosses {
+				count++
+				at = Point{X: at.X + dir.dx, Y: at.Y + dir.dy}
+			}
+			if count > 0 && free(at) {
+				arcs = append(arcs, crossingArc{From: from, To: at, Crosses: count})
+			}
+		}
+	}
+
+	return arcs
+}
+
+// cost returns the cost of travelling along the arc, including the penalty
+// for each path that it crosses
+func (a crossingArc) cost() dijkstra.CostFunction {
+	return func(from, to dijkstra.Point) int64 {
+		return PythagoreanDistance(from, to) + int64(a.Crosses)*crossingCost
+	}
+}
+
+func sign(v float64) float64 {
+	if v == 0 {
+		return 0
+	}
+	return math.Copysign(1, v)
+}
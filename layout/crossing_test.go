@@ -0,0 +1,120 @@
+package layout
+
+import (
+	"testing"
+
+	"github.com/dnnrly/layli/pathfinder/dijkstra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLayoutPaths_Crossings(t *testing.T) {
+	t.Run("finds paths crossing at right angles", func(t *testing.T) {
+		paths := LayoutPaths{
+			{ID: "across", Points: Points{{X: 0, Y: 5}, {X: 1, Y: 5}, {X: 9, Y: 5}, {X: 10, Y: 5}}},
+			{ID: "down", Points: Points{{X: 4, Y: 0}, {X: 4, Y: 1}, {X: 4, Y: 9}, {X: 4, Y: 10}}},
+		}
+
+		assert.Equal(t, Crossings{{A: "across", B: "down", At: Point{X: 4, Y: 5}}}, paths.Crossings())
+	})
+
+	t.Run("finds paths that touch", func(t *testing.T) {
+		paths := LayoutPaths{
+			{ID: "1", Points: Points{{X: 0, Y: 5}, {X: 1, Y: 5}, {X: 4, Y: 5}, {X: 4, Y: 9}, {X: 5, Y: 10}}},
+			{ID: "2", Points: Points{{X: 8, Y: 0}, {X: 8, Y: 1}, {X: 8, Y: 5}, {X: 5, Y: 5}, {X: 4, Y: 5}, {X: 3, Y: 6}}},
+		}
+
+		crossings := paths.Crossings()
+		require.Len(t, crossings, 1)
+		assert.Equal(t, Point{X: 4, Y: 5}, crossings[0].At)
+	})
+
+	t.Run("ignores paths that do not meet", func(t *testing.T) {
+		paths := LayoutPaths{
+			{ID: "1", Points: Points{{X: 0, Y: 5}, {X: 1, Y: 5}, {X: 9, Y: 5}, {X: 10, Y: 5}}},
+			{ID: "2", Points: Points{{X: 0, Y: 7}, {X: 1, Y: 7}, {X: 9, Y: 7}, {X: 10, Y: 7}}},
+		}
+
+		assert.Empty(t, paths.Crossings())
+	})
+
+	t.Run("ignores paths sharing a port", func(t *testing.T) {
+		paths := LayoutPaths{
+			{ID: "1", Points: Points{{X: 0, Y: 5}, {X: 1, Y: 5}, {X: 9, Y: 5}, {X: 10, Y: 5}}},
+			{ID: "2", Points: Points{{X: 0, Y: 5}, {X: 1, Y: 5}, {X: 1, Y: 9}, {X: 1, Y: 10}}},
+		}
+
+		assert.Empty(t, paths.Crossings())
+	})
+
+	t.Run("ignores the centres of nodes", func(t *testing.T) {
+		paths := LayoutPaths{
+			{ID: "1", Points: Points{{X: 0, Y: 5}, {X: 2, Y: 5}, {X: 9, Y: 5}, {X: 10, Y: 5}}},
+			{ID: "2", Points: Points{{X: 0, Y: 5}, {X: 0, Y: 7}, {X: 9, Y: 7}, {X: 10, Y: 7}}},
+		}
+
+		assert.Empty(t, paths.Crossings())
+	})
+}
+
+func TestCrossing_String(t *testing.T) {
+	assert.Equal(t, "edges e1 and e2 cross at 4,5", Crossing{A: "e1", B: "e2", At: Point{X: 4, Y: 5}}.String())
+}
+
+// wallLayout returns a layout with a path running from the top to the bottom
+// of the grid between 2 nodes
+func wallLayout(bottom float64) *Layout {
+	return &Layout{
+		Nodes: LayoutNodes{
+			NewLayoutNode("a", "", 2, 2, 3, 3, "", ""),
+			NewLayoutNode("b", "", 10, 2, 3, 3, "", ""),
+		},
+		Paths: LayoutPaths{
+			{ID: "wall", Points: Points{{X: 7, Y: 0}, {X: 7, Y: 1}, {X: 7, Y: bottom}, {X: 7, Y: bottom + 1}}},
+		},
+		CreateFinder: func(start, end dijkstra.Point) PathFinder {
+			return dijkstra.NewPathFinder(start, end)
+		},
+		nodeMargin:   1,
+		layoutBorder: 1,
+	}
+}
+
+func TestLayout_FindPath_crossesWhenThereIsNoOtherWay(t *testing.T) {
+	l := wallLayout(5)
+
+	path, err := l.FindPath("a", "b")
+	require.NoError(t, err)
+	path.ID = "a-b"
+
+	l.Paths = append(l.Paths, *path)
+	crossings := l.Paths.Crossings()
+	require.Len(t, crossings, 1)
+	assert.Equal(t, "wall", crossings[0].A)
+	assert.Equal(t, "a-b", crossings[0].B)
+	assert.Equal(t, 7.0, crossings[0].At.X)
+}
+
+func TestLayout_FindPath_goesAroundRatherThanCrossing(t *testing.T) {
+	l := wallLayout(4)
+
+	path, err := l.FindPath("a", "b")
+	require.NoError(t, err)
+	path.ID = "a-b"
+
+	l.Paths = append(l.Paths, *path)
+	assert.Empty(t, l.Paths.Crossings())
+	assert.Contains(t, path.cells(), Point{X: 7, Y: 5}, "goes underneath the wall")
+}
+
+func TestLayout_crossingArcs(t *testing.T) {
+	l := wallLayout(5)
+	vm := BuildVertexMap(l)
+
+	arcs := l.crossingArcs(vm)
+	assert.ElementsMatch(t, []crossingArc{
+		{From: Point{X: 6, Y: 2}, To: Point{X: 8, Y: 2}, Crosses: 1},
+		{From: Point{X: 6, Y: 3}, To: Point{X: 8, Y: 3}, Crosses: 1},
+		{From: Point{X: 6, Y: 4}, To: Point{X: 8, Y: 4}, Crosses: 1},
+	}, arcs, "cannot cross the ends of the path")
+}
@@ -31,19 +31,19 @@ func (d *Diagram) Draw() error {
 		fmt.Sprintf(`data-border="%d"`, d.Config.Border),
 		fmt.Sprintf(`data-node-width="%d"`, d.Config.NodeWidth),
 		fmt.Sprintf(`data-node-height="%d"`, d.Config.NodeHeight),
+		fmt.Sprintf(`data-seed="%d"`, d.Config.Seed),
 	)
 	if len(d.Config.Styles) != 0 {
 		canvas.Style("text/css", d.Config.Styles.toCSS())
 	}
 	canvas.Gstyle("text-anchor:middle;font-family:sans;fill:none;stroke:black")
-	canvas.Def()
-	canvas.Marker("arrow", 10, 5, 7, 7,
-		`viewBox="0 0 10 10"`,
-		`fill="black"`,
-		`orient="auto-start-reverse"`)
-	canvas.Path("M 0 0 L 10 5 L 0 10 z")
-	canvas.MarkerEnd()
-	canvas.DefEnd()
+	if heads := d.Layout.Paths.arrowHeads(d.Config.Styles); len(heads) != 0 {
+		canvas.Def()
+		for _, h := range heads {
+			h.draw(canvas)
+		}
+		canvas.DefEnd()
+	}
 
 	if d.ShowGrid {
 		d.Layout.ShowGrid(canvas, d.Config.Spacing)
@@ -104,8 +104,15 @@ func AbsoluteFromSVG(svg string, output OutputFunc) error {
 	if err != nil {
 		return fmt.Errorf("parsing margin: %w", err)
 	}
+	if seed := root.SelectAttr("data-seed"); seed != "" {
+		config.Seed, err = strconv.ParseInt(seed, 10, 64)
+		if err != nil {
+			return fmt.Errorf("parsing seed: %w", err)
+		}
+	}
 
-	for _, n := range xmlquery.Find(dom, "//rect") {
+	// Nodes can be drawn as any shape, but they always say where they are
+	for _, n := range xmlquery.Find(dom, "//*[@data-pos-x]") {
 		id := n.SelectAttr("id")
 		x, err := strconv.Atoi(n.SelectAttr("data-pos-x"))
 		if err != nil {
@@ -116,28 +123,52 @@ func AbsoluteFromSVG(svg string, output OutputFunc) error {
 			return fmt.Errorf("parsing Y: %w", err)
 		}
 
-		text := xmlquery.FindOne(dom, "//*[@id='"+id+"-text']")
+		width, err := blankParse(n.SelectAttr("data-width"))
+		if err != nil {
+			return fmt.Errorf("parsing width: %w", err)
+		}
+		height, err := blankParse(n.SelectAttr("data-height"))
+		if err != nil {
+			return fmt.Errorf("parsing height: %w", err)
+		}
+
+		text := findByID(dom, id+"-text")
 		if text == nil {
 			return fmt.Errorf("no text found for node %s", id)
 		}
 
 		config.Nodes = append(config.Nodes, ConfigNode{
 			Id:       id,
-			Contents: text.InnerText(),
+			Contents: textContents(text),
 			Position: Position{X: x, Y: y},
+			Width:    sizeOverride(width, config.NodeWidth),
+			Height:   sizeOverride(height, config.NodeHeight),
 			Class:    n.SelectAttr("class"),
 			Style:    n.SelectAttr("style"),
+			Shape:    n.SelectAttr("data-shape"),
 		})
 	}
 
 	for _, e := range xmlquery.Find(dom, "//g/path") {
+		label := ""
+		if id := e.SelectAttr("id"); id != "" {
+			if text := findByID(dom, id+"-label"); text != nil {
+				label = text.InnerText()
+			}
+		}
+
 		config.Edges = append(config.Edges, ConfigEdge{
 			From:  e.SelectAttr("data-from"),
 			To:    e.SelectAttr("data-to"),
+			Label: label,
 			Class: strings.Trim(strings.ReplaceAll(e.SelectAttr("class"), "path-line", ""), " "),
+			Arrow: e.SelectAttr("data-arrow"),
+			Head:  e.SelectAttr("data-head"),
 		})
 	}
 
+	config.Groups = groupsFromSVG(xmlquery.Find(dom, "//rect[@data-nodes]"))
+
 	config.Styles = ConfigStyles{}
 	styleData := xmlquery.FindOne(dom, "//style")
 	if styleData != nil {
@@ -156,3 +187,78 @@ func AbsoluteFromSVG(svg string, output OutputFunc) error {
 
 	return output(config.String())
 }
+
+// groupsFromSVG puts the groups back together, nesting each group inside the
+// group that lists it
+func groupsFromSVG(rects []*xmlquery.Node) ConfigGroups {
+	byID := map[string]*xmlquery.Node{}
+	nested := map[string]bool{}
+	for _, r := range rects {
+		byID[r.SelectAttr("id")] = r
+		for _, child := range strings.Fields(r.SelectAttr("data-groups")) {
+			nested[child] = true
+		}
+	}
+
+	var build func(r *xmlquery.Node) ConfigGroup
+	build = func(r *xmlquery.Node) ConfigGroup {
+		g := ConfigGroup{
+			Id:    r.SelectAttr("id"),
+			Label: r.SelectAttr("data-label"),
+			Nodes: strings.Fields(r.SelectAttr("data-nodes")),
+		}
+		for _, child := range strings.Fields(r.SelectAttr("data-groups")) {
+			if c, found := byID[child]; found {
+				g.Groups = append(g.Groups, build(c))
+			}
+		}
+		return g
+	}
+
+	var groups ConfigGroups
+	for _, r := range rects {
+		if !nested[r.SelectAttr("id")] {
+			groups = append(groups, build(r))
+		}
+	}
+	return groups
+}
+
+// findByID returns the element with the given id. The ids come from the
+// diagram, so they are compared directly rather than put in to an XPath
+// query where quotes would break it.
+func findByID(dom *xmlquery.Node, id string) *xmlquery.Node {
+	for _, n := range xmlquery.Find(dom, "//*[@id]") {
+		if n.SelectAttr("id") == id {
+			return n
+		}
+	}
+	return nil
+}
+
+// textContents returns the contents of a node's text, putting back the line
+// breaks between each of the lines that it was drawn as
+func textContents(text *xmlquery.Node) string {
+	spans := xmlquery.Find(text, "tspan")
+	if len(spans) == 0 {
+		return text.InnerText()
+	}
+
+	contents := ""
+	for i, s := range spans {
+		if i > 0 && s.SelectAttr("data-wrapped") != "true" {
+			contents += "\n"
+		}
+		contents += s.InnerText()
+	}
+	return contents
+}
+
+// sizeOverride returns the size of a node if it is different to the diagram
+// wide default, or 0 if the node should just use the default
+func sizeOverride(size, def int) int {
+	if def == 0 || size == def {
+		return 0
+	}
+	return size
+}
@@ -0,0 +1,394 @@
+package layout
+
+import (
+	"fmt"
+	"html"
+	"math"
+	"strings"
+)
+
+// groupTitleRows is the number of rows at the top of a group that are kept
+// for its label
+const groupTitleRows = 1
+
+// ConfigGroup draws a labelled boundary around related nodes. Groups can
+// contain other groups as well as nodes.
+type ConfigGroup struct {
+	Id     string       `yaml:"id"`
+	Label  string       `yaml:"label,omitempty"`
+	Nodes  []string     `yaml:"nodes,omitempty"`
+	Groups ConfigGroups `yaml:"groups,omitempty"`
+}
+
+type ConfigGroups []ConfigGroup
+
+// members returns the IDs of every node in the group, including the nodes in
+// any groups nested inside it
+func (g *ConfigGroup) members() []string {
+	members := append([]string{}, g.Nodes...)
+	for i := range g.Groups {
+		members = append(members, g.Groups[i].members()...)
+	}
+	return members
+}
+
+// ByID returns the group with the given ID, or nil if there isn't one. Only
+// the top level of groups is searched.
+func (groups ConfigGroups) ByID(id string) *ConfigGroup {
+	for _, g := range groups {
+		if g.Id == id {
+			return &g
+		}
+	}
+	return nil
+}
+
+// validateGroups checks that every group has a unique ID and that each node
+// is in at most 1 group
+func validateGroups(config *Config) error {
+	used := map[string]bool{}
+	for _, n := range config.Nodes {
+		used[n.Id] = true
+	}
+	grouped := map[string]string{}
+
+	var check func(groups ConfigGroups) error
+	check = func(groups ConfigGroups) error {
+		for _, g := range groups {
+			if g.Id == "" {
+				return fmt.Errorf("all groups must have an id")
+			}
+			if used[g.Id] {
+				return fmt.Errorf("group id %s is already used", g.Id)
+			}
+			used[g.Id] = true
+
+			if len(g.Nodes) == 0 && len(g.Groups) == 0 {
+				return fmt.Errorf("group %s must contain at least 1 node", g.Id)
+			}
+			for _, id := range g.Nodes {
+				if config.Nodes.ByID(id) == nil {
+					return fmt.Errorf("group %s contains unknown node %s", g.Id, id)
+				}
+				if other, found := grouped[id]; found {
+					return fmt.Errorf("node %s cannot be in groups %s and %s", id, other, g.Id)
+				}
+				grouped[id] = g.Id
+			}
+
+			if err := check(g.Groups); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	return check(config.Groups)
+}
+
+// arrangeGroups arranges the nodes so that the members of each group are kept
+// together. Each group is arranged on its own first and then takes the place
+// of all of its members as a single node when the rest of the diagram is
+// arranged.
+func arrangeGroups(c *Config, arrange LayoutArrangementFunc) (LayoutNodes, error) {
+	if len(c.Groups) == 0 {
+		return arrange(c)
+	}
+
+	owner := map[string]string{}
+	for _, g := range c.Groups {
+		for _, id := range g.members() {
+			owner[id] = g.Id
+		}
+	}
+
+	reduced := *c
+	reduced.Nodes = ConfigNodes{}
+	reduced.Edges = ConfigEdges{}
+	reduced.Groups = nil
+
+	// Each group is put in the position of its first member so that the
+	// order of the nodes is kept as much as possible
+	inner := map[string]LayoutNodes{}
+	for _, n := range c.Nodes {
+		id := owner[n.Id]
+		if id == "" {
+			reduced.Nodes = append(reduced.Nodes, n)
+			continue
+		}
+		if _, done := inner[id]; done {
+			continue
+		}
+
+		nodes, width, height, err := arrangeGroup(c, c.Groups.ByID(id), arrange)
+		if err != nil {
+			return nil, err
+		}
+		inner[id] = nodes
+		reduced.Nodes = append(reduced.Nodes, ConfigNode{
+			Id:     id,
+			Width:  width,
+			Height: height,
+			Sizing: SizingFixed,
+		})
+	}
+
+	for _, e := range c.Edges {
+		from, to := e.From, e.To
+		if owner[from] != "" {
+			from = owner[from]
+		}
+		if owner[to] != "" {
+			to = owner[to]
+		}
+		if from != to {
+			e.From, e.To = from, to
+			reduced.Edges = append(reduced.Edges, e)
+		}
+	}
+
+	arranged, err := arrange(&reduced)
+	if err != nil {
+		return nil, err
+	}
+
+	nodes := LayoutNodes{}
+	for _, n := range arranged {
+		members, isGroup := inner[n.Id]
+		if !isGroup {
+			nodes = append(nodes, n)
+			continue
+		}
+		for _, m := range members {
+			nodes = append(nodes, m.moved(n.left, n.top))
+		}
+	}
+
+	return nodes, nil
+}
+
+// arrangeGroup arranges the members of a group on their own, with the top
+// left corner of the group at 0,0, and returns the size of the group. Layouts
+// that leave out some of the members fall back to a flow square so that none
+// of them go missing.
+func arrangeGroup(c *Config, g *ConfigGroup, arrange LayoutArrangementFunc) (LayoutNodes, int, int, error) {
+	members := map[string]bool{}
+	for _, id := range g.members() {
+		members[id] = true
+	}
+
+	sub := *c
+	sub.Border = 0
+	sub.Groups = g.Groups
+	sub.Nodes = ConfigNodes{}
+	sub.Edges = ConfigEdges{}
+	for _, n := range c.Nodes {
+		if members[n.Id] {
+			sub.Nodes = append(sub.Nodes, n)
+		}
+	}
+	for _, e := range c.Edges {
+		if members[e.From] && members[e.To] {
+			sub.Edges = append(sub.Edges, e)
+		}
+	}
+
+	nodes, err := arrangeGroups(&sub, arrange)
+	if err != nil {
+		return nil, 0, 0, fmt.Errorf("arranging group %s: %w", g.Id, err)
+	}
+	if len(nodes) != len(members) {
+		nodes, err = arrangeGroups(&sub, flowSquare)
+		if err != nil {
+			return nil, 0, 0, fmt.Errorf("arranging group %s: %w", g.Id, err)
+		}
+	}
+
+	box := NewLayoutGroups(ConfigGroups{*g}, nodes, c.Margin, c.Spacing)[0]
+	for i := range nodes {
+		nodes[i] = nodes[i].moved(-box.left, -box.top)
+	}
+
+	return nodes, box.right - box.left + 1, box.bottom - box.top + 1, nil
+}
+
+// moved returns a copy of the node moved by dx and dy path units
+func (n LayoutNode) moved(dx, dy int) LayoutNode {
+	n.left += dx
+	n.right += dx
+	n.top += dy
+	n.bottom += dy
+	return n
+}
+
+// LayoutGroup is a group that has been placed around its members. The edges
+// of the group are the outermost grid points inside it, the boundary is drawn
+// half way between the grid points so that paths can cross it but never run
+// along it.
+type LayoutGroup struct {
+	Id     string
+	Label  string
+	Nodes  []string
+	Groups []string
+
+	top    int
+	bottom int
+	left   int
+	right  int
+
+	// titleWidth is the number of columns at the top left of the group that
+	// are covered by the label
+	titleWidth int
+
+	// members is every node and group nested inside this one
+	members map[string]bool
+}
+
+type LayoutGroups []LayoutGroup
+
+// NewLayoutGroups places each group around its members, leaving a margin
+// around them and space at the top for the label. Groups come before any
+// groups nested inside them. Groups without any arranged members are left
+// out.
+func NewLayoutGroups(groups ConfigGroups, nodes LayoutNodes, margin, spacing int) LayoutGroups {
+	if spacing == 0 {
+		spacing = defaultSpacing
+	}
+
+	all := LayoutGroups{}
+	for i := range groups {
+		all = append(all, newLayoutGroup(&groups[i], nodes, margin, spacing)...)
+	}
+	return all
+}
+
+func newLayoutGroup(g *ConfigGroup, nodes LayoutNodes, margin, spacing int) LayoutGroups {
+	lg := LayoutGroup{
+		Id:      g.Id,
+		Label:   g.Label,
+		Nodes:   g.Nodes,
+		top:     math.MaxInt,
+		left:    math.MaxInt,
+		bottom:  math.MinInt,
+		right:   math.MinInt,
+		members: map[string]bool{},
+	}
+
+	extend := func(left, top, right, bottom int) {
+		lg.left = min(lg.left, left)
+		lg.top = min(lg.top, top)
+		lg.right = max(lg.right, right)
+		lg.bottom = max(lg.bottom, bottom)
+	}
+
+	nested := LayoutGroups{}
+	for i := range g.Groups {
+		children := newLayoutGroup(&g.Groups[i], nodes, margin, spacing)
+		if len(children) == 0 {
+			continue
+		}
+		child := children[0]
+		extend(child.left, child.top, child.right, child.bottom)
+		lg.Groups = append(lg.Groups, child.Id)
+		lg.members[child.Id] = true
+		for id := range child.members {
+			lg.members[id] = true
+		}
+		nested = append(nested, children...)
+	}
+
+	for _, id := range g.Nodes {
+		if n := nodes.ByID(id); n != nil {
+			extend(n.left, n.top, n.right, n.bottom)
+			lg.members[id] = true
+		}
+	}
+
+	if len(lg.members) == 0 {
+		return nil
+	}
+
+	lg.left -= margin
+	lg.right += margin
+	lg.top -= margin + groupTitleRows
+	lg.bottom += margin
+
+	if lg.Label != "" {
+		lg.titleWidth = int(math.Ceil((textWidth(lg.Label) + 2*nodeTextPadding) / float64(spacing)))
+		lg.titleWidth = min(lg.titleWidth, lg.right-lg.left+1)
+	}
+
+	return append(LayoutGroups{lg}, nested...)
+}
+
+// Left returns the left edge of the group in grid units.
+func (g *LayoutGroup) Left() int { return g.left }
+
+// Top returns the top edge of the group in grid units.
+func (g *LayoutGroup) Top() int { return g.top }
+
+// Right returns the right edge of the group in grid units.
+func (g *LayoutGroup) Right() int { return g.right }
+
+// Bottom returns the bottom edge of the group in grid units.
+func (g *LayoutGroup) Bottom() int { return g.bottom }
+
+// IsTitle returns true if the grid point is covered by the group's label
+func (g *LayoutGroup) IsTitle(x, y int) bool {
+	return y >= g.top && y < g.top+groupTitleRows &&
+		x >= g.left && x < g.left+g.titleWidth
+}
+
+// overlaps returns true if the box overlaps any part of the group
+func (g *LayoutGroup) overlaps(left, top, right, bottom int) bool {
+	return !(right < g.left || left > g.right || bottom < g.top || top > g.bottom)
+}
+
+// validate checks that groups that have been placed by hand don't overlap the
+// border or anything that isn't inside them
+func (groups LayoutGroups) validate(nodes LayoutNodes, border int) error {
+	for _, g := range groups {
+		if g.left < border || g.top < border {
+			return fmt.Errorf("group %s overlaps border", g.Id)
+		}
+		for _, n := range nodes {
+			if !g.members[n.Id] && g.overlaps(n.left, n.top, n.right, n.bottom) {
+				return fmt.Errorf("node %s overlaps group %s", n.Id, g.Id)
+			}
+		}
+		for _, other := range groups {
+			if other.Id == g.Id || g.members[other.Id] || other.members[g.Id] {
+				continue
+			}
+			if g.overlaps(other.left, other.top, other.right, other.bottom) {
+				return fmt.Errorf("groups %s and %s overlap", g.Id, other.Id)
+			}
+		}
+	}
+	return nil
+}
+
+func (g *LayoutGroup) Draw(d LayoutDrawer, spacing int) {
+	d.Roundrect(
+		g.left*spacing-spacing/2, g.top*spacing-spacing/2,
+		(g.right-g.left+1)*spacing, (g.bottom-g.top+1)*spacing,
+		6, 6,
+		fmt.Sprintf(`id="%s"`, g.Id),
+		`class="group"`,
+		`stroke-dasharray="6 3"`,
+		fmt.Sprintf(`data-label="%s"`, html.EscapeString(g.Label)),
+		fmt.Sprintf(`data-nodes="%s"`, strings.Join(g.Nodes, " ")),
+		fmt.Sprintf(`data-groups="%s"`, strings.Join(g.Groups, " ")),
+	)
+
+	if g.Label != "" {
+		d.Textspan(
+			g.left*spacing-spacing/2+nodeTextPadding, g.top*spacing+nodeFontSize/2,
+			g.Label,
+			fmt.Sprintf(`id="%s-title"`, g.Id),
+			`class="group-label"`,
+			fmt.Sprintf("font-size:%dpx;text-anchor:start", nodeFontSize),
+		)
+		d.TextEnd()
+	}
+}
@@ -0,0 +1,238 @@
+package layout
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/dnnrly/layli/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+func groupTestConfig() *Config {
+	return &Config{
+		Nodes: ConfigNodes{
+			ConfigNode{Id: "web"},
+			ConfigNode{Id: "api"},
+			ConfigNode{Id: "ledger", Width: 7},
+			ConfigNode{Id: "db"},
+			ConfigNode{Id: "email"},
+		},
+		Edges: ConfigEdges{
+			ConfigEdge{From: "web", To: "api"},
+			ConfigEdge{From: "api", To: "ledger"},
+			ConfigEdge{From: "ledger", To: "db"},
+			ConfigEdge{From: "api", To: "email"},
+		},
+		Groups: ConfigGroups{
+			{
+				Id:    "payments",
+				Label: "Payments",
+				Nodes: []string{"api", "ledger"},
+				Groups: ConfigGroups{
+					{Id: "storage", Label: "Storage", Nodes: []string{"db"}},
+				},
+			},
+		},
+		Spacing:        20,
+		NodeWidth:      5,
+		NodeHeight:     3,
+		Border:         1,
+		Margin:         2,
+		LayoutAttempts: 3,
+	}
+}
+
+func TestArrangementsKeepGroupsTogether(t *testing.T) {
+	arrangements := map[string]LayoutArrangementFunc{
+		"flow-square":            LayoutFlowSquare,
+		"topo-sort":              LayoutTopologicalSort,
+		"tarjan":                 LayoutTarjan,
+		"random-shortest-square": LayoutRandomShortestSquare,
+		"layered":                LayoutLayered,
+	}
+
+	for name, f := range arrangements {
+		t.Run(name, func(t *testing.T) {
+			c := groupTestConfig()
+			nodes, err := f(c)
+			require.NoError(t, err)
+			require.Len(t, nodes, len(c.Nodes))
+
+			for i, n1 := range nodes {
+				for j, n2 := range nodes {
+					if i != j {
+						assert.False(t, marginsOverlap(n1, n2, c.Margin), "%s (%s) is too close to %s (%s)", n1.Id, s(n1), n2.Id, s(n2))
+					}
+				}
+			}
+
+			groups := NewLayoutGroups(c.Groups, nodes, c.Margin, c.Spacing)
+			require.Len(t, groups, 2)
+			assert.NoError(t, groups.validate(nodes, c.Border))
+
+			for _, g := range groups {
+				for id := range g.members {
+					if n := nodes.ByID(id); n != nil {
+						assert.LessOrEqual(t, g.left, n.left-c.Margin, "%s is inside %s", id, g.Id)
+						assert.GreaterOrEqual(t, g.right, n.right+c.Margin, "%s is inside %s", id, g.Id)
+						assert.LessOrEqual(t, g.top, n.top-c.Margin-groupTitleRows, "%s is below the title of %s", id, g.Id)
+						assert.GreaterOrEqual(t, g.bottom, n.bottom+c.Margin, "%s is inside %s", id, g.Id)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestAbsoluteArrangement_ErrorsOnGroupOverlaps(t *testing.T) {
+	c := &Config{
+		Layout: "absolute",
+		Nodes: ConfigNodes{
+			ConfigNode{Id: "a", Position: Position{X: 7, Y: 7}},
+			ConfigNode{Id: "b", Position: Position{X: 18, Y: 7}},
+		},
+		Groups: ConfigGroups{
+			{Id: "g", Groups: ConfigGroups{{Id: "h", Nodes: []string{"a"}}}},
+		},
+		NodeWidth:  5,
+		NodeHeight: 3,
+		Margin:     2,
+		Border:     1,
+	}
+
+	_, err := LayoutAbsolute(c)
+	require.NoError(t, err)
+
+	// Clear of the margin around a but not the outer group
+	c.Nodes[1].Position.X = 15
+	_, err = LayoutAbsolute(c)
+	assert.EqualError(t, err, "node b overlaps group g")
+
+	c.Nodes[1].Position.X = 18
+	c.Nodes[0].Position.Y = 6
+	_, err = LayoutAbsolute(c)
+	assert.EqualError(t, err, "group g overlaps border")
+}
+
+func TestNewLayoutGroups(t *testing.T) {
+	nodes := LayoutNodes{
+		NewLayoutNode("a", "", 5, 6, 5, 3, "", ""),
+		NewLayoutNode("b", "", 14, 6, 5, 3, "", ""),
+		NewLayoutNode("c", "", 14, 14, 5, 3, "", ""),
+	}
+
+	groups := NewLayoutGroups(ConfigGroups{
+		{
+			Id:     "outer",
+			Label:  "A long label for the outer group",
+			Nodes:  []string{"a"},
+			Groups: ConfigGroups{{Id: "inner", Nodes: []string{"b"}}},
+		},
+		{Id: "missing", Nodes: []string{"unknown"}},
+	}, nodes, 2, 20)
+
+	require.Len(t, groups, 2, "groups without any members are left out")
+
+	outer, inner := groups[0], groups[1]
+	assert.Equal(t, "outer", outer.Id)
+	assert.Equal(t, []string{"inner"}, outer.Groups)
+	assert.Equal(t, "L3 R22 T0 B12", fmt.Sprintf("L%d R%d T%d B%d", outer.left, outer.right, outer.top, outer.bottom))
+	assert.Equal(t, 8, outer.titleWidth)
+
+	assert.Equal(t, "inner", inner.Id)
+	assert.Equal(t, "L12 R20 T3 B10", fmt.Sprintf("L%d R%d T%d B%d", inner.left, inner.right, inner.top, inner.bottom))
+	assert.Equal(t, 0, inner.titleWidth, "no label, no title")
+
+	assert.True(t, outer.members["a"])
+	assert.True(t, outer.members["b"])
+	assert.True(t, outer.members["inner"])
+	assert.False(t, outer.members["c"])
+}
+
+func TestLayoutGroup_IsTitle(t *testing.T) {
+	g := LayoutGroup{left: 3, right: 12, top: 4, bottom: 10, titleWidth: 4}
+
+	assert.True(t, g.IsTitle(3, 4))
+	assert.True(t, g.IsTitle(6, 4))
+	assert.False(t, g.IsTitle(7, 4), "past the end of the label")
+	assert.False(t, g.IsTitle(3, 5), "below the title")
+	assert.False(t, g.IsTitle(2, 4), "outside the group")
+}
+
+func TestLayout_BuildVertexMapAvoidsGroupTitles(t *testing.T) {
+	l := NewLayout(LayoutNodes{
+		NewLayoutNode("a", "", 4, 5, 3, 3, "", ""),
+	}, nil, 3, 3, 1, 1, 20)
+	l.Groups = NewLayoutGroups(ConfigGroups{{Id: "g", Label: "Group", Nodes: []string{"a"}}}, l.Nodes, 1, 20)
+
+	assert.Equal(t, strings.ReplaceAll(
+		`..........
+		.xxxxxxxx.
+		.xxxxxxxx.
+		.xx..xxxx.
+		.xxxxxxxx.
+		.xxx.x.xx.
+		.xxxx.xxx.
+		.xxx.x.xx.
+		.xxxxxxxx.
+		.xxxxxxxx.
+		..........`, "	", ""), BuildVertexMap(l).String())
+}
+
+func TestLayoutGroup_Draw(t *testing.T) {
+	drawer := mocks.NewLayoutDrawer(t)
+
+	g := LayoutGroup{
+		Id:         "g",
+		Label:      "Payments",
+		Nodes:      []string{"a", "b"},
+		Groups:     []string{"h"},
+		left:       3,
+		right:      12,
+		top:        4,
+		bottom:     10,
+		titleWidth: 4,
+	}
+
+	drawer.On("Roundrect", 50, 70, 200, 140, 6, 6,
+		`id="g"`, `class="group"`, `stroke-dasharray="6 3"`,
+		`data-label="Payments"`, `data-nodes="a b"`, `data-groups="h"`).Once()
+	drawer.On("Textspan", 54, 85, "Payments", `id="g-title"`, `class="group-label"`, "font-size:10px;text-anchor:start").Once()
+	drawer.On("TextEnd").Once()
+
+	g.Draw(drawer, 20)
+}
+
+func TestAbsoluteFromSVG_keepsGroups(t *testing.T) {
+	c := groupTestConfig()
+	l, err := NewLayoutFromConfig(nil, &Config{
+		Nodes:      c.Nodes,
+		Groups:     c.Groups,
+		Spacing:    c.Spacing,
+		NodeWidth:  c.NodeWidth,
+		NodeHeight: c.NodeHeight,
+		Border:     c.Border,
+		Margin:     c.Margin,
+		Path:       ConfigPath{Attempts: 1},
+	})
+	require.NoError(t, err)
+
+	var svg string
+	d := Diagram{
+		Output: func(output string) error { svg = output; return nil },
+		Config: *c,
+		Layout: l,
+	}
+	require.NoError(t, d.Draw())
+
+	var output string
+	require.NoError(t, AbsoluteFromSVG(svg, func(data string) error { output = data; return nil }))
+
+	config := Config{}
+	require.NoError(t, yaml.NewDecoder(strings.NewReader(output)).Decode(&config))
+	assert.Len(t, config.Nodes, len(c.Nodes))
+	assert.Equal(t, c.Groups, config.Groups)
+}
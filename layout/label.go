@@ -0,0 +1,224 @@
+package layout
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"unicode/utf8"
+)
+
+// Approximate metrics of the default label font, in pixels
+const (
+	labelGlyphWidth = 6
+	labelHeight     = 10
+	labelGap        = 3
+)
+
+// LabelPlacement anchors a path label to a point on the path.
+type LabelPlacement struct {
+	At   Point
+	Side string
+}
+
+const (
+	LabelAbove = "above"
+	LabelBelow = "below"
+	LabelLeft  = "left"
+	LabelRight = "right"
+)
+
+type rect struct {
+	left, top, right, bottom int
+}
+
+func (r rect) overlaps(o rect) bool {
+	return r.left < o.right && o.left < r.right &&
+		r.top < o.bottom && o.top < r.bottom
+}
+
+// labelRect returns the pixel area taken up by a label placed next to a point
+func labelRect(text string, at Point, side string, spacing int) rect {
+	w := utf8.RuneCountInString(text) * labelGlyphWidth
+	x := int(at.X) * spacing
+	y := int(at.Y) * spacing
+
+	switch side {
+	case LabelAbove:
+		return rect{x - w/2, y - labelGap - labelHeight, x + w - w/2, y - labelGap}
+	case LabelBelow:
+		return rect{x - w/2, y + labelGap, x + w - w/2, y + labelGap + labelHeight}
+	case LabelLeft:
+		return rect{x - labelGap - w, y - labelHeight/2, x - labelGap, y + labelHeight/2}
+	default:
+		return rect{x + labelGap, y - labelHeight/2, x + labelGap + w, y + labelHeight/2}
+	}
+}
+
+// nodeRect returns the pixel area taken up by a node
+func nodeRect(n LayoutNode, spacing int) rect {
+	return rect{n.left * spacing, n.top * spacing, n.right * spacing, n.bottom * spacing}
+}
+
+type pathSegment struct {
+	from, to Point
+}
+
+func (s pathSegment) length() float64 {
+	return s.from.Distance(s.to)
+}
+
+func (s pathSegment) horizontal() bool {
+	return s.from.Y == s.to.Y
+}
+
+// candidates returns the grid points along the segment, closest to the
+// middle first
+func (s pathSegment) candidates() Points {
+	points := Points{}
+	steps := int(s.length())
+	dx := (s.to.X - s.from.X) / math.Max(1, float64(steps))
+	dy := (s.to.Y - s.from.Y) / math.Max(1, float64(steps))
+	for i := 0; i <= steps; i++ {
+		points = append(points, Point{X: s.from.X + dx*float64(i), Y: s.from.Y + dy*float64(i)})
+	}
+
+	mid := Point{X: (s.from.X + s.to.X) / 2, Y: (s.from.Y + s.to.Y) / 2}
+	sort.SliceStable(points, func(i, j int) bool {
+		return points[i].Distance(mid) < points[j].Distance(mid)
+	})
+
+	return points
+}
+
+// segments returns the straight segments of the drawn part of the path,
+// longest first
+func (p *LayoutPath) segments() []pathSegment {
+	segments := []pathSegment{}
+	for i := 2; i < len(p.Points)-1; i++ {
+		segments = append(segments, pathSegment{from: p.Points[i-1], to: p.Points[i]})
+	}
+
+	sort.SliceStable(segments, func(i, j int) bool {
+		return segments[i].length() > segments[j].length()
+	})
+
+	return segments
+}
+
+// maxLabelOffset is how many grid units away from its path a label can be
+// moved to find room for it
+const maxLabelOffset = 2
+
+// PlaceLabels positions the label of every path that has one. Labels go on
+// the longest straight segment of their path where they do not overlap any
+// node or a label that has already been placed, trying either side of every
+// segment and then moving further away from the path when there is no room.
+// Labels that can't be placed without overlapping are left out, and the IDs
+// of their paths are returned.
+func (paths LayoutPaths) PlaceLabels(nodes LayoutNodes, spacing int) []string {
+	taken := []rect{}
+	for _, n := range nodes {
+		taken = append(taken, nodeRect(n, spacing))
+	}
+
+	free := func(r rect) bool {
+		for _, t := range taken {
+			if r.overlaps(t) {
+				return false
+			}
+		}
+		return true
+	}
+
+	unplaced := []string{}
+	for i := range paths {
+		p := &paths[i]
+		p.LabelPlacement = nil
+		if p.Label == "" {
+			continue
+		}
+
+		segments := p.segments()
+		if len(segments) == 0 {
+			continue
+		}
+
+		p.LabelPlacement = findLabelPlacement(p.Label, segments, spacing, free)
+		if p.LabelPlacement == nil {
+			unplaced = append(unplaced, p.ID)
+			continue
+		}
+
+		taken = append(taken, labelRect(p.Label, p.LabelPlacement.At, p.LabelPlacement.Side, spacing))
+	}
+
+	return unplaced
+}
+
+func findLabelPlacement(text string, segments []pathSegment, spacing int, free func(rect) bool) *LabelPlacement {
+	for offset := 0; offset <= maxLabelOffset; offset++ {
+		for _, s := range segments {
+			sides := []string{LabelRight, LabelLeft}
+			if s.horizontal() {
+				sides = []string{LabelAbove, LabelBelow}
+			}
+
+			for _, at := range s.candidates() {
+				for _, side := range sides {
+					at := awayFrom(at, side, offset)
+					if free(labelRect(text, at, side, spacing)) {
+						return &LabelPlacement{At: at, Side: side}
+					}
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+// awayFrom moves a point on a path a number of grid units out to the side
+// that the label is on
+func awayFrom(at Point, side string, offset int) Point {
+	d := float64(offset)
+	switch side {
+	case LabelAbove:
+		at.Y -= d
+	case LabelBelow:
+		at.Y += d
+	case LabelLeft:
+		at.X -= d
+	default:
+		at.X += d
+	}
+	return at
+}
+
+// drawLabel writes the label text next to the point it has been placed at
+func (p *LayoutPath) drawLabel(canvas LayoutDrawer, spacing int) {
+	if p.Label == "" || p.LabelPlacement == nil {
+		return
+	}
+
+	r := labelRect(p.Label, p.LabelPlacement.At, p.LabelPlacement.Side, spacing)
+	anchor := "middle"
+	x := (r.left + r.right) / 2
+	switch p.LabelPlacement.Side {
+	case LabelLeft:
+		anchor = "end"
+		x = r.right
+	case LabelRight:
+		anchor = "start"
+		x = r.left
+	}
+
+	canvas.Textspan(
+		x, r.bottom-1,
+		p.Label,
+		fmt.Sprintf(`id="%s-label"`, p.ID),
+		`class="path-label"`,
+		fmt.Sprintf(`data-side="%s"`, p.LabelPlacement.Side),
+		"font-size:10px;text-anchor:"+anchor,
+	)
+	canvas.TextEnd()
+}
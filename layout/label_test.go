@@ -0,0 +1,143 @@
+package layout
+
+import (
+	"testing"
+
+	"github.com/dnnrly/layli/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLayoutPaths_PlaceLabels_longestSegment(t *testing.T) {
+	paths := LayoutPaths{
+		{
+			ID:    "e1",
+			Label: "HTTP",
+			Points: Points{
+				{X: 5, Y: 4},
+				{X: 7, Y: 4},
+				{X: 9, Y: 4},
+				{X: 9, Y: 12},
+				{X: 12, Y: 12},
+				{X: 14, Y: 12},
+			},
+		},
+	}
+
+	paths.PlaceLabels(LayoutNodes{}, 20)
+
+	require.NotNil(t, paths[0].LabelPlacement)
+	assert.Equal(t, Point{X: 9, Y: 8}, paths[0].LabelPlacement.At)
+	assert.Equal(t, LabelRight, paths[0].LabelPlacement.Side)
+}
+
+func TestLayoutPaths_PlaceLabels_avoidsNodes(t *testing.T) {
+	nodes := LayoutNodes{
+		NewLayoutNode("a", "", 3, 3, 5, 3, "", ""),
+		NewLayoutNode("c", "", 10, 5, 5, 3, "", ""),
+	}
+	paths := LayoutPaths{
+		{
+			ID:     "e1",
+			Label:  "publishes events",
+			Points: Points{{X: 5, Y: 4}, {X: 7, Y: 4}, {X: 9, Y: 4}, {X: 9, Y: 8}, {X: 13, Y: 8}, {X: 14, Y: 8}},
+		},
+	}
+
+	paths.PlaceLabels(nodes, 20)
+
+	require.NotNil(t, paths[0].LabelPlacement)
+	assert.Equal(t, Point{X: 9, Y: 6}, paths[0].LabelPlacement.At)
+	assert.Equal(t, LabelLeft, paths[0].LabelPlacement.Side, "the right side collides with node c")
+	r := labelRect(paths[0].Label, paths[0].LabelPlacement.At, paths[0].LabelPlacement.Side, 20)
+	for _, n := range nodes {
+		assert.False(t, r.overlaps(nodeRect(n, 20)), "label overlaps node %s", n.Id)
+	}
+}
+
+func TestLayoutPaths_PlaceLabels_avoidsOtherLabels(t *testing.T) {
+	paths := LayoutPaths{
+		{ID: "e1", Label: "first", Points: Points{{X: 1, Y: 5}, {X: 2, Y: 5}, {X: 20, Y: 5}, {X: 21, Y: 5}}},
+		{ID: "e2", Label: "second", Points: Points{{X: 1, Y: 5}, {X: 2, Y: 5}, {X: 20, Y: 5}, {X: 21, Y: 5}}},
+		{ID: "e3", Label: "third", Points: Points{{X: 1, Y: 5}, {X: 2, Y: 5}, {X: 20, Y: 5}, {X: 21, Y: 5}}},
+	}
+
+	paths.PlaceLabels(LayoutNodes{}, 20)
+
+	for i, p := range paths {
+		require.NotNil(t, p.LabelPlacement, p.ID)
+		for j, o := range paths {
+			if i == j {
+				continue
+			}
+			a := labelRect(p.Label, p.LabelPlacement.At, p.LabelPlacement.Side, 20)
+			b := labelRect(o.Label, o.LabelPlacement.At, o.LabelPlacement.Side, 20)
+			assert.False(t, a.overlaps(b), "%s overlaps %s", p.ID, o.ID)
+		}
+	}
+}
+
+func TestLayoutPaths_PlaceLabels_movesAwayFromCrowdedPaths(t *testing.T) {
+	// Nodes hug both sides of the path, so the label only fits further out
+	nodes := LayoutNodes{
+		NewLayoutNode("above", "", 0, 0, 12, 5, "", ""),
+		NewLayoutNode("below", "", 0, 6, 12, 5, "", ""),
+	}
+	paths := LayoutPaths{
+		{ID: "e1", Label: "HTTP", Points: Points{{X: 13, Y: 1}, {X: 14, Y: 1}, {X: 14, Y: 9}, {X: 15, Y: 9}}},
+	}
+
+	unplaced := paths.PlaceLabels(nodes, 20)
+
+	assert.Empty(t, unplaced)
+	require.NotNil(t, paths[0].LabelPlacement)
+	r := labelRect(paths[0].Label, paths[0].LabelPlacement.At, paths[0].LabelPlacement.Side, 20)
+	for _, n := range nodes {
+		assert.False(t, r.overlaps(nodeRect(n, 20)), "label overlaps node %s", n.Id)
+	}
+}
+
+func TestLayoutPaths_PlaceLabels_reportsLabelsThatDoNotFit(t *testing.T) {
+	nodes := LayoutNodes{NewLayoutNode("big", "", 0, 0, 30, 30, "", "")}
+	paths := LayoutPaths{
+		{ID: "e1", Label: "hidden", Points: Points{{X: 1, Y: 5}, {X: 2, Y: 5}, {X: 8, Y: 5}, {X: 9, Y: 5}}},
+	}
+
+	unplaced := paths.PlaceLabels(nodes, 20)
+
+	assert.Equal(t, []string{"e1"}, unplaced)
+	assert.Nil(t, paths[0].LabelPlacement, "overlapping labels are not drawn")
+}
+
+func TestLayoutPaths_PlaceLabels_ignoresPathsWithoutLabels(t *testing.T) {
+	paths := LayoutPaths{
+		{ID: "e1", Points: Points{{X: 1, Y: 5}, {X: 2, Y: 5}, {X: 8, Y: 5}, {X: 9, Y: 5}}},
+	}
+
+	paths.PlaceLabels(LayoutNodes{}, 20)
+
+	assert.Nil(t, paths[0].LabelPlacement)
+}
+
+func TestLayoutPath_DrawWithLabel(t *testing.T) {
+	drawer := mocks.NewLayoutDrawer(t)
+
+	p := LayoutPath{
+		ID:             "id",
+		From:           "a",
+		To:             "b",
+		Points:         Points{{X: 5.5, Y: 4.5}, {X: 8, Y: 4}, {X: 12, Y: 4}, {X: 14.5, Y: 4.5}},
+		Label:          "HTTP",
+		LabelPlacement: &LabelPlacement{At: Point{X: 10, Y: 4}, Side: LabelAbove},
+	}
+
+	drawer.On("Path", "M 80 40 L 120 40", `id="id"`, `class="path-line"`, "",
+		`marker-end="url(#arrow)"`, `data-from="a"`, `data-to="b"`).Once()
+	drawer.On("Textspan", 100, 36, "HTTP", `id="id-label"`, `class="path-label"`,
+		`data-side="above"`, "font-size:10px;text-anchor:middle").Once()
+	drawer.On("TextEnd").Once()
+
+	p.Draw(drawer, 10, 0)
+
+	drawer.AssertExpectations(t)
+}
@@ -20,6 +20,7 @@ func TestDiagram_EmbedsRootConfigValues(t *testing.T) {
 			Border:     5,
 			NodeWidth:  3,
 			NodeHeight: 6,
+			Seed:       1234,
 		},
 		Layout:   &Layout{},
 		ShowGrid: false,
@@ -38,6 +39,7 @@ func TestDiagram_EmbedsRootConfigValues(t *testing.T) {
 	assert.Equal(t, "5", root.SelectAttr("data-border"))
 	assert.Equal(t, "3", root.SelectAttr("data-node-width"))
 	assert.Equal(t, "6", root.SelectAttr("data-node-height"))
+	assert.Equal(t, "1234", root.SelectAttr("data-seed"))
 }
 
 func TestDiagram_DrawWithStyleClass(t *testing.T) {
@@ -302,6 +304,148 @@ func TestAbsoluteFromSvg(t *testing.T) {
 		})
 	})
 
+	t.Run("Reads arrow heads", func(t *testing.T) {
+		check(t, `<?xml version="1.0"?>
+<svg width="380" height="300" xmlns="http://www.w3.org/2000/svg">
+<g style="text-anchor:middle;font-family:sans;fill:none;stroke:black">
+<rect x="60" y="60" width="80" height="40" rx="3" ry="3" id="a" data-pos-x="3" data-pos-y="3" data-width="5" data-height="3" />
+<text x="100" y="80" id="a-text" style="font-size:10px" >Node 1</text>
+<rect x="240" y="60" width="80" height="40" rx="3" ry="3" id="b" data-pos-x="12" data-pos-y="3" data-width="5" data-height="3" />
+<text x="280" y="80" id="b-text" style="font-size:10px" >Node 2</text>
+<path d="M 140 80 L 240 80" id="edge-1" class="path-line" marker-start="url(#arrow-diamond)" data-from="a" data-to="b" data-arrow="backward" data-head="diamond" />
+<path d="M 240 90 L 140 90" id="edge-2" class="path-line" data-from="b" data-to="a" data-arrow="none" />
+</g>
+</svg>
+`, Config{
+			Layout: "absolute",
+			Nodes: ConfigNodes{
+				ConfigNode{Id: "a", Contents: "Node 1", Position: Position{X: 3, Y: 3}},
+				ConfigNode{Id: "b", Contents: "Node 2", Position: Position{X: 12, Y: 3}},
+			},
+			Edges: ConfigEdges{
+				ConfigEdge{From: "a", To: "b", Arrow: ArrowBackward, Head: HeadDiamond},
+				ConfigEdge{From: "b", To: "a", Arrow: ArrowNone},
+			},
+			Styles: ConfigStyles{},
+		})
+	})
+
+	t.Run("Reads edge labels", func(t *testing.T) {
+		check(t, `<?xml version="1.0"?>
+<svg width="380" height="300" xmlns="http://www.w3.org/2000/svg">
+<g style="text-anchor:middle;font-family:sans;fill:none;stroke:black">
+<rect x="60" y="60" width="80" height="40" rx="3" ry="3" id="a" data-pos-x="3" data-pos-y="3" data-width="5" data-height="3" />
+<text x="100" y="80" id="a-text" style="font-size:10px" >Node 1</text>
+<rect x="240" y="60" width="80" height="40" rx="3" ry="3" id="b" data-pos-x="12" data-pos-y="3" data-width="5" data-height="3" />
+<text x="280" y="80" id="b-text" style="font-size:10px" >Node 2</text>
+<path d="M 140 80 L 240 80" id="edge-1" class="path-line" marker-end="url(#arrow)" data-from="a" data-to="b" />
+<text x="180" y="76" id="edge-1-label" class="path-label" data-side="above" style="font-size:10px;text-anchor:middle" >HTTP</text>
+<path d="M 240 90 L 140 90" id="edge-2" class="path-line" marker-end="url(#arrow)" data-from="b" data-to="a" />
+</g>
+</svg>
+`, Config{
+			Layout: "absolute",
+			Nodes: ConfigNodes{
+				ConfigNode{Id: "a", Contents: "Node 1", Position: Position{X: 3, Y: 3}},
+				ConfigNode{Id: "b", Contents: "Node 2", Position: Position{X: 12, Y: 3}},
+			},
+			Edges: ConfigEdges{
+				ConfigEdge{From: "a", To: "b", Label: "HTTP"},
+				ConfigEdge{From: "b", To: "a"},
+			},
+			Styles: ConfigStyles{},
+		})
+	})
+
+	t.Run("Reads ids with quotes", func(t *testing.T) {
+		check(t, `<?xml version="1.0"?>
+<svg width="380" height="300" xmlns="http://www.w3.org/2000/svg">
+<g style="text-anchor:middle;font-family:sans;fill:none;stroke:black">
+<rect x="60" y="60" width="80" height="40" rx="3" ry="3" id="it's" data-pos-x="3" data-pos-y="3" data-width="5" data-height="3" />
+<text x="100" y="80" id="it's-text" style="font-size:10px" >Node 1</text>
+<rect x="240" y="60" width="80" height="40" rx="3" ry="3" id='say "b"' data-pos-x="12" data-pos-y="3" data-width="5" data-height="3" />
+<text x="280" y="80" id='say "b"-text' style="font-size:10px" >Node 2</text>
+<path d="M 140 80 L 240 80" id="it's-edge" class="path-line" marker-end="url(#arrow)" data-from="it's" data-to='say "b"' />
+<text x="180" y="76" id="it's-edge-label" class="path-label" data-side="above" style="font-size:10px;text-anchor:middle" >HTTP</text>
+</g>
+</svg>
+`, Config{
+			Layout: "absolute",
+			Nodes: ConfigNodes{
+				ConfigNode{Id: "it's", Contents: "Node 1", Position: Position{X: 3, Y: 3}},
+				ConfigNode{Id: `say "b"`, Contents: "Node 2", Position: Position{X: 12, Y: 3}},
+			},
+			Edges: ConfigEdges{
+				ConfigEdge{From: "it's", To: `say "b"`, Label: "HTTP"},
+			},
+			Styles: ConfigStyles{},
+		})
+	})
+
+	t.Run("Reads node sizes", func(t *testing.T) {
+		check(t, `<svg width="520" height="260" data-node-width="5" data-node-height="3" data-border="1" data-margin="2" >
+<g>
+<rect x="60" y="60" width="80" height="40" rx="3" ry="3" id="a" data-pos-x="3" data-pos-y="3" data-width="5" data-height="3" />
+<text x="100" y="85" id="a-text" style="font-size:10px" >A</text>
+<rect x="240" y="60" width="160" height="80" rx="3" ry="3" id="b" data-pos-x="12" data-pos-y="3" data-width="9" data-height="5" />
+<text x="320" y="105" id="b-text" style="font-size:10px" >B</text>
+</g>
+</svg>
+`, Config{
+			Layout: "absolute",
+			Nodes: ConfigNodes{
+				ConfigNode{Id: "a", Contents: "A", Position: Position{X: 3, Y: 3}},
+				ConfigNode{Id: "b", Contents: "B", Position: Position{X: 12, Y: 3}, Width: 9, Height: 5},
+			},
+			NodeWidth:  5,
+			NodeHeight: 3,
+			Border:     1,
+			Margin:     2,
+			Styles:     ConfigStyles{},
+		})
+	})
+
+	t.Run("Reads the seed", func(t *testing.T) {
+		check(t, `<svg width="520" height="260" data-node-width="5" data-node-height="3" data-border="1" data-margin="2" data-seed="1234" >
+<g>
+<rect x="60" y="60" width="80" height="40" rx="3" ry="3" id="a" data-pos-x="3" data-pos-y="3" data-width="5" data-height="3" />
+<text x="100" y="85" id="a-text" style="font-size:10px" >A</text>
+</g>
+</svg>
+`, Config{
+			Layout: "absolute",
+			Nodes: ConfigNodes{
+				ConfigNode{Id: "a", Contents: "A", Position: Position{X: 3, Y: 3}},
+			},
+			NodeWidth:  5,
+			NodeHeight: 3,
+			Border:     1,
+			Margin:     2,
+			Seed:       1234,
+			Styles:     ConfigStyles{},
+		})
+	})
+
+	t.Run("Reads multi-line contents", func(t *testing.T) {
+		check(t, `<svg width="520" height="260" data-node-width="5" data-node-height="3" data-border="1" data-margin="2" >
+<g>
+<rect x="60" y="60" width="80" height="40" rx="3" ry="3" id="a" data-pos-x="3" data-pos-y="3" data-width="5" data-height="3" />
+<text x="100" y="74" id="a-text" style="font-size:10px" ><tspan x="100" y="74" >A </tspan><tspan x="100" y="86" data-wrapped="true" >wrapped</tspan><tspan x="100" y="98" >line</tspan></text>
+</g>
+</svg>
+`, Config{
+			Layout: "absolute",
+			Nodes: ConfigNodes{
+				ConfigNode{Id: "a", Contents: "A wrapped\nline", Position: Position{X: 3, Y: 3}},
+			},
+			NodeWidth:  5,
+			NodeHeight: 3,
+			Border:     1,
+			Margin:     2,
+			Styles:     ConfigStyles{},
+		})
+	})
+
 	t.Run("Sets width", func(t *testing.T) {
 		check(t, `<?xml version="1.0"?>
 <!-- Generated by SVGo -->
@@ -333,9 +477,9 @@ func TestAbsoluteFromSvg(t *testing.T) {
 `, Config{
 			Layout: "absolute",
 			Nodes: ConfigNodes{
-				ConfigNode{Id: "a", Contents: "First", Position: Position{X: 12, Y: 12}},
-				ConfigNode{Id: "b", Contents: "Second", Position: Position{X: 36, Y: 12}},
-				ConfigNode{Id: "c", Contents: "Third", Position: Position{X: 12, Y: 36}},
+				ConfigNode{Id: "a", Contents: "First", Position: Position{X: 12, Y: 12}, Width: 8, Height: 6},
+				ConfigNode{Id: "b", Contents: "Second", Position: Position{X: 36, Y: 12}, Width: 8, Height: 6},
+				ConfigNode{Id: "c", Contents: "Third", Position: Position{X: 12, Y: 36}, Width: 8, Height: 6},
 			},
 			Edges: ConfigEdges{
 				ConfigEdge{From: "a", To: "b"},
@@ -365,6 +509,7 @@ func TestAbsoluteFromSvg_Errors(t *testing.T) {
 	check(t, "data-node-height is invalid", strings.Replace(validSVG, "data-node-height=\"2\"", "data-node-height=\"a\"", 1))
 	check(t, "data-border is invalid", strings.Replace(validSVG, "data-border=\"3\"", "data-border=\"a\"", 1))
 	check(t, "data-margin is invalid", strings.Replace(validSVG, "data-margin=\"9\"", "data-margin=\"a\"", 1))
+	check(t, "data-seed is invalid", strings.Replace(validSVG, "data-margin=\"9\"", "data-margin=\"9\" data-seed=\"a\"", 1))
 	check(t, "data-pos-x is invalid", strings.Replace(validSVG, "data-pos-x=\"12\"", "data-pos-x=\"a\"", 1))
 	check(t, "data-pos-y is invalid", strings.Replace(validSVG, "data-pos-y=\"12\"", "data-pos-y=\"a\"", 1))
 	check(t, "can't find matching text", strings.Replace(validSVG, "id=\"a-text\"", "id=\"unknown-text\"", 1))
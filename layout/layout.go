@@ -8,17 +8,32 @@ import (
 
 type LayoutDrawer interface {
 	Circle(x int, y int, r int, s ...string)
+	Ellipse(x int, y int, w int, h int, s ...string)
 	Path(d string, s ...string)
+	Polygon(x []int, y []int, s ...string)
+	Rect(x int, y int, w int, h int, s ...string)
 	Roundrect(x int, y int, w int, h int, rx int, ry int, s ...string)
 	Textspan(x int, y int, t string, s ...string)
+	Span(t string, s ...string)
 	TextEnd()
 }
 
 type Layout struct {
 	Nodes        LayoutNodes
 	Paths        LayoutPaths
+	Groups       LayoutGroups
 	CreateFinder CreateFinder
 
+	// Crossings are the places where paths had to cross because there was
+	// no other way to route them
+	Crossings Crossings
+
+	// UnplacedLabels are the IDs of the paths whose labels could not be
+	// placed without overlapping a node or another label
+	UnplacedLabels []string
+
+	namedPorts map[string]ConfigPorts // Ports declared on each node
+
 	nodeHeight   int // Height of a node in path unites
 	nodeWidth    int // Width of a node in path units
 	nodeMargin   int // Spare around nodes in path units
@@ -33,8 +48,7 @@ func NewLayoutFromConfig(finder CreateFinder, c *Config) (*Layout, error) {
 		return nil, err
 	}
 
-	pathStrategy, err := selectPathStrategy(c)
-	if err != nil {
+	if _, err := selectPathStrategy(c); err != nil {
 		return nil, err
 	}
 
@@ -43,21 +57,41 @@ func NewLayoutFromConfig(finder CreateFinder, c *Config) (*Layout, error) {
 		return nil, fmt.Errorf("arranging nodes: %w", err)
 	}
 
+	return NewLayoutFromNodes(finder, c, nodes)
+}
+
+// NewLayoutFromNodes routes the edges in the config between nodes that have
+// already been arranged. The nodes are used exactly as they are given so that
+// the paths always line up with the positions chosen by the caller.
+func NewLayoutFromNodes(finder CreateFinder, c *Config, nodes LayoutNodes) (*Layout, error) {
+	pathStrategy, err := selectPathStrategy(c)
+	if err != nil {
+		return nil, err
+	}
+
 	l := &Layout{
 		Nodes:        nodes,
+		Groups:       NewLayoutGroups(c.Groups, nodes, c.Margin, c.Spacing),
 		CreateFinder: finder,
 
 		nodeWidth:    c.NodeWidth,
 		nodeHeight:   c.NodeHeight,
 		nodeMargin:   c.Margin,
 		layoutBorder: c.Border,
+		namedPorts:   map[string]ConfigPorts{},
+	}
+	for _, n := range c.Nodes {
+		l.namedPorts[n.Id] = n.Ports
 	}
 
-	err = pathStrategy(*c, &l.Paths, l.FindPath)
+	err = pathStrategy(*c, &l.Paths, l.FindEdgePath)
 	if err != nil {
 		return nil, err
 	}
 
+	l.Crossings = l.Paths.Crossings()
+	l.UnplacedLabels = l.Paths.PlaceLabels(l.Nodes, c.Spacing)
+
 	return l, nil
 }
 
@@ -69,6 +103,9 @@ func (l *Layout) LayoutHeight() int {
 			maxBottom = n.bottom
 		}
 	}
+	for _, g := range l.Groups {
+		maxBottom = max(maxBottom, g.bottom)
+	}
 
 	return maxBottom + l.nodeMargin + 1 + l.layoutBorder
 }
@@ -81,6 +118,9 @@ func (l *Layout) LayoutWidth() int {
 			maxRight = n.right
 		}
 	}
+	for _, g := range l.Groups {
+		maxRight = max(maxRight, g.right)
+	}
 
 	return maxRight + l.nodeMargin + 1 + l.layoutBorder
 }
@@ -110,12 +150,27 @@ func (l *Layout) ShowGrid(canvas LayoutDrawer, spacing int) {
 	}
 }
 
+// IsAnyTitle returns true if the grid point is covered by the label of a group
+func (l *Layout) IsAnyTitle(x, y int) bool {
+	for _, g := range l.Groups {
+		if g.IsTitle(x, y) {
+			return true
+		}
+	}
+	return false
+}
+
 func (l *Layout) Draw(canvas LayoutDrawer, spacing int) {
+	for _, g := range l.Groups {
+		g.Draw(canvas, spacing)
+	}
+
 	for i, n := range l.Nodes {
 		n.Draw(canvas, spacing, i)
 	}
 
 	for i, p := range l.Paths {
+		p.reachOutlines(l.Nodes, spacing)
 		p.Draw(canvas, spacing, i)
 	}
 
@@ -137,6 +192,10 @@ type LayoutNode struct {
 
 	class string
 	style string
+
+	// Shape is how the outline of the node is drawn, it doesn't change
+	// where the node's ports are
+	Shape string
 }
 
 type LayoutNodes []LayoutNode
@@ -196,6 +255,14 @@ func NewLayoutNode(id, contents string, left, top, width, height int, class, sty
 	}
 }
 
+// placeNode creates the layout node for a node in the config, at the given
+// position and size
+func placeNode(c *ConfigNode, left, top, width, height int) LayoutNode {
+	n := NewLayoutNode(c.Id, c.Contents, left, top, width, height, c.Class, c.Style)
+	n.Shape = c.Shape
+	return n
+}
+
 func (n *LayoutNode) IsInside(x, y int) bool {
 	if y >= n.top && y <= n.bottom && x >= n.left && x <= n.right {
 		return true
@@ -260,10 +327,7 @@ func (n *LayoutNode) Draw(d LayoutDrawer, spacing, order int) {
 	if n.style != "" {
 		style = fmt.Sprintf(`style="%s"`, n.style)
 	}
-	d.Roundrect(
-		n.left*spacing, n.top*spacing,
-		(n.width-1)*spacing, (n.height-1)*spacing,
-		3, 3,
+	attrs := []string{
 		fmt.Sprintf(`id="%s"`, n.Id),
 		class,
 		style,
@@ -271,14 +335,40 @@ func (n *LayoutNode) Draw(d LayoutDrawer, spacing, order int) {
 		fmt.Sprintf("data-pos-y=\"%d\"", n.top),
 		fmt.Sprintf("data-width=\"%d\"", n.width),
 		fmt.Sprintf("data-height=\"%d\"", n.height),
-	)
-	d.Textspan(
-		n.left*spacing+(((n.width-1)*spacing)/2),
-		n.top*spacing+(((n.height-1)*spacing)/2),
-		n.Contents,
+	}
+	if n.Shape != "" {
+		attrs = append(attrs, fmt.Sprintf(`data-shape="%s"`, n.Shape))
+	}
+	n.drawShape(d, spacing, attrs...)
+
+	x := n.left*spacing + (((n.width - 1) * spacing) / 2)
+	y := n.top*spacing + (((n.height - 1) * spacing) / 2) + n.textOffset(spacing)
+	lines := n.textLines(spacing)
+	if len(lines) == 1 {
+		d.Textspan(x, y, lines[0].text,
+			fmt.Sprintf(`id="%s-text"`, n.Id),
+			fmt.Sprintf("font-size:%dpx", nodeFontSize),
+		)
+		d.TextEnd()
+		return
+	}
+
+	// Centre the block of lines vertically around the middle of the node
+	top := y - ((len(lines)-1)*nodeLineHeight)/2
+	d.Textspan(x, top, "",
 		fmt.Sprintf(`id="%s-text"`, n.Id),
-		"font-size:10px",
+		fmt.Sprintf("font-size:%dpx", nodeFontSize),
 	)
+	for i, l := range lines {
+		attrs := []string{
+			fmt.Sprintf(`x="%d"`, x),
+			fmt.Sprintf(`y="%d"`, top+i*nodeLineHeight),
+		}
+		if l.wrapped {
+			attrs = append(attrs, `data-wrapped="true"`)
+		}
+		d.Span(l.text, attrs...)
+	}
 	d.TextEnd()
 }
 
@@ -289,6 +379,22 @@ type LayoutPath struct {
 	Points Points
 	Class  string
 	Style  string
+
+	// Arrow says which ends of the path get an arrow head and Head is the
+	// shape that they are drawn as
+	Arrow string
+	Head  string
+
+	Label          string
+	LabelPlacement *LabelPlacement
+
+	// colour is the stroke of the path, worked out from its styles, so that
+	// the arrow heads can match it
+	colour string
+
+	// reach is where the start and end of the path meet the outline of
+	// nodes whose shape doesn't fill their box
+	reach [2]*Point
 }
 
 func (p *LayoutPath) Draw(canvas LayoutDrawer, spacing, order int) {
@@ -300,15 +406,24 @@ func (p *LayoutPath) Draw(canvas LayoutDrawer, spacing, order int) {
 	if p.Style != "" {
 		style = "style=\"" + p.Style + "\""
 	}
-	canvas.Path(
-		p.Points.Path(spacing),
-		`id="`+p.ID+`"`,
-		`class="`+class+`"`,
+	attrs := []string{
+		`id="` + p.ID + `"`,
+		`class="` + class + `"`,
 		style,
-		`marker-end="url(#arrow)"`,
+	}
+	attrs = append(attrs, p.markers()...)
+	attrs = append(attrs,
 		fmt.Sprintf(`data-from="%s"`, p.From),
 		fmt.Sprintf(`data-to="%s"`, p.To),
 	)
+	if p.Arrow != "" {
+		attrs = append(attrs, fmt.Sprintf(`data-arrow="%s"`, p.Arrow))
+	}
+	if p.Head != "" {
+		attrs = append(attrs, fmt.Sprintf(`data-head="%s"`, p.Head))
+	}
+	canvas.Path(p.drawnPath(spacing), attrs...)
+	p.drawLabel(canvas, spacing)
 }
 
 func (paths *LayoutPath) Length() float64 {
@@ -110,6 +110,43 @@ func TestLayoutNode_DrawNode(t *testing.T) {
 	drawer.AssertExpectations(t)
 }
 
+func TestLayoutNode_DrawNodeWithMultipleLines(t *testing.T) {
+	drawer := mocks.NewLayoutDrawer(t)
+
+	n := NewLayoutNode("nodeA", "first\nsecond\nthird", 4, 5, 3, 3, "", "")
+
+	drawer.On("Roundrect", 160, 200, 80, 80, 3, 3, `id="nodeA"`, "", "",
+		"data-pos-x=\"4\"", "data-pos-y=\"5\"",
+		"data-width=\"3\"", "data-height=\"3\"").Once()
+	drawer.On("Textspan", 200, 228, "", `id="nodeA-text"`, "font-size:10px").Once()
+	drawer.On("Span", "first", `x="200"`, `y="228"`).Once()
+	drawer.On("Span", "second", `x="200"`, `y="240"`).Once()
+	drawer.On("Span", "third", `x="200"`, `y="252"`).Once()
+	drawer.On("TextEnd").Once()
+
+	n.Draw(drawer, 40, 3)
+
+	drawer.AssertExpectations(t)
+}
+
+func TestLayoutNode_DrawNodeWrapsLongContents(t *testing.T) {
+	drawer := mocks.NewLayoutDrawer(t)
+
+	n := NewLayoutNode("nodeA", "a long label", 4, 5, 3, 3, "", "")
+
+	drawer.On("Roundrect", 80, 100, 40, 40, 3, 3, `id="nodeA"`, "", "",
+		"data-pos-x=\"4\"", "data-pos-y=\"5\"",
+		"data-width=\"3\"", "data-height=\"3\"").Once()
+	drawer.On("Textspan", 100, 114, "", `id="nodeA-text"`, "font-size:10px").Once()
+	drawer.On("Span", "a long ", `x="100"`, `y="114"`).Once()
+	drawer.On("Span", "label", `x="100"`, `y="126"`, `data-wrapped="true"`).Once()
+	drawer.On("TextEnd").Once()
+
+	n.Draw(drawer, 20, 3)
+
+	drawer.AssertExpectations(t)
+}
+
 func TestLayoutNode_DrawNodeWithClass(t *testing.T) {
 	drawer := mocks.NewLayoutDrawer(t)
 
@@ -212,6 +249,21 @@ func TestLayoutNode_IsPort(t *testing.T) {
 	assert.True(t, n.IsPort(4, 5), "4,5 %d,%d,%d,%d", n.left, n.right, n.top, n.bottom)
 }
 
+func TestLayoutNode_GetPorts(t *testing.T) {
+	n := NewLayoutNode("id", "contents", 3, 3, 4, 5, "", "")
+
+	assert.ElementsMatch(t, Points{
+		{X: 4, Y: 3}, {X: 5, Y: 3},
+		{X: 4, Y: 7}, {X: 5, Y: 7},
+		{X: 3, Y: 4}, {X: 3, Y: 5}, {X: 3, Y: 6},
+		{X: 6, Y: 4}, {X: 6, Y: 5}, {X: 6, Y: 6},
+	}, n.GetPorts())
+
+	for _, p := range n.GetPorts() {
+		assert.True(t, n.IsPort(int(p.X), int(p.Y)), "%v is a port", p)
+	}
+}
+
 func TestLayoutNode_GetCentre(t *testing.T) {
 	n := NewLayoutNode("id", "contents", 3, 3, 5, 3, "", "")
 
@@ -436,3 +488,49 @@ func TestLayoutPaths_Length(t *testing.T) {
 	})
 
 }
+
+func TestNewLayoutFromNodes_usesNodesAsGiven(t *testing.T) {
+	nodes := LayoutNodes{
+		NewLayoutNode("1", "", 20, 3, 5, 3, "", ""),
+		NewLayoutNode("2", "", 3, 12, 5, 3, "", ""),
+	}
+	config := &Config{
+		Edges:      ConfigEdges{{ID: "e1", From: "1", To: "2"}},
+		Spacing:    20,
+		NodeWidth:  5,
+		NodeHeight: 3,
+		Margin:     2,
+		Border:     1,
+	}
+
+	l, err := NewLayoutFromNodes(func(start, end dijkstra.Point) PathFinder {
+		return dijkstra.NewPathFinder(start, end)
+	}, config, nodes)
+	require.NoError(t, err)
+
+	assert.Equal(t, nodes, l.Nodes)
+	require.Len(t, l.Paths, 1)
+
+	points := l.Paths[0].Points
+	assert.Equal(t, nodes[0].GetCentre(), points[0])
+	assert.Equal(t, nodes[1].GetCentre(), points[len(points)-1])
+	assert.True(t, nodes[0].IsPort(int(points[1].X), int(points[1].Y)))
+	assert.True(t, nodes[1].IsPort(int(points[len(points)-2].X), int(points[len(points)-2].Y)))
+}
+
+func TestNewLayoutFromNodes_errorsOnUnknownNode(t *testing.T) {
+	nodes := LayoutNodes{NewLayoutNode("1", "", 3, 3, 5, 3, "", "")}
+	config := &Config{
+		Edges:      ConfigEdges{{ID: "e1", From: "1", To: "2"}},
+		NodeWidth:  5,
+		NodeHeight: 3,
+		Margin:     2,
+		Border:     1,
+	}
+
+	_, err := NewLayoutFromNodes(func(start, end dijkstra.Point) PathFinder {
+		return dijkstra.NewPathFinder(start, end)
+	}, config, nodes)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "unknown node")
+}
@@ -0,0 +1,99 @@
+package layout
+
+import "fmt"
+
+// loopCorner is a corner of a node that a self loop can be drawn around. The
+// loop leaves the node through the port on one side of the corner and comes
+// back in through the port on the other side.
+type loopCorner struct {
+	leave, enter string
+	dx, dy       int // Direction from the node to the outside of the corner
+}
+
+// loopCorners are tried in order until there is room for the loop
+var loopCorners = []loopCorner{
+	{leave: PortEast, enter: PortNorth, dx: 1, dy: -1},
+	{leave: PortEast, enter: PortSouth, dx: 1, dy: 1},
+	{leave: PortWest, enter: PortNorth, dx: -1, dy: -1},
+	{leave: PortWest, enter: PortSouth, dx: -1, dy: 1},
+}
+
+// findLoop routes an edge that starts and ends on the same node as a small
+// loop around one of the corners of the node. It doesn't need to search for
+// a path, only to find a corner where the loop doesn't run in to anything.
+func (l *Layout) findLoop(edge ConfigEdge, n *LayoutNode) (*LayoutPath, error) {
+	leave, err := l.portSide(n, edge.FromPort)
+	if err != nil {
+		return nil, fmt.Errorf("finding loop on %s: %w", n.Id, err)
+	}
+	enter, err := l.portSide(n, edge.ToPort)
+	if err != nil {
+		return nil, fmt.Errorf("finding loop on %s: %w", n.Id, err)
+	}
+
+	vm := BuildVertexMap(l)
+	for _, c := range loopCorners {
+		if (leave != "" && leave != c.leave) || (enter != "" && enter != c.enter) {
+			continue
+		}
+
+		path := n.loopAround(c)
+		if path.fits(vm) {
+			return &path, nil
+		}
+	}
+
+	return nil, fmt.Errorf("finding loop on %s: no room around the node", n.Id)
+}
+
+// portSide returns the side of the node that a port is on
+func (l *Layout) portSide(n *LayoutNode, ref string) (string, error) {
+	if ref == "" || IsPortSide(ref) {
+		return ref, nil
+	}
+
+	p := l.namedPorts[n.Id].ByName(ref)
+	if p == nil {
+		return "", fmt.Errorf("node %s does not have a port called %s", n.Id, ref)
+	}
+	return p.Side, nil
+}
+
+// loopAround returns a loop that steps 1 grid point out from each side of the
+// corner
+func (n *LayoutNode) loopAround(c loopCorner) LayoutPath {
+	x, y := n.left, n.top
+	if c.dx > 0 {
+		x = n.right
+	}
+	if c.dy > 0 {
+		y = n.bottom
+	}
+
+	at := func(dx, dy int) Point {
+		return Point{X: float64(x + dx), Y: float64(y + dy)}
+	}
+
+	return LayoutPath{
+		Points: Points{
+			n.GetCentre(),
+			at(0, -c.dy),
+			at(c.dx, -c.dy),
+			at(c.dx, c.dy),
+			at(-c.dx, c.dy),
+			at(-c.dx, 0),
+			n.GetCentre(),
+		},
+	}
+}
+
+// fits returns true if every grid point that the path is drawn over is free
+func (p *LayoutPath) fits(vm VertexMap) bool {
+	for _, c := range p.cells() {
+		x, y := int(c.X), int(c.Y)
+		if x < 0 || y < 0 || x >= vm.width || y >= vm.height || !vm.Get(x, y) {
+			return false
+		}
+	}
+	return true
+}
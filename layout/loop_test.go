@@ -0,0 +1,96 @@
+package layout
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func loopTestLayout(t *testing.T, edges ConfigEdges) *Layout {
+	c := &Config{
+		Nodes:      ConfigNodes{{Id: "a"}, {Id: "b"}},
+		Edges:      edges,
+		NodeWidth:  5,
+		NodeHeight: 3,
+		Margin:     2,
+		Border:     1,
+		Spacing:    20,
+	}
+
+	// Loops never search for a path, so there is no finder
+	l, err := NewLayoutFromNodes(nil, c, LayoutNodes{
+		NewLayoutNode("a", "", 3, 3, 5, 3, "", ""),
+		NewLayoutNode("b", "", 12, 3, 5, 3, "", ""),
+	})
+	require.NoError(t, err)
+	return l
+}
+
+func TestLayoutNode_loopAround(t *testing.T) {
+	n := NewLayoutNode("a", "", 3, 3, 5, 3, "", "")
+
+	path := n.loopAround(loopCorner{leave: PortEast, enter: PortNorth, dx: 1, dy: -1})
+
+	assert.Equal(t, Points{
+		{X: 5.5, Y: 4.5},
+		{X: 7, Y: 4},
+		{X: 8, Y: 4},
+		{X: 8, Y: 2},
+		{X: 6, Y: 2},
+		{X: 6, Y: 3},
+		{X: 5.5, Y: 4.5},
+	}, path.Points)
+}
+
+func TestLayout_FindEdgePath_selfLoop(t *testing.T) {
+	l := loopTestLayout(t, ConfigEdges{{ID: "loop", From: "a", To: "a"}})
+
+	require.Len(t, l.Paths, 1)
+	p := l.Paths[0]
+	assert.Equal(t, "loop", p.ID)
+	assert.Equal(t, "a", p.From)
+	assert.Equal(t, "a", p.To)
+	assert.Equal(t, "M 140 80 L 160 80 L 160 40 L 120 40 L 120 60", p.Points.Path(20))
+}
+
+func TestLayout_FindEdgePath_selfLoopsUseAnotherCornerWhenBlocked(t *testing.T) {
+	l := loopTestLayout(t, ConfigEdges{
+		{ID: "first", From: "a", To: "a"},
+		{ID: "second", From: "a", To: "a"},
+	})
+
+	require.Len(t, l.Paths, 2)
+	assert.Equal(t, "M 140 80 L 160 80 L 160 40 L 120 40 L 120 60", l.Paths[0].Points.Path(20))
+	assert.Equal(t, "M 60 80 L 40 80 L 40 40 L 80 40 L 80 60", l.Paths[1].Points.Path(20),
+		"the only port on the east side is taken, so it goes round the west side")
+	assert.Empty(t, l.Crossings)
+}
+
+func TestLayout_FindEdgePath_selfLoopUsesPorts(t *testing.T) {
+	l := loopTestLayout(t, ConfigEdges{{From: "a", To: "a", FromPort: PortWest, ToPort: PortSouth}})
+
+	require.Len(t, l.Paths, 1)
+	assert.Equal(t, "M 60 80 L 40 80 L 40 120 L 80 120 L 80 100", l.Paths[0].Points.Path(20))
+}
+
+func TestLayout_FindEdgePath_selfLoopWithoutRoom(t *testing.T) {
+	l := loopTestLayout(t, nil)
+
+	_, err := l.FindEdgePath(ConfigEdge{From: "a", To: "a", FromPort: PortNorth})
+	assert.ErrorContains(t, err, "finding loop on a: no room around the node")
+}
+
+func TestNewConfigFromFile_allowsSelfLoops(t *testing.T) {
+	config, err := NewConfigFromFile(strings.NewReader(`
+nodes:
+  - id: retry
+edges:
+  - from: retry
+    to: retry
+`))
+	require.NoError(t, err)
+	assert.Equal(t, "retry", config.Edges[0].From)
+	assert.Equal(t, "retry", config.Edges[0].To)
+}
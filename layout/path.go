@@ -5,7 +5,6 @@ import (
 	"fmt"
 	"math"
 
-	"github.com/dnnrly/layli/internal/common"
 	"github.com/dnnrly/layli/pathfinder/dijkstra"
 )
 
@@ -20,33 +19,15 @@ func BuildVertexMap(l *Layout) VertexMap {
 	vm := NewVertexMap(l.LayoutWidth(), l.LayoutHeight())
 	vm.MapUnset(l.InsideAny)
 	vm.MapOr(l.IsAnyPort)
+	vm.Map(func(x, y int, current bool) bool { return !l.IsAnyTitle(x, y) && current })
 	vm.Map(func(x, y int, current bool) bool { return x >= l.layoutBorder && current })
 	vm.Map(func(x, y int, current bool) bool { return y >= l.layoutBorder && current })
 	vm.Map(func(x, y int, current bool) bool { return x < l.LayoutWidth()-l.layoutBorder && current })
 	vm.Map(func(x, y int, current bool) bool { return y < l.LayoutHeight()-l.layoutBorder && current })
 
 	for _, v := range l.Paths {
-		for p := 1; p < len(v.Points)-2; p++ {
-			start := v.Points[p]
-			end := v.Points[p+1]
-
-			x := math.Min(start.X, end.X)
-			y := math.Min(start.Y, end.Y)
-
-			xMax := math.Max(start.X, end.X)
-			yMax := math.Max(start.Y, end.Y)
-
-			vm.Set(int(x), int(y), false)
-			for x != xMax || y != yMax {
-				if x != xMax {
-					x += 1
-				}
-				if y != yMax {
-					y += 1
-				}
-
-				vm.Set(int(x), int(y), false)
-			}
+		for _, c := range v.cells() {
+			vm.Set(int(c.X), int(c.Y), false)
 		}
 	}
 
@@ -54,8 +35,30 @@ func BuildVertexMap(l *Layout) VertexMap {
 }
 
 func (l *Layout) FindPath(from, to string) (*LayoutPath, error) {
+	return l.FindEdgePath(ConfigEdge{From: from, To: to})
+}
+
+// FindEdgePath finds a path for the edge, only leaving and entering the
+// nodes through the ports that the edge allows
+func (l *Layout) FindEdgePath(edge ConfigEdge) (*LayoutPath, error) {
+	from, to := edge.From, edge.To
 	nFrom := l.Nodes.ByID(from)
 	nTo := l.Nodes.ByID(to)
+	if nFrom == nil || nTo == nil {
+		return nil, fmt.Errorf("finding path between %s and %s: unknown node", from, to)
+	}
+	if from == to {
+		return l.findLoop(edge, nFrom)
+	}
+
+	fromPorts, err := l.allowedPorts(nFrom, edge.FromPort)
+	if err != nil {
+		return nil, fmt.Errorf("finding path between %s and %s: %w", from, to, err)
+	}
+	toPorts, err := l.allowedPorts(nTo, edge.ToPort)
+	if err != nil {
+		return nil, fmt.Errorf("finding path between %s and %s: %w", from, to, err)
+	}
 
 	finder := l.CreateFinder(
 		nFrom.GetCentre(),
@@ -69,11 +72,16 @@ func (l *Layout) FindPath(from, to string) (*LayoutPath, error) {
 		finder.AddConnection(a.From, PythagoreanDistance, a.To)
 	}
 
+	// Crossing another path is allowed, but only when there's no other way
+	for _, a := range l.crossingArcs(vm) {
+		finder.AddConnection(a.From, a.cost(), a.To)
+		finder.AddConnection(a.To, a.cost(), a.From)
+	}
+
 	{
 		// Add "from" paths
 		centre := nFrom.GetCentre()
-		ports := nFrom.GetPorts()
-		for _, to := range ports {
+		for _, to := range fromPorts {
 			finder.AddConnection(centre, PythagoreanDistance, to)
 		}
 	}
@@ -81,8 +89,7 @@ func (l *Layout) FindPath(from, to string) (*LayoutPath, error) {
 	{
 		// Add "to" paths
 		centre := nTo.GetCentre()
-		ports := nTo.GetPorts()
-		for _, from := range ports {
+		for _, from := range toPorts {
 			finder.AddConnection(
 				from,
 				PythagoreanDistance,
@@ -105,7 +112,7 @@ func (l *Layout) FindPath(from, to string) (*LayoutPath, error) {
 	return &path, nil
 }
 
-type PathStrategy func(config Config, paths *LayoutPaths, find func(from, to string) (*LayoutPath, error)) error
+type PathStrategy func(config Config, paths *LayoutPaths, find func(edge ConfigEdge) (*LayoutPath, error)) error
 
 func selectPathStrategy(c *Config) (PathStrategy, error) {
 	switch c.Path.Strategy {
@@ -121,15 +128,18 @@ func selectPathStrategy(c *Config) (PathStrategy, error) {
 	}
 }
 
-func findPathsInOrder(config Config, paths *LayoutPaths, find func(from, to string) (*LayoutPath, error)) error {
+func findPathsInOrder(config Config, paths *LayoutPaths, find func(edge ConfigEdge) (*LayoutPath, error)) error {
 	for _, p := range config.Edges {
-		path, err := find(p.From, p.To)
+		path, err := find(p)
 		if err != nil {
 			return err
 		}
 		path.ID = p.ID
 		path.Class = p.Class
 		path.Style = p.Style
+		path.Arrow = p.Arrow
+		path.Head = p.Head
+		path.Label = p.Label
 		path.From = p.From
 		path.To = p.To
 
@@ -138,26 +148,35 @@ func findPathsInOrder(config Config, paths *LayoutPaths, find func(from, to stri
 	return nil
 }
 
-func findPathsRandomlyByOrder(config Config, paths *LayoutPaths, find func(from, to string) (*LayoutPath, error)) error {
+func findPathsRandomlyByOrder(config Config, paths *LayoutPaths, find func(edge ConfigEdge) (*LayoutPath, error)) error {
 	return findPathsRandomly(findPathsInOrder)(config, paths, find)
 }
 
 func findPathsRandomly(subStrategy PathStrategy) PathStrategy {
-	return func(config Config, paths *LayoutPaths, find func(from, to string) (*LayoutPath, error)) error {
+	return func(config Config, paths *LayoutPaths, find func(edge ConfigEdge) (*LayoutPath, error)) error {
 		shortest := LayoutPaths{LayoutPath{Points: []Point{
 			{X: 0.0, Y: 0.0},
 			{X: math.MaxFloat64, Y: math.MaxFloat64},
 		}}}
+		fewestCrossings := math.MaxInt
 
 		gotPath := false
+		random := config.random()
 
 		for count := 0; count < config.Path.Attempts; count++ {
-			common.Shuffle(len(config.Edges), func(i, j int) { config.Edges[i], config.Edges[j] = config.Edges[j], config.Edges[i] })
+			random.Shuffle(len(config.Edges), func(i, j int) { config.Edges[i], config.Edges[j] = config.Edges[j], config.Edges[i] })
+
+			// Each attempt routes every path from scratch
+			*paths = LayoutPaths{}
 			err := subStrategy(config, paths, find)
 
 			if err == nil {
-				if paths.Length() < shortest.Length() {
+				// Crossings are worse than long paths
+				crossings := len(paths.Crossings())
+				if crossings < fewestCrossings ||
+					(crossings == fewestCrossings && paths.Length() < shortest.Length()) {
 					shortest = *paths
+					fewestCrossings = crossings
 				}
 				gotPath = true
 			} else {
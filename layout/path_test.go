@@ -174,11 +174,11 @@ func Test_findPathsInOrder_runsInOrder(t *testing.T) {
 			},
 		},
 		&paths,
-		func(from, to string) (*LayoutPath, error) {
+		func(edge ConfigEdge) (*LayoutPath, error) {
 			record = append(record, struct {
 				from string
 				to   string
-			}{from: from, to: to})
+			}{from: edge.From, to: edge.To})
 			return &LayoutPath{}, nil
 		},
 	)
@@ -210,11 +210,11 @@ func Test_findPathsInOrder_addsIdCorrectly(t *testing.T) {
 			},
 		},
 		&paths,
-		func(from, to string) (*LayoutPath, error) {
+		func(edge ConfigEdge) (*LayoutPath, error) {
 			record = append(record, struct {
 				from string
 				to   string
-			}{from: from, to: to})
+			}{from: edge.From, to: edge.To})
 			return &LayoutPath{}, nil
 		},
 	)
@@ -252,7 +252,7 @@ func Test_findPathsInOrder_passesErrorThrough(t *testing.T) {
 			},
 		},
 		&paths,
-		func(from, to string) (*LayoutPath, error) {
+		func(edge ConfigEdge) (*LayoutPath, error) {
 			return nil, expectedErr
 		},
 	)
@@ -273,11 +273,11 @@ func Test_findPathsRandomly_addsAllPaths(t *testing.T) {
 		},
 	}
 	records := []ConfigEdges{}
-	subStrat := func(config Config, paths *LayoutPaths, find func(from, to string) (*LayoutPath, error)) error {
+	subStrat := func(config Config, paths *LayoutPaths, find func(edge ConfigEdge) (*LayoutPath, error)) error {
 		records = append(records, config.Edges)
 		return nil
 	}
-	err := findPathsRandomly(subStrat)(config, &LayoutPaths{}, func(from, to string) (*LayoutPath, error) { return &LayoutPath{}, nil })
+	err := findPathsRandomly(subStrat)(config, &LayoutPaths{}, func(edge ConfigEdge) (*LayoutPath, error) { return &LayoutPath{}, nil })
 
 	assert.NoError(t, err)
 	assert.Len(t, records, 5)
@@ -303,25 +303,49 @@ func Test_findPathsRandomly_orderChanges(t *testing.T) {
 	}
 	last := ConfigEdges{}
 	last = append(last, config.Edges...)
-	subStrat := func(config Config, paths *LayoutPaths, find func(from, to string) (*LayoutPath, error)) error {
+	subStrat := func(config Config, paths *LayoutPaths, find func(edge ConfigEdge) (*LayoutPath, error)) error {
 		assert.NotEqual(t, last, config.Edges)
 		last = append(last, config.Edges...)
 		return nil
 	}
-	err := findPathsRandomly(subStrat)(config, &LayoutPaths{}, func(from, to string) (*LayoutPath, error) { return &LayoutPath{}, nil })
+	err := findPathsRandomly(subStrat)(config, &LayoutPaths{}, func(edge ConfigEdge) (*LayoutPath, error) { return &LayoutPath{}, nil })
 
 	assert.NoError(t, err)
 }
 
+func Test_findPathsRandomly_repeatsWithTheSameSeed(t *testing.T) {
+	orders := func(seed int64) []ConfigEdges {
+		config := Config{
+			Seed: seed,
+			Path: ConfigPath{Attempts: 5},
+			Edges: ConfigEdges{
+				{From: "a", To: "b"}, {From: "1", To: "2"}, {From: "2", To: "3"}, {From: "r", To: "t"},
+				{From: "a", To: "d"}, {From: "1", To: "5"}, {From: "2", To: "99"}, {From: "r", To: "d"},
+			},
+		}
+		records := []ConfigEdges{}
+		subStrat := func(config Config, paths *LayoutPaths, find func(edge ConfigEdge) (*LayoutPath, error)) error {
+			records = append(records, append(ConfigEdges{}, config.Edges...))
+			return nil
+		}
+		err := findPathsRandomly(subStrat)(config, &LayoutPaths{}, func(edge ConfigEdge) (*LayoutPath, error) { return &LayoutPath{}, nil })
+		assert.NoError(t, err)
+		return records
+	}
+
+	assert.Equal(t, orders(3), orders(3))
+	assert.NotEqual(t, orders(3), orders(4))
+}
+
 func Test_findPathsRandomly_selectsShortestPath(t *testing.T) {
 	config := Config{Path: ConfigPath{
 		Attempts: 5},
 		Edges: ConfigEdges{{From: "a", To: "b"}, {From: "1", To: "2"}, {From: "2", To: "3"}, {From: "r", To: "t"}},
 	}
-	subStrat := func(config Config, paths *LayoutPaths, find func(from, to string) (*LayoutPath, error)) error {
+	subStrat := func(config Config, paths *LayoutPaths, find func(edge ConfigEdge) (*LayoutPath, error)) error {
 		return nil
 	}
-	err := findPathsRandomly(subStrat)(config, &LayoutPaths{}, func(from, to string) (*LayoutPath, error) { return &LayoutPath{}, nil })
+	err := findPathsRandomly(subStrat)(config, &LayoutPaths{}, func(edge ConfigEdge) (*LayoutPath, error) { return &LayoutPath{}, nil })
 
 	assert.NoError(t, err)
 }
@@ -344,7 +368,7 @@ func Test_findPathsRandomly_passesErrorThrough(t *testing.T) {
 			},
 		},
 		&paths,
-		func(from, to string) (*LayoutPath, error) {
+		func(edge ConfigEdge) (*LayoutPath, error) {
 			count++
 			return nil, expectedErr
 		},
@@ -368,7 +392,7 @@ func Test_findPathsRandomly_retriesWhenStrugglingToFindPath(t *testing.T) {
 			},
 		},
 		&paths,
-		func(from, to string) (*LayoutPath, error) {
+		func(edge ConfigEdge) (*LayoutPath, error) {
 			count++
 			if count%2 == 0 {
 				return nil, dijkstra.ErrNotFound
@@ -401,10 +425,40 @@ func Test_findPathsRandomly_eventuallyGivesUp(t *testing.T) {
 			},
 		},
 		&paths,
-		func(from, to string) (*LayoutPath, error) {
+		func(edge ConfigEdge) (*LayoutPath, error) {
 			return nil, dijkstra.ErrNotFound
 		},
 	)
 
 	assert.ErrorIs(t, err, dijkstra.ErrNotFound, "got error: %v", err)
 }
+
+func Test_findPathsRandomly_prefersFewestCrossings(t *testing.T) {
+	config := Config{
+		Path:  ConfigPath{Attempts: 2},
+		Edges: ConfigEdges{{ID: "1", From: "a", To: "b"}, {ID: "2", From: "c", To: "d"}},
+	}
+
+	crossing := LayoutPaths{
+		{ID: "1", Points: Points{{X: 0, Y: 5}, {X: 1, Y: 5}, {X: 9, Y: 5}, {X: 10, Y: 5}}},
+		{ID: "2", Points: Points{{X: 4, Y: 0}, {X: 4, Y: 1}, {X: 4, Y: 9}, {X: 4, Y: 10}}},
+	}
+	longer := LayoutPaths{
+		{ID: "1", Points: Points{{X: 0, Y: 5}, {X: 1, Y: 5}, {X: 1, Y: 20}, {X: 9, Y: 20}, {X: 9, Y: 5}, {X: 10, Y: 5}}},
+		{ID: "2", Points: Points{{X: 4, Y: 0}, {X: 4, Y: 1}, {X: 4, Y: 9}, {X: 4, Y: 10}}},
+	}
+
+	attempts := []LayoutPaths{crossing, longer}
+	subStrat := func(config Config, paths *LayoutPaths, find func(edge ConfigEdge) (*LayoutPath, error)) error {
+		assert.Empty(t, *paths, "each attempt starts from scratch")
+		*paths = append(*paths, attempts[0]...)
+		attempts = attempts[1:]
+		return nil
+	}
+
+	paths := LayoutPaths{}
+	err := findPathsRandomly(subStrat)(config, &paths, nil)
+
+	require.NoError(t, err)
+	assert.Equal(t, longer, paths)
+}
@@ -0,0 +1,140 @@
+package layout
+
+import "fmt"
+
+// Sides of a node that an edge can leave or enter through
+const (
+	PortNorth = "north"
+	PortSouth = "south"
+	PortEast  = "east"
+	PortWest  = "west"
+)
+
+// ConfigPort is a named place on the side of a node that edges can connect
+// to. The offset is measured in path units from the top or left corner of
+// that side.
+type ConfigPort struct {
+	Name   string `yaml:"name"`
+	Side   string `yaml:"side"`
+	Offset int    `yaml:"offset"`
+}
+
+type ConfigPorts []ConfigPort
+
+// ByName returns the port with the given name, or nil if there isn't one
+func (ports ConfigPorts) ByName(name string) *ConfigPort {
+	for _, p := range ports {
+		if p.Name == name {
+			return &p
+		}
+	}
+	return nil
+}
+
+// IsPortSide returns true if s is the name of a side of a node
+func IsPortSide(s string) bool {
+	return s == PortNorth || s == PortSouth || s == PortEast || s == PortWest
+}
+
+// validatePorts checks the ports declared on a node
+func validatePorts(n *ConfigNode) error {
+	seen := map[string]bool{}
+	for _, p := range n.Ports {
+		if p.Name == "" {
+			return fmt.Errorf("all ports on node %s must have a name", n.Id)
+		}
+		if IsPortSide(p.Name) {
+			return fmt.Errorf("port %s on node %s cannot be named after a side", p.Name, n.Id)
+		}
+		if seen[p.Name] {
+			return fmt.Errorf("node %s has more than 1 port called %s", n.Id, p.Name)
+		}
+		seen[p.Name] = true
+		if !IsPortSide(p.Side) {
+			return fmt.Errorf("invalid side for port %s on node %s: %s. Valid options: north, south, east, west", p.Name, n.Id, p.Side)
+		}
+		if p.Offset < 1 {
+			return fmt.Errorf("port %s on node %s must have an offset of at least 1", p.Name, n.Id)
+		}
+	}
+	return nil
+}
+
+// validatePortRef checks that an edge refers to a side or to a port that has
+// been declared on the node
+func validatePortRef(ref string, n *ConfigNode) error {
+	if ref == "" || IsPortSide(ref) || n.Ports.ByName(ref) != nil {
+		return nil
+	}
+	return fmt.Errorf("node %s does not have a port called %s", n.Id, ref)
+}
+
+// GetPortsOn returns the ports along one side of the node
+func (n *LayoutNode) GetPortsOn(side string) Points {
+	ports := Points{}
+
+	switch side {
+	case PortNorth, PortSouth:
+		y := n.top
+		if side == PortSouth {
+			y = n.bottom
+		}
+		for i := n.left + 1; i < n.right; i++ {
+			ports = append(ports, Point{X: float64(i), Y: float64(y)})
+		}
+	case PortEast, PortWest:
+		x := n.left
+		if side == PortEast {
+			x = n.right
+		}
+		for i := n.top + 1; i < n.bottom; i++ {
+			ports = append(ports, Point{X: float64(x), Y: float64(i)})
+		}
+	}
+
+	return ports
+}
+
+// GetNamedPort returns the position of a port declared on the node
+func (n *LayoutNode) GetNamedPort(p ConfigPort) (Point, error) {
+	var at Point
+	switch p.Side {
+	case PortNorth:
+		at = Point{X: float64(n.left + p.Offset), Y: float64(n.top)}
+	case PortSouth:
+		at = Point{X: float64(n.left + p.Offset), Y: float64(n.bottom)}
+	case PortWest:
+		at = Point{X: float64(n.left), Y: float64(n.top + p.Offset)}
+	case PortEast:
+		at = Point{X: float64(n.right), Y: float64(n.top + p.Offset)}
+	}
+
+	if !n.IsPort(int(at.X), int(at.Y)) {
+		return Point{}, fmt.Errorf("port %s is not on the %s side of node %s", p.Name, p.Side, n.Id)
+	}
+
+	return at, nil
+}
+
+// allowedPorts returns the ports that an edge may use to leave or enter the
+// node. An empty ref allows every port.
+func (l *Layout) allowedPorts(n *LayoutNode, ref string) (Points, error) {
+	switch {
+	case ref == "":
+		return n.GetPorts(), nil
+	case IsPortSide(ref):
+		return n.GetPortsOn(ref), nil
+	}
+
+	p := l.namedPorts[n.Id].ByName(ref)
+	if p == nil {
+		return nil, fmt.Errorf("node %s does not have a port called %s", n.Id, ref)
+	}
+
+	at, err := n.GetNamedPort(*p)
+	if err != nil {
+		return nil, err
+	}
+
+	return Points{at}, nil
+}
@@ -0,0 +1,102 @@
+package layout
+
+import (
+	"testing"
+
+	"github.com/dnnrly/layli/mocks"
+	"github.com/dnnrly/layli/pathfinder/dijkstra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLayoutNode_GetPortsOn(t *testing.T) {
+	n := NewLayoutNode("id", "contents", 3, 3, 4, 5, "", "")
+
+	assert.Equal(t, Points{{X: 4, Y: 3}, {X: 5, Y: 3}}, n.GetPortsOn(PortNorth))
+	assert.Equal(t, Points{{X: 4, Y: 7}, {X: 5, Y: 7}}, n.GetPortsOn(PortSouth))
+	assert.Equal(t, Points{{X: 3, Y: 4}, {X: 3, Y: 5}, {X: 3, Y: 6}}, n.GetPortsOn(PortWest))
+	assert.Equal(t, Points{{X: 6, Y: 4}, {X: 6, Y: 5}, {X: 6, Y: 6}}, n.GetPortsOn(PortEast))
+	assert.Empty(t, n.GetPortsOn("up"))
+}
+
+func TestLayoutNode_GetNamedPort(t *testing.T) {
+	n := NewLayoutNode("id", "contents", 3, 3, 4, 5, "", "")
+
+	tests := []struct {
+		port ConfigPort
+		want Point
+	}{
+		{port: ConfigPort{Name: "n", Side: PortNorth, Offset: 1}, want: Point{X: 4, Y: 3}},
+		{port: ConfigPort{Name: "s", Side: PortSouth, Offset: 2}, want: Point{X: 5, Y: 7}},
+		{port: ConfigPort{Name: "w", Side: PortWest, Offset: 3}, want: Point{X: 3, Y: 6}},
+		{port: ConfigPort{Name: "e", Side: PortEast, Offset: 1}, want: Point{X: 6, Y: 4}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.port.Name, func(t *testing.T) {
+			got, err := n.GetNamedPort(tt.port)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+
+	t.Run("offset past the corner", func(t *testing.T) {
+		_, err := n.GetNamedPort(ConfigPort{Name: "far", Side: PortNorth, Offset: 3})
+		assert.ErrorContains(t, err, "port far is not on the north side of node id")
+	})
+}
+
+func TestLayout_FindEdgePath_onlyUsesAllowedPorts(t *testing.T) {
+	config := pathTestConfig
+	config.Nodes = ConfigNodes{
+		ConfigNode{Id: "1"},
+		ConfigNode{Id: "2", Ports: ConfigPorts{{Name: "in", Side: PortWest, Offset: 1}}},
+	}
+
+	var fromCentre, toCentre dijkstra.Point
+	leaving := Points{}
+	entering := Points{}
+
+	finder := mocks.NewPathFinder(t)
+	finder.On("AddConnection", mock.Anything, mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		from := args.Get(0).(dijkstra.Point)
+		to := args.Get(2).(dijkstra.Point)
+		if from == fromCentre {
+			leaving = append(leaving, to.(Point))
+		}
+		if to == toCentre {
+			entering = append(entering, from.(Point))
+		}
+	})
+	finder.On("BestPath").Return([]dijkstra.Point{}, nil)
+
+	l, err := NewLayoutFromNodes(func(start, end dijkstra.Point) PathFinder {
+		fromCentre = start
+		toCentre = end
+		return finder
+	}, &config, LayoutNodes{
+		NewLayoutNode("1", "", 3, 3, 3, 3, "", ""),
+		NewLayoutNode("2", "", 10, 3, 3, 3, "", ""),
+	})
+	require.NoError(t, err)
+
+	_, err = l.FindEdgePath(ConfigEdge{From: "1", To: "2", FromPort: PortSouth, ToPort: "in"})
+	require.NoError(t, err)
+
+	assert.Equal(t, Points{{X: 4, Y: 5}}, leaving)
+	assert.Equal(t, Points{{X: 10, Y: 4}}, entering)
+}
+
+func TestLayout_FindEdgePath_unknownPort(t *testing.T) {
+	finder := mocks.NewPathFinder(t)
+	l, err := NewLayoutFromNodes(func(start, end dijkstra.Point) PathFinder {
+		return finder
+	}, &pathTestConfig, LayoutNodes{
+		NewLayoutNode("1", "", 3, 3, 3, 3, "", ""),
+		NewLayoutNode("2", "", 10, 3, 3, 3, "", ""),
+	})
+	require.NoError(t, err)
+
+	_, err = l.FindEdgePath(ConfigEdge{From: "1", To: "2", ToPort: "missing"})
+	assert.ErrorContains(t, err, "node 2 does not have a port called missing")
+}
@@ -0,0 +1,250 @@
+package layout
+
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+// Shapes that a node can be drawn as. Every shape is drawn inside the box of
+// the node so that the ports are still on the grid around it.
+const (
+	ShapeRounded  = "rounded"
+	ShapeRect     = "rect"
+	ShapeEllipse  = "ellipse"
+	ShapeDiamond  = "diamond"
+	ShapeCylinder = "cylinder"
+	ShapeHexagon  = "hexagon"
+	ShapeNote     = "note"
+	ShapePerson   = "person"
+)
+
+// IsShape returns true if s is the name of a node shape
+func IsShape(s string) bool {
+	switch s {
+	case "", ShapeRounded, ShapeRect, ShapeEllipse, ShapeDiamond, ShapeCylinder, ShapeHexagon, ShapeNote, ShapePerson:
+		return true
+	}
+	return false
+}
+
+// box is the outline of a node in pixels
+type box struct {
+	x0, y0, x1, y1 float64
+}
+
+func (n *LayoutNode) box(spacing int) box {
+	return box{
+		x0: float64(n.left * spacing),
+		y0: float64(n.top * spacing),
+		x1: float64((n.left + n.width - 1) * spacing),
+		y1: float64((n.top + n.height - 1) * spacing),
+	}
+}
+
+func (b box) width() float64   { return b.x1 - b.x0 }
+func (b box) height() float64  { return b.y1 - b.y0 }
+func (b box) centreX() float64 { return (b.x0 + b.x1) / 2 }
+func (b box) centreY() float64 { return (b.y0 + b.y1) / 2 }
+
+// capHeight is the height of the ellipse at each end of a cylinder
+func (b box) capHeight(spacing int) float64 {
+	return math.Min(float64(spacing)/2, b.height()/4)
+}
+
+// hexagonPoint is how far the points at the sides of a hexagon stick out
+func (b box) hexagonPoint() float64 {
+	return math.Min(b.height()/2, b.width()/4)
+}
+
+// foldSize is the size of the corner that is folded over on a note. It is
+// never more than half a grid square so that it doesn't reach any ports.
+func (b box) foldSize(spacing int) float64 {
+	return math.Min(float64(spacing)/2, math.Min(b.width(), b.height())/2)
+}
+
+// headRadius is the radius of the head of a person. The body starts below
+// the head and its shoulders are rounded by the same amount.
+func (b box) headRadius() float64 {
+	return math.Min(b.height(), b.width()) / 4
+}
+
+// drawShape draws the outline of the node, passing the attributes to the
+// element that represents the whole node
+func (n *LayoutNode) drawShape(d LayoutDrawer, spacing int, attrs ...string) {
+	b := n.box(spacing)
+	x0, y0, x1, y1 := int(b.x0), int(b.y0), int(b.x1), int(b.y1)
+	w, h := x1-x0, y1-y0
+
+	switch n.Shape {
+	case ShapeRect:
+		d.Rect(x0, y0, w, h, attrs...)
+	case ShapeEllipse:
+		d.Ellipse(x0+w/2, y0+h/2, w/2, h/2, attrs...)
+	case ShapeDiamond:
+		d.Polygon(
+			[]int{x0 + w/2, x1, x0 + w/2, x0},
+			[]int{y0, y0 + h/2, y1, y0 + h/2},
+			attrs...,
+		)
+	case ShapeHexagon:
+		a := int(b.hexagonPoint())
+		d.Polygon(
+			[]int{x0 + a, x1 - a, x1, x1 - a, x0 + a, x0},
+			[]int{y0, y0, y0 + h/2, y1, y1, y0 + h/2},
+			attrs...,
+		)
+	case ShapeCylinder:
+		rx, ry := w/2, int(b.capHeight(spacing))
+		d.Path(fmt.Sprintf(
+			"M %d %d A %d %d 0 0 1 %d %d A %d %d 0 0 1 %d %d L %d %d A %d %d 0 0 0 %d %d L %d %d",
+			x0, y0+ry, rx, ry, x1, y0+ry, rx, ry, x0, y0+ry,
+			x0, y1-ry, rx, ry, x1, y1-ry, x1, y0+ry,
+		), attrs...)
+	case ShapeNote:
+		f := int(b.foldSize(spacing))
+		d.Path(fmt.Sprintf(
+			"M %d %d L %d %d L %d %d L %d %d L %d %d z M %d %d L %d %d L %d %d",
+			x0, y0, x1-f, y0, x1, y0+f, x1, y1, x0, y1,
+			x1-f, y0, x1-f, y0+f, x1, y0+f,
+		), attrs...)
+	case ShapePerson:
+		r := int(b.headRadius())
+		cx, body := x0+w/2, y0+2*r
+		d.Path(fmt.Sprintf(
+			"M %d %d A %d %d 0 1 1 %d %d A %d %d 0 1 1 %d %d z "+
+				"M %d %d L %d %d A %d %d 0 0 1 %d %d L %d %d A %d %d 0 0 1 %d %d L %d %d z",
+			cx-r, y0+r, r, r, cx+r, y0+r, r, r, cx-r, y0+r,
+			x0, y1, x0, body+r, r, r, x0+r, body, x1-r, body, r, r, x1, body+r, x1, y1,
+		), attrs...)
+	default:
+		d.Roundrect(x0, y0, w, h, 3, 3, attrs...)
+	}
+}
+
+// textOffset is how far the contents of the node are moved down from the
+// middle of its box so that they sit inside the shape
+func (n *LayoutNode) textOffset(spacing int) int {
+	if n.Shape == ShapePerson {
+		return int(n.box(spacing).headRadius())
+	}
+	return 0
+}
+
+// outlineDepth is how far inside the box of the node its outline is, in
+// pixels, at a port. Paths are drawn on from the port to meet the outline.
+func (n *LayoutNode) outlineDepth(port Point, spacing int) float64 {
+	b := n.box(spacing)
+	px, py := port.X*float64(spacing), port.Y*float64(spacing)
+	across := int(port.Y) == n.top || int(port.Y) == n.bottom
+
+	// curve is the depth of an ellipse with radius r along the side and
+	// depth d into the box, at distance t from its middle
+	curve := func(r, d, t float64) float64 {
+		return d * (1 - math.Sqrt(math.Max(0, 1-(t/r)*(t/r))))
+	}
+
+	switch n.Shape {
+	case ShapeEllipse:
+		if across {
+			return curve(b.width()/2, b.height()/2, px-b.centreX())
+		}
+		return curve(b.height()/2, b.width()/2, py-b.centreY())
+	case ShapeDiamond:
+		if across {
+			return b.height() * math.Abs(px-b.centreX()) / b.width()
+		}
+		return b.width() * math.Abs(py-b.centreY()) / b.height()
+	case ShapeHexagon:
+		a := b.hexagonPoint()
+		if across {
+			return math.Max(0, a-math.Min(px-b.x0, b.x1-px)) * b.height() / (2 * a)
+		}
+		return a * math.Abs(py-b.centreY()) / (b.height() / 2)
+	case ShapeCylinder:
+		if across {
+			return curve(b.width()/2, b.capHeight(spacing), px-b.centreX())
+		}
+	case ShapePerson:
+		r := b.headRadius()
+		if int(port.Y) == n.top {
+			if math.Abs(px-b.centreX()) < r {
+				return curve(r, r, px-b.centreX())
+			}
+			return 2*r + curve(r, r, math.Max(0, r-math.Min(px-b.x0, b.x1-px)))
+		}
+		if !across {
+			dy := py - b.y0
+			if dy < 2*r {
+				return b.width()/2 - math.Sqrt(math.Max(0, r*r-(dy-r)*(dy-r)))
+			}
+			return curve(r, r, math.Max(0, 3*r-dy))
+		}
+	}
+
+	return 0
+}
+
+// reachOutlines works out where each end of the path meets the outline of
+// its node, for nodes that don't fill the whole of their box
+func (p *LayoutPath) reachOutlines(nodes LayoutNodes, spacing int) {
+	p.reach = [2]*Point{}
+	if len(p.Points) < 4 {
+		return
+	}
+
+	ends := []struct {
+		node string
+		port Point
+	}{
+		{p.From, p.Points[1]},
+		{p.To, p.Points[len(p.Points)-2]},
+	}
+	for i, e := range ends {
+		n := nodes.ByID(e.node)
+		if n == nil {
+			continue
+		}
+		depth := n.outlineDepth(e.port, spacing)
+		if depth < 0.5 {
+			continue
+		}
+
+		// The path leaves the port away from the node, so the outline is
+		// the other way
+		dx, dy := n.inwards(e.port)
+		depth /= float64(spacing)
+		p.reach[i] = &Point{X: e.port.X + dx*depth, Y: e.port.Y + dy*depth}
+	}
+}
+
+// inwards returns the direction from a port in to the node
+func (n *LayoutNode) inwards(port Point) (float64, float64) {
+	switch int(port.Y) {
+	case n.top:
+		return 0, 1
+	case n.bottom:
+		return 0, -1
+	}
+	if int(port.X) == n.left {
+		return 1, 0
+	}
+	return -1, 0
+}
+
+// drawnPath is the data for the path that is drawn, including the parts that
+// reach from the ports to the outlines of the nodes
+func (p *LayoutPath) drawnPath(spacing int) string {
+	path := p.Points.Path(spacing)
+	pixels := func(pt *Point) string {
+		return fmt.Sprintf("%d %d", int(math.Round(pt.X*float64(spacing))), int(math.Round(pt.Y*float64(spacing))))
+	}
+
+	if start := p.reach[0]; start != nil {
+		path = "M " + pixels(start) + " L" + strings.TrimPrefix(path, "M")
+	}
+	if end := p.reach[1]; end != nil {
+		path += " L " + pixels(end)
+	}
+	return path
+}
@@ -0,0 +1,175 @@
+package layout
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/dnnrly/layli/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+func TestLayoutNode_drawShape(t *testing.T) {
+	attrs := []interface{}{`id="n"`}
+
+	tests := []struct {
+		shape  string
+		method string
+		args   []interface{}
+	}{
+		{shape: "", method: "Roundrect", args: []interface{}{60, 60, 80, 40, 3, 3}},
+		{shape: ShapeRounded, method: "Roundrect", args: []interface{}{60, 60, 80, 40, 3, 3}},
+		{shape: ShapeRect, method: "Rect", args: []interface{}{60, 60, 80, 40}},
+		{shape: ShapeEllipse, method: "Ellipse", args: []interface{}{100, 80, 40, 20}},
+		{shape: ShapeDiamond, method: "Polygon", args: []interface{}{[]int{100, 140, 100, 60}, []int{60, 80, 100, 80}}},
+		{shape: ShapeHexagon, method: "Polygon", args: []interface{}{[]int{80, 120, 140, 120, 80, 60}, []int{60, 60, 80, 100, 100, 80}}},
+		{shape: ShapeCylinder, method: "Path", args: []interface{}{"M 60 70 A 40 10 0 0 1 140 70 A 40 10 0 0 1 60 70 L 60 90 A 40 10 0 0 0 140 90 L 140 70"}},
+		{shape: ShapeNote, method: "Path", args: []interface{}{"M 60 60 L 130 60 L 140 70 L 140 100 L 60 100 z M 130 60 L 130 70 L 140 70"}},
+		{shape: ShapePerson, method: "Path", args: []interface{}{"M 90 70 A 10 10 0 1 1 110 70 A 10 10 0 1 1 90 70 z M 60 100 L 60 90 A 10 10 0 0 1 70 80 L 130 80 A 10 10 0 0 1 140 90 L 140 100 z"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.method+" "+tt.shape, func(t *testing.T) {
+			drawer := mocks.NewLayoutDrawer(t)
+			n := NewLayoutNode("n", "", 3, 3, 5, 3, "", "")
+			n.Shape = tt.shape
+
+			drawer.On(tt.method, append(tt.args, attrs...)...).Once()
+
+			n.drawShape(drawer, 20, `id="n"`)
+		})
+	}
+}
+
+func TestLayoutNode_DrawShapeMovesTextInToBody(t *testing.T) {
+	drawer := mocks.NewLayoutDrawer(t)
+	n := NewLayoutNode("n", "Someone", 3, 3, 5, 5, "", "")
+	n.Shape = ShapePerson
+
+	drawer.On("Path", mock.Anything, `id="n"`, "", "",
+		`data-pos-x="3"`, `data-pos-y="3"`, `data-width="5"`, `data-height="5"`,
+		`data-shape="person"`).Once()
+	drawer.On("Textspan", 100, 120, "Someone", `id="n-text"`, "font-size:10px").Once()
+	drawer.On("TextEnd").Once()
+
+	n.Draw(drawer, 20, 0)
+}
+
+func TestLayoutNode_outlineDepth(t *testing.T) {
+	node := func(shape string) *LayoutNode {
+		n := NewLayoutNode("n", "", 3, 3, 7, 5, "", "")
+		n.Shape = shape
+		return &n
+	}
+
+	tests := []struct {
+		name     string
+		shape    string
+		port     Point
+		expected float64
+	}{
+		{name: "rounded", shape: ShapeRounded, port: Point{X: 4, Y: 3}, expected: 0},
+		{name: "diamond point", shape: ShapeDiamond, port: Point{X: 6, Y: 3}, expected: 0},
+		{name: "diamond side", shape: ShapeDiamond, port: Point{X: 4, Y: 3}, expected: 80.0 / 3},
+		{name: "diamond west", shape: ShapeDiamond, port: Point{X: 3, Y: 4}, expected: 30},
+		{name: "ellipse middle", shape: ShapeEllipse, port: Point{X: 9, Y: 5}, expected: 0},
+		{name: "ellipse side", shape: ShapeEllipse, port: Point{X: 6, Y: 6}, expected: 60 - 60*0.8660254037844386},
+		{name: "hexagon flat", shape: ShapeHexagon, port: Point{X: 5, Y: 7}, expected: 0},
+		{name: "hexagon point", shape: ShapeHexagon, port: Point{X: 3, Y: 5}, expected: 0},
+		{name: "hexagon slope", shape: ShapeHexagon, port: Point{X: 4, Y: 3}, expected: 40.0 / 3},
+		{name: "cylinder side", shape: ShapeCylinder, port: Point{X: 9, Y: 5}, expected: 0},
+		{name: "cylinder top", shape: ShapeCylinder, port: Point{X: 6, Y: 3}, expected: 0},
+		{name: "cylinder cap", shape: ShapeCylinder, port: Point{X: 4, Y: 7}, expected: 10 - 10*0.7453559924999299},
+		{name: "note", shape: ShapeNote, port: Point{X: 8, Y: 3}, expected: 0},
+		{name: "person head", shape: ShapePerson, port: Point{X: 6, Y: 3}, expected: 0},
+		{name: "person shoulder", shape: ShapePerson, port: Point{X: 4, Y: 3}, expected: 40},
+		{name: "person beside head", shape: ShapePerson, port: Point{X: 3, Y: 4}, expected: 40},
+		{name: "person body", shape: ShapePerson, port: Point{X: 9, Y: 6}, expected: 0},
+		{name: "person feet", shape: ShapePerson, port: Point{X: 4, Y: 7}, expected: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.InDelta(t, tt.expected, node(tt.shape).outlineDepth(tt.port, 20), 0.001)
+		})
+	}
+}
+
+func TestLayoutPath_drawnPathReachesOutlines(t *testing.T) {
+	from := NewLayoutNode("a", "", 3, 3, 5, 3, "", "")
+	from.Shape = ShapeEllipse
+	to := NewLayoutNode("b", "", 12, 3, 5, 3, "", "")
+	to.Shape = ShapeDiamond
+
+	p := LayoutPath{
+		From: "a",
+		To:   "b",
+		Points: Points{
+			from.GetCentre(),
+			{X: 5, Y: 3}, {X: 5, Y: 2}, {X: 13, Y: 2}, {X: 13, Y: 3},
+			to.GetCentre(),
+		},
+	}
+
+	p.reachOutlines(LayoutNodes{from, to}, 20)
+	assert.Equal(t, "M 100 60 L 100 40 L 260 40 L 260 60 L 260 70", p.drawnPath(20),
+		"the middle of the ellipse touches its box, the side of the diamond doesn't")
+
+	p.reachOutlines(LayoutNodes{}, 20)
+	assert.Equal(t, "M 100 60 L 100 40 L 260 40 L 260 60", p.drawnPath(20))
+}
+
+func TestNewConfigFromFile_shapes(t *testing.T) {
+	config, err := NewConfigFromFile(strings.NewReader(`
+nodes:
+  - id: db
+    shape: cylinder
+`))
+	require.NoError(t, err)
+	assert.Equal(t, ShapeCylinder, config.Nodes[0].Shape)
+
+	_, err = NewConfigFromFile(strings.NewReader(`
+nodes:
+  - id: db
+    shape: star
+`))
+	assert.EqualError(t, err, "invalid shape for node db: star. Valid options: rounded, rect, ellipse, diamond, cylinder, hexagon, note, person")
+}
+
+func TestAbsoluteFromSVG_keepsShapes(t *testing.T) {
+	c := &Config{
+		Nodes: ConfigNodes{
+			{Id: "a", Contents: "A", Shape: ShapeCylinder},
+			{Id: "b", Contents: "B", Shape: ShapeDiamond},
+			{Id: "c", Contents: "C"},
+		},
+		Spacing:    20,
+		NodeWidth:  5,
+		NodeHeight: 3,
+		Border:     1,
+		Margin:     2,
+		Path:       ConfigPath{Attempts: 1},
+	}
+	l, err := NewLayoutFromConfig(nil, c)
+	require.NoError(t, err)
+
+	var svg string
+	d := Diagram{
+		Output: func(output string) error { svg = output; return nil },
+		Config: *c,
+		Layout: l,
+	}
+	require.NoError(t, d.Draw())
+
+	var output string
+	require.NoError(t, AbsoluteFromSVG(svg, func(data string) error { output = data; return nil }))
+
+	config := Config{}
+	require.NoError(t, yaml.NewDecoder(strings.NewReader(output)).Decode(&config))
+	require.Len(t, config.Nodes, 3)
+	assert.Equal(t, ShapeCylinder, config.Nodes[0].Shape)
+	assert.Equal(t, ShapeDiamond, config.Nodes[1].Shape)
+	assert.Equal(t, "", config.Nodes[2].Shape)
+}
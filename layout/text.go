@@ -0,0 +1,150 @@
+package layout
+
+import (
+	"math"
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Node text is drawn using the default sans-serif font, so these metrics are
+// only an approximation of what the browser will actually render.
+const (
+	nodeFontSize    = 10
+	nodeLineHeight  = 12
+	nodeTextPadding = 4
+)
+
+const (
+	// defaultSpacing is the number of pixels between each grid point
+	defaultSpacing = 20
+
+	// minAutoSize is the smallest that an automatically sized node can be,
+	// so that there is always a port on every side
+	minAutoSize = 3
+)
+
+// textLine is a single line of text drawn inside a node. Wrapped lines are
+// continuations of the line before them rather than an explicit line break.
+type textLine struct {
+	text    string
+	wrapped bool
+}
+
+var wordPattern = regexp.MustCompile(`\S+\s*`)
+
+// glyphWidth returns the approximate advance of a character in pixels at the
+// node font size
+func glyphWidth(r rune) float64 {
+	switch {
+	case strings.ContainsRune("il.,:;'|!", r):
+		return 2.8
+	case strings.ContainsRune(" fjrt()[]{}-\"`", r):
+		return 3.3
+	case strings.ContainsRune("mwMW@%", r):
+		return 8.5
+	case unicode.IsUpper(r):
+		return 6.7
+	}
+	return 5.6
+}
+
+// textWidth returns the approximate width of the text in pixels, ignoring
+// any leading or trailing whitespace as it is not rendered
+func textWidth(s string) float64 {
+	w := 0.0
+	for _, r := range strings.TrimSpace(s) {
+		w += glyphWidth(r)
+	}
+	return w
+}
+
+// wrapText breaks the contents in to lines that fit inside the width given.
+// Explicit line breaks are always kept, and long lines are broken between
+// words where possible. Joining the text of a line with the lines wrapped
+// after it gives back the original line.
+func wrapText(contents string, width float64) []textLine {
+	lines := []textLine{}
+	for _, l := range strings.Split(contents, "\n") {
+		for i, w := range wrapLine(l, width) {
+			lines = append(lines, textLine{text: w, wrapped: i > 0})
+		}
+	}
+	return lines
+}
+
+func wrapLine(line string, width float64) []string {
+	words := wordPattern.FindAllStringIndex(line, -1)
+	if len(words) == 0 || width <= 0 {
+		return []string{line}
+	}
+
+	lines := []string{}
+	current := line[:words[0][0]]
+	for _, loc := range words {
+		word := line[loc[0]:loc[1]]
+		if strings.TrimSpace(current) != "" && textWidth(current+word) > width {
+			lines = append(lines, current)
+			current = ""
+		}
+
+		// A word that doesn't fit on a line of its own is split wherever it
+		// has to be
+		for textWidth(current+word) > width {
+			split := splitAt(current, word, width)
+			lines = append(lines, current+word[:split])
+			current, word = "", word[split:]
+		}
+		current += word
+	}
+
+	return append(lines, current)
+}
+
+// splitAt finds how much of the word can follow the prefix without going
+// over the width, always taking at least 1 character so that wrapping ends
+func splitAt(prefix, word string, width float64) int {
+	w := textWidth(prefix)
+	for i, r := range word {
+		w += glyphWidth(r)
+		if w > width {
+			if i == 0 {
+				return utf8.RuneLen(r)
+			}
+			return i
+		}
+	}
+	return len(word)
+}
+
+// textLines returns the lines of the node contents, wrapped to fit the
+// interior of the node
+func (n *LayoutNode) textLines(spacing int) []textLine {
+	interior := float64((n.width-1)*spacing - nodeTextPadding*2)
+	return wrapText(n.Contents, interior)
+}
+
+// autoSize returns the smallest width and height, in grid units, that fit
+// the contents along with some padding. A width or height that has already
+// been set is kept, with the contents wrapped to fit inside it.
+func autoSize(contents string, width, height, spacing int) (int, int) {
+	units := func(pixels float64) int {
+		return max(minAutoSize, int(math.Ceil((pixels+nodeTextPadding*2)/float64(spacing)))+1)
+	}
+
+	if width == 0 {
+		longest := 0.0
+		for _, l := range strings.Split(contents, "\n") {
+			longest = max(longest, textWidth(l))
+		}
+		width = units(longest)
+	}
+
+	if height == 0 {
+		lines := wrapText(contents, float64((width-1)*spacing-nodeTextPadding*2))
+		height = units(float64(len(lines) * nodeLineHeight))
+	}
+
+	return width, height
+}
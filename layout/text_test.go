@@ -0,0 +1,138 @@
+package layout
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTextWidth(t *testing.T) {
+	assert.Equal(t, 0.0, textWidth(""))
+	assert.InDelta(t, 14.5, textWidth("the"), 0.001)
+	assert.InDelta(t, 14.5, textWidth("the   "), 0.001, "trailing spaces are not drawn")
+	assert.Greater(t, textWidth("WWW"), textWidth("iii"))
+}
+
+func TestWrapText(t *testing.T) {
+	tests := []struct {
+		name     string
+		contents string
+		width    float64
+		expected []textLine
+	}{
+		{
+			name:     "fits on one line",
+			contents: "short",
+			width:    100,
+			expected: []textLine{{text: "short"}},
+		},
+		{
+			name:     "keeps line breaks",
+			contents: "one\ntwo",
+			width:    100,
+			expected: []textLine{{text: "one"}, {text: "two"}},
+		},
+		{
+			name:     "wraps between words",
+			contents: "the quick brown fox",
+			width:    40,
+			expected: []textLine{
+				{text: "the "},
+				{text: "quick ", wrapped: true},
+				{text: "brown ", wrapped: true},
+				{text: "fox", wrapped: true},
+			},
+		},
+		{
+			name:     "splits words that are too long",
+			contents: "abcdefghij",
+			width:    20,
+			expected: []textLine{
+				{text: "abc"},
+				{text: "def", wrapped: true},
+				{text: "ghij", wrapped: true},
+			},
+		},
+		{
+			name:     "wraps each line separately",
+			contents: "the quick\nfox",
+			width:    40,
+			expected: []textLine{
+				{text: "the "},
+				{text: "quick", wrapped: true},
+				{text: "fox"},
+			},
+		},
+		{
+			name:     "does not wrap without any room",
+			contents: "the quick brown fox",
+			width:    0,
+			expected: []textLine{{text: "the quick brown fox"}},
+		},
+		{
+			name:     "empty contents",
+			contents: "",
+			width:    40,
+			expected: []textLine{{text: ""}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, wrapText(tt.contents, tt.width))
+		})
+	}
+}
+
+func TestWrapText_canBeJoinedBackTogether(t *testing.T) {
+	for _, contents := range []string{
+		"the quick brown fox jumps over the lazy dog",
+		"  leading and   repeated spaces  ",
+		"a-very-long-hyphenated-identifier that keeps going",
+		"multiple\nlines with some\n\nblank ones",
+	} {
+		lines := wrapText(contents, 30)
+
+		joined := ""
+		for i, l := range lines {
+			if i > 0 && !l.wrapped {
+				joined += "\n"
+			}
+			joined += l.text
+		}
+		assert.Equal(t, contents, joined)
+
+		for _, l := range lines {
+			if len(strings.TrimSpace(l.text)) > 1 {
+				assert.LessOrEqual(t, textWidth(l.text), 30.0, "%q fits", l.text)
+			}
+		}
+	}
+}
+
+func TestAutoSize(t *testing.T) {
+	tests := []struct {
+		name           string
+		contents       string
+		width, height  int
+		expectedWidth  int
+		expectedHeight int
+	}{
+		{name: "never smaller than the minimum", contents: "A", expectedWidth: 3, expectedHeight: 3},
+		{name: "empty contents", contents: "", expectedWidth: 3, expectedHeight: 3},
+		{name: "fits a single line", contents: "Hello world", expectedWidth: 5, expectedHeight: 3},
+		{name: "fits the longest line", contents: "first\nsecond\nthird\nfourth", expectedWidth: 4, expectedHeight: 4},
+		{name: "wraps to a fixed width", contents: "one two three", width: 3, expectedWidth: 3, expectedHeight: 4},
+		{name: "keeps a fixed height", contents: "one two three", height: 9, expectedWidth: 5, expectedHeight: 9},
+		{name: "keeps both when fixed", contents: "one two three", width: 7, height: 5, expectedWidth: 7, expectedHeight: 5},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			width, height := autoSize(tt.contents, tt.width, tt.height, 20)
+			assert.Equal(t, tt.expectedWidth, width, "width")
+			assert.Equal(t, tt.expectedHeight, height, "height")
+		})
+	}
+}
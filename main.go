@@ -7,14 +7,13 @@ import (
 	"strings"
 
 	"github.com/dnnrly/layli/internal/composition"
+	"github.com/dnnrly/layli/internal/domain"
 	"github.com/dnnrly/layli/layout"
 	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
 )
 
 func main() {
-	// The random seeding is now handled by the pkg/random service
-	// which respects the LAYLI_TEST_SEED environment variable
-
 	err := Execute()
 	if err != nil {
 		fmt.Fprintln(os.Stderr, err.Error())
@@ -26,21 +25,50 @@ func main() {
 // This is called by main.main(). It only needs to happen once to the rootCmd.
 func Execute() error {
 	var output string
-	var layoutAlgo string
+	var format string
+	var inputFormat string
 	var showGrid bool
+	var scale float64
+	var pageSize string
+	var sets []string
 
 	var rootCmd = &cobra.Command{
 		Use:   "layli [flags] [layout file]",
 		Short: "Create ASCII diagrams with automatic layout",
-		Long:  `Layli generates SVG diagrams from YAML layout descriptions.`,
+		Long:  `Layli generates SVG, PNG, PDF, text, Graphviz DOT, Mermaid, draw.io and Excalidraw diagrams, or the layout as JSON, from YAML layout descriptions, Graphviz DOT files or Mermaid flowcharts.`,
 		Args:  cobra.MatchAll(cobra.ExactArgs(1), cobra.OnlyValidArgs),
 		RunE: func(cmd *cobra.Command, args []string) error {
+			ext, err := composition.OutputExtension(format)
+			if err != nil {
+				return err
+			}
+
 			if output == "" {
-				output = strings.ReplaceAll(args[0], ".layli", "")
-				output = fmt.Sprintf("%s.svg", output)
+				output = composition.InputBase(args[0])
+				output = fmt.Sprintf("%s%s", output, ext)
+				if output == args[0] {
+					return fmt.Errorf("output would overwrite %s, use --output to choose another file", args[0])
+				}
+			}
+
+			overrides, err := configOverrides(cmd.Flags(), sets)
+			if err != nil {
+				return err
+			}
+
+			app, err := composition.NewGenerateDiagram(composition.Options{
+				ShowGrid:    showGrid,
+				Scale:       scale,
+				PageSize:    pageSize,
+				Format:      format,
+				InputFormat: inputFormat,
+				Overrides:   overrides,
+				Warnings:    cmd.ErrOrStderr(),
+			})
+			if err != nil {
+				return err
 			}
 
-			app := composition.NewGenerateDiagram(showGrid)
 			if err := app.Execute(args[0], output); err != nil {
 				return mapError(err)
 			}
@@ -49,8 +77,23 @@ func Execute() error {
 	}
 
 	rootCmd.PersistentFlags().StringVarP(&output, "output", "o", "", "output file or directory/")
-	rootCmd.PersistentFlags().StringVarP(&layoutAlgo, "layout", "l", "flow-square", "the layout algorithm")
+	rootCmd.PersistentFlags().StringVarP(&format, "format", "f", "", "output format: svg, png, pdf, ascii, dot, mermaid, drawio, excalidraw or json (default chosen from the output file extension)")
+	rootCmd.PersistentFlags().StringVar(&inputFormat, "input-format", "", "input format: layli, dot or mermaid (default chosen from the input file extension)")
 	rootCmd.PersistentFlags().BoolVar(&showGrid, "show-grid", false, "show the path grid dots (great for debugging)")
+	rootCmd.PersistentFlags().Float64Var(&scale, "scale", 1, "how many times bigger to draw PNG images, such as 2 for high resolution screens")
+	rootCmd.PersistentFlags().StringVar(&pageSize, "page-size", "", "fit PDFs on to a4 or letter paper (default is the size of the diagram)")
+	rootCmd.PersistentFlags().StringP("layout", "l", "", "the layout algorithm: flow-square, topo-sort, tarjan, absolute, random-shortest-square or layered (default from the layout file)")
+	rootCmd.PersistentFlags().Int("layout-attempts", 0, "how many arrangements to try (default from the layout file)")
+	rootCmd.PersistentFlags().String("path-strategy", "", "the order that paths are found in: in-order or random (default from the layout file)")
+	rootCmd.PersistentFlags().String("path-algorithm", "", "how paths are found: dijkstra, astar or bidirectional (default from the layout file)")
+	rootCmd.PersistentFlags().String("path-heuristic", "", "the heuristic used by astar: euclidean or manhattan (default from the layout file)")
+	rootCmd.PersistentFlags().Int("path-attempts", 0, "how many orders the random path strategy tries (default from the layout file)")
+	rootCmd.PersistentFlags().Int("margin", 0, "the space around each node in grid points (default from the layout file)")
+	rootCmd.PersistentFlags().Int("border", 0, "the space around the diagram in grid points (default from the layout file)")
+	rootCmd.PersistentFlags().Int("node-width", 0, "the width of nodes that don't set their own (default from the layout file)")
+	rootCmd.PersistentFlags().Int("node-height", 0, "the height of nodes that don't set their own (default from the layout file)")
+	rootCmd.PersistentFlags().Int64("seed", 0, "the seed for random layouts and path orders, to make a diagram again exactly (default from the layout file or chosen at random)")
+	rootCmd.PersistentFlags().StringArrayVar(&sets, "set", nil, "override any config key with key=value, such as path.bend-penalty=100 or styles.#node1=fill: red (can be repeated)")
 
 	rootCmd.AddCommand(
 		&cobra.Command{
@@ -83,6 +126,46 @@ func Execute() error {
 	return rootCmd.Execute()
 }
 
+// configFlags are the flags that override a key in the config that was read
+// from the input
+var configFlags = []struct {
+	flag string
+	key  string
+}{
+	{"layout", "layout"},
+	{"layout-attempts", "layout-attempts"},
+	{"path-strategy", "path.strategy"},
+	{"path-algorithm", "path.algorithm"},
+	{"path-heuristic", "path.heuristic"},
+	{"path-attempts", "path.attempts"},
+	{"margin", "margin"},
+	{"border", "border"},
+	{"node-width", "width"},
+	{"node-height", "height"},
+	{"seed", "seed"},
+}
+
+// configOverrides collects the config set on the command line. Only flags
+// that were used override the input, and --set comes last so that it wins.
+func configOverrides(flags *pflag.FlagSet, sets []string) ([]domain.ConfigOverride, error) {
+	overrides := []domain.ConfigOverride{}
+	for _, f := range configFlags {
+		if flags.Changed(f.flag) {
+			overrides = append(overrides, domain.ConfigOverride{Key: f.key, Value: flags.Lookup(f.flag).Value.String()})
+		}
+	}
+
+	for _, s := range sets {
+		o, err := domain.ParseConfigOverride(s)
+		if err != nil {
+			return nil, err
+		}
+		overrides = append(overrides, o)
+	}
+
+	return overrides, nil
+}
+
 func mapError(err error) error {
 	msg := err.Error()
 	switch {
@@ -21,6 +21,18 @@ func (_m *LayoutDrawer) Circle(x int, y int, r int, s ...string) {
 	_m.Called(_ca...)
 }
 
+// Ellipse provides a mock function with given fields: x, y, w, h, s
+func (_m *LayoutDrawer) Ellipse(x int, y int, w int, h int, s ...string) {
+	_va := make([]interface{}, len(s))
+	for _i := range s {
+		_va[_i] = s[_i]
+	}
+	var _ca []interface{}
+	_ca = append(_ca, x, y, w, h)
+	_ca = append(_ca, _va...)
+	_m.Called(_ca...)
+}
+
 // Path provides a mock function with given fields: d, s
 func (_m *LayoutDrawer) Path(d string, s ...string) {
 	_va := make([]interface{}, len(s))
@@ -33,6 +45,30 @@ func (_m *LayoutDrawer) Path(d string, s ...string) {
 	_m.Called(_ca...)
 }
 
+// Polygon provides a mock function with given fields: x, y, s
+func (_m *LayoutDrawer) Polygon(x []int, y []int, s ...string) {
+	_va := make([]interface{}, len(s))
+	for _i := range s {
+		_va[_i] = s[_i]
+	}
+	var _ca []interface{}
+	_ca = append(_ca, x, y)
+	_ca = append(_ca, _va...)
+	_m.Called(_ca...)
+}
+
+// Rect provides a mock function with given fields: x, y, w, h, s
+func (_m *LayoutDrawer) Rect(x int, y int, w int, h int, s ...string) {
+	_va := make([]interface{}, len(s))
+	for _i := range s {
+		_va[_i] = s[_i]
+	}
+	var _ca []interface{}
+	_ca = append(_ca, x, y, w, h)
+	_ca = append(_ca, _va...)
+	_m.Called(_ca...)
+}
+
 // Roundrect provides a mock function with given fields: x, y, w, h, rx, ry, s
 func (_m *LayoutDrawer) Roundrect(x int, y int, w int, h int, rx int, ry int, s ...string) {
 	_va := make([]interface{}, len(s))
@@ -45,6 +81,18 @@ func (_m *LayoutDrawer) Roundrect(x int, y int, w int, h int, rx int, ry int, s
 	_m.Called(_ca...)
 }
 
+// Span provides a mock function with given fields: t, s
+func (_m *LayoutDrawer) Span(t string, s ...string) {
+	_va := make([]interface{}, len(s))
+	for _i := range s {
+		_va[_i] = s[_i]
+	}
+	var _ca []interface{}
+	_ca = append(_ca, t)
+	_ca = append(_ca, _va...)
+	_m.Called(_ca...)
+}
+
 // TextEnd provides a mock function with given fields:
 func (_m *LayoutDrawer) TextEnd() {
 	_m.Called()
@@ -0,0 +1,150 @@
+package dijkstra
+
+import (
+	"container/heap"
+	"math"
+)
+
+// direction is the way a path is travelling as it arrives at a point. Each
+// component is -1, 0 or 1.
+type direction struct {
+	dx, dy int
+}
+
+func directionOf(from, to Point) direction {
+	fromX, fromY := from.Coordinates()
+	toX, toY := to.Coordinates()
+	return direction{dx: sign(toX - fromX), dy: sign(toY - fromY)}
+}
+
+func sign(v float64) int {
+	switch {
+	case v > 0:
+		return 1
+	case v < 0:
+		return -1
+	default:
+		return 0
+	}
+}
+
+// bendState is a point in the search along with the direction the path was
+// travelling when it got there
+type bendState struct {
+	point    Point
+	incoming direction
+}
+
+type bendQueueItem struct {
+	state bendState
+	cost  int64
+}
+
+type bendQueue []*bendQueueItem
+
+func (pq bendQueue) Len() int           { return len(pq) }
+func (pq bendQueue) Less(i, j int) bool { return pq[i].cost < pq[j].cost }
+func (pq bendQueue) Swap(i, j int)      { pq[i], pq[j] = pq[j], pq[i] }
+
+func (pq *bendQueue) Push(x interface{}) {
+	*pq = append(*pq, x.(*bendQueueItem))
+}
+
+func (pq *bendQueue) Pop() interface{} {
+	old := *pq
+	n := len(old)
+	item := old[n-1]
+	*pq = old[0 : n-1]
+	return item
+}
+
+// BendPathFinder implements Dijkstra's algorithm over (point, incoming
+// direction) states so that every change of direction adds a penalty to the
+// cost of the path. Of 2 paths with the same length, the one with fewer
+// corners is preferred.
+//
+// Turning at the end of the first step or the start of the last step is
+// free. Those steps join a path to the centres of the nodes at either end
+// and aren't drawn.
+type BendPathFinder struct {
+	*PathFinder
+	penalty int64
+}
+
+// NewBendPathFinder creates a new pathfinder that adds penalty to the cost
+// of the path each time it changes direction
+func NewBendPathFinder(start, end Point, penalty int64) *BendPathFinder {
+	return &BendPathFinder{
+		PathFinder: NewPathFinder(start, end),
+		penalty:    penalty,
+	}
+}
+
+// BestPath finds the cheapest path, including the penalty for each bend
+func (pf *BendPathFinder) BestPath() ([]Point, error) {
+	distance := make(map[bendState]int64)
+	previous := make(map[bendState]bendState)
+	pq := make(bendQueue, 0)
+
+	start := bendState{point: pf.start}
+	distance[start] = 0
+	heap.Push(&pq, &bendQueueItem{state: start, cost: 0})
+
+	for len(pq) > 0 {
+		item := heap.Pop(&pq).(*bendQueueItem)
+		current := item.state
+
+		if item.cost > distance[current] {
+			continue // Already found a cheaper way to this state
+		}
+
+		if current.point == pf.end {
+			return pf.reconstructPath(previous, current, start), nil
+		}
+
+		destMapping := get(pf.nodes, current.point)
+		if destMapping == nil {
+			continue
+		}
+
+		for _, neighborPoint := range (*destMapping).Keys() {
+			next := bendState{
+				point:    neighborPoint,
+				incoming: directionOf(current.point, neighborPoint),
+			}
+
+			cost := distance[current] + get(destMapping, neighborPoint)
+			if current.incoming != (direction{}) && neighborPoint != pf.end && current.incoming != next.incoming {
+				cost += pf.penalty
+			}
+
+			// Turning at the end of the first step is free
+			if current == start {
+				next.incoming = direction{}
+			}
+
+			known, ok := distance[next]
+			if !ok {
+				known = math.MaxInt64
+			}
+			if cost < known {
+				distance[next] = cost
+				previous[next] = current
+				heap.Push(&pq, &bendQueueItem{state: next, cost: cost})
+			}
+		}
+	}
+
+	return nil, ErrNotFound
+}
+
+func (pf *BendPathFinder) reconstructPath(previous map[bendState]bendState, end, start bendState) []Point {
+	path := []Point{end.point}
+
+	for current := end; current != start; {
+		current = previous[current]
+		path = append([]Point{current.point}, path...)
+	}
+
+	return path
+}
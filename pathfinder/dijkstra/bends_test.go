@@ -0,0 +1,89 @@
+package dijkstra
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// addGrid connects every point in a 4x4 grid to its neighbours
+func addGrid(pf interface {
+	AddConnection(from Point, cost CostFunction, to ...Point)
+}) {
+	for x := 1.0; x <= 4; x++ {
+		for y := 1.0; y <= 4; y++ {
+			if x < 4 {
+				pf.AddConnection(pt(x, y), uniformCost, pt(x+1, y))
+			}
+			if y < 4 {
+				pf.AddConnection(pt(x, y), uniformCost, pt(x, y+1))
+			}
+		}
+	}
+}
+
+func countBends(path []Point) int {
+	bends := 0
+	for i := 2; i < len(path); i++ {
+		if directionOf(path[i-2], path[i-1]) != directionOf(path[i-1], path[i]) {
+			bends++
+		}
+	}
+	return bends
+}
+
+func TestBendPathFinder_PrefersFewerBends(t *testing.T) {
+	pf := NewBendPathFinder(pt(1, 1), pt(4, 4), 10)
+	addGrid(pf)
+
+	path, err := pf.BestPath()
+	require.NoError(t, err)
+	assert.Equal(t, pt(1, 1), path[0])
+	assert.Equal(t, pt(4, 4), path[len(path)-1])
+	assert.Len(t, path, 7, "still takes a shortest path")
+	assert.Equal(t, 1, countBends(path[1:len(path)-1]), "turning at the first and last steps is free")
+}
+
+func TestBendPathFinder_NoPenaltyIsShortestPath(t *testing.T) {
+	pf := NewBendPathFinder(pt(1, 1), pt(4, 4), 0)
+	addGrid(pf)
+
+	path, err := pf.BestPath()
+	require.NoError(t, err)
+	assert.Len(t, path, 7)
+}
+
+func TestBendPathFinder_LongerPathWithFewerBends(t *testing.T) {
+	// The staircase is 4 long with 3 bends, going around the outside is 8
+	// long with 2 bends
+	build := func(penalty int64) *BendPathFinder {
+		pf := NewBendPathFinder(pt(1, 1), pt(3, 3), penalty)
+		pf.AddConnection(pt(1, 1), ManhattanDistance, pt(2, 1))
+		pf.AddConnection(pt(2, 1), ManhattanDistance, pt(2, 2))
+		pf.AddConnection(pt(2, 2), ManhattanDistance, pt(3, 2))
+		pf.AddConnection(pt(3, 2), ManhattanDistance, pt(3, 3))
+
+		pf.AddConnection(pt(1, 1), ManhattanDistance, pt(5, 1))
+		pf.AddConnection(pt(5, 1), ManhattanDistance, pt(5, 3))
+		pf.AddConnection(pt(5, 3), ManhattanDistance, pt(3, 3))
+		return pf
+	}
+
+	path, err := build(1).BestPath()
+	require.NoError(t, err)
+	assert.Equal(t, []Point{pt(1, 1), pt(2, 1), pt(2, 2), pt(3, 2), pt(3, 3)}, path, "a low penalty keeps the short path")
+
+	path, err = build(10).BestPath()
+	require.NoError(t, err)
+	assert.Equal(t, []Point{pt(1, 1), pt(5, 1), pt(5, 3), pt(3, 3)}, path, "a high penalty avoids bends")
+}
+
+func TestBendPathFinder_NoPath(t *testing.T) {
+	pf := NewBendPathFinder(pt(1, 1), pt(3, 3), 10)
+	pf.AddConnection(pt(1, 1), uniformCost, pt(2, 1))
+	pf.AddConnection(pt(3, 3), uniformCost, pt(3, 2))
+
+	_, err := pf.BestPath()
+	assert.ErrorIs(t, err, ErrNotFound)
+}
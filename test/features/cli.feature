@@ -32,3 +32,96 @@ Feature: Validate CLI configuration
         When the app runs with parameters "--output / tmp/fixtures/inputs/2-nodes.layli"
         Then the app exits with an error
         And the app output contains "drawing diagram"
+
+    @Acceptance
+    Scenario: Writes text diagrams for a .txt output
+        When the app runs with parameters "--output tmp/2-nodes.txt tmp/fixtures/inputs/2-nodes.layli"
+        Then the app exits without error
+        And a file "tmp/2-nodes.txt" exists
+
+    @Acceptance
+    Scenario: Errors on an unknown output format
+        When the app runs with parameters "--format bmp tmp/fixtures/inputs/2-nodes.layli"
+        Then the app exits with an error
+        And the app output contains "unknown output format: bmp"
+
+    @Acceptance
+    Scenario: Reads Graphviz DOT files
+        When the app runs with parameters "tmp/fixtures/inputs/graphviz.dot"
+        Then the app exits without error
+        And a file "tmp/fixtures/inputs/graphviz.svg" exists
+        And the number of nodes is 3
+        And the number of paths is 2
+        And in the SVG file, element "db" has attribute "data-shape" with value "rect"
+        And in the SVG file, nodes do not overlap
+
+    @Acceptance
+    Scenario: Errors on an unknown input format
+        When the app runs with parameters "--input-format xml tmp/fixtures/inputs/2-nodes.layli"
+        Then the app exits with an error
+        And the app output contains "unknown input format: xml"
+
+    @Acceptance
+    Scenario: Reads Mermaid flowcharts
+        When the app runs with parameters "tmp/fixtures/inputs/mermaid.mmd"
+        Then the app exits without error
+        And a file "tmp/fixtures/inputs/mermaid.svg" exists
+        And the number of paths is 3
+        And in the SVG file, element "check" has attribute "data-shape" with value "diamond"
+        And in the SVG file, element "edge-3" has style "stroke-dasharray: 5;"
+
+    @Acceptance
+    Scenario: Writes diagrams that can be read back in as DOT and Mermaid
+        When the app runs with parameters "--output tmp/2-nodes.dot tmp/fixtures/inputs/2-nodes.layli"
+        Then the app exits without error
+        When the app runs with parameters "--output tmp/2-nodes.mmd tmp/fixtures/inputs/2-nodes.layli"
+        Then the app exits without error
+        When the app runs with parameters "--output tmp/2-nodes-from-dot.svg tmp/2-nodes.dot"
+        Then the app exits without error
+        And a file "tmp/2-nodes-from-dot.svg" exists
+        And the number of nodes is 2
+        When the app runs with parameters "--output tmp/2-nodes-from-mermaid.svg tmp/2-nodes.mmd"
+        Then the app exits without error
+        And a file "tmp/2-nodes-from-mermaid.svg" exists
+        And the number of nodes is 2
+
+    @Acceptance
+    Scenario: Writes diagrams that can be opened in draw.io
+        When the app runs with parameters "--output tmp/2-nodes.drawio tmp/fixtures/inputs/2-nodes.layli"
+        Then the app exits without error
+        And a file "tmp/2-nodes.drawio" exists
+        And in the SVG file, element "node-node1" has attribute "vertex" with value "1"
+        And in the SVG file, element "edge-edge-1" has attribute "source" with value "node-node1"
+        And in the SVG file, element "edge-edge-1" has attribute "target" with value "node-node2"
+
+    @Acceptance
+    Scenario: Overrides the config from the command line
+        When the app runs with parameters "--layout topo-sort --margin 3 --set path.algorithm=astar --output tmp/2-nodes-overridden.svg tmp/fixtures/inputs/2-nodes.layli"
+        Then the app exits without error
+        And a file "tmp/2-nodes-overridden.svg" exists
+        And the number of nodes is 2
+        And the number of paths is 1
+        And in the SVG file, nodes do not overlap
+
+    @Acceptance
+    Scenario: Errors on an unknown config key
+        When the app runs with parameters "--set colour=red tmp/fixtures/inputs/2-nodes.layli"
+        Then the app exits with an error
+        And the app output contains "unknown config key: colour"
+
+    @Acceptance
+    Scenario: Errors on an unknown layout
+        When the app runs with parameters "--layout spiral tmp/fixtures/inputs/2-nodes.layli"
+        Then the app exits with an error
+        And the app output contains "invalid layout: spiral"
+
+    @Acceptance
+    Scenario: Records the seed so that random diagrams can be made again
+        When the app runs with parameters "--layout random-shortest-square --seed 1234 --output tmp/random-seeded.svg tmp/fixtures/inputs/2-nodes.layli"
+        Then the app exits without error
+        And a file "tmp/random-seeded.svg" exists
+        And the SVG file has attribute "data-seed" with value "1234"
+        When the app runs with parameters "--layout random-shortest-square --output tmp/random-unseeded.svg tmp/fixtures/inputs/2-nodes.layli"
+        Then the app exits without error
+        And a file "tmp/random-unseeded.svg" exists
+        And the SVG file has attribute "data-seed" with value "1"
@@ -26,8 +26,8 @@ func TestFeatures(t *testing.T) {
 // nolint: unused
 func InitializeTestSuite(ctx *godog.TestSuiteContext) {
 	ctx.BeforeSuite(func() {
-		// Random seeding is now handled by the pkg/random service
-		// which respects the LAYLI_TEST_SEED environment variable
+		// Random choices are seeded from LAYLI_TEST_SEED, which is set when
+		// the app is run
 	})
 }
 
@@ -68,5 +68,6 @@ func InitializeScenario(ctx *godog.ScenarioContext) {
 	ctx.Step(`^in the SVG file, element "([^"]*)" has class "([^"]*)"$`, tc.inTheSVGFileElementHasClass)
 	ctx.Step(`^in the SVG file, element "([^"]*)" has style "([^"]*)"$`, tc.inTheSVGFileElementHasStyle)
 	ctx.Step(`^in the SVG file, element "([^"]*)" has attribute "([^"]*)" with value "([^"]*)"$`, tc.inTheSVGFileElementHasAttrWithVal)
+	ctx.Step(`^the SVG file has attribute "([^"]*)" with value "([^"]*)"$`, tc.theSVGFileHasAttrWithVal)
 	ctx.Step(`^the layli file contains the following nodes:$`, tc.theLayliFileContainsTheFollowingNodes)
 }
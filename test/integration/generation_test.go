@@ -39,10 +39,11 @@ func TestGenerateDiagram_EndToEnd(t *testing.T) {
 	fixtures := fixtureDir(t)
 
 	// Use the composition root to wire everything together
-	generateDiagram := composition.NewGenerateDiagram(false)
+	generateDiagram, err := composition.NewGenerateDiagram(composition.Options{Scale: 1})
+	require.NoError(t, err)
 
 	// Execute - use a known fixture that exists
-	err := generateDiagram.Execute(filepath.Join(fixtures, "inputs", "hello-world.layli"), outputPath)
+	err = generateDiagram.Execute(filepath.Join(fixtures, "inputs", "hello-world.layli"), outputPath)
 
 	// Assert
 	require.NoError(t, err)
@@ -75,9 +76,10 @@ func TestGenerateDiagram_AllLayouts(t *testing.T) {
 			tmpDir := t.TempDir()
 			outputPath := filepath.Join(tmpDir, "output.svg")
 
-			generateDiagram := composition.NewGenerateDiagram(false)
+			generateDiagram, err := composition.NewGenerateDiagram(composition.Options{Scale: 1})
+			require.NoError(t, err)
 
-			err := generateDiagram.Execute(tc.config, outputPath)
+			err = generateDiagram.Execute(tc.config, outputPath)
 
 			assert.NoError(t, err, "layout %s should succeed", tc.name)
 			
@@ -93,10 +95,11 @@ func TestGenerateDiagram_MultipleNodes(t *testing.T) {
 	outputPath := filepath.Join(tmpDir, "output.svg")
 	fixtures := fixtureDir(t)
 
-	generateDiagram := composition.NewGenerateDiagram(false)
+	generateDiagram, err := composition.NewGenerateDiagram(composition.Options{Scale: 1})
+	require.NoError(t, err)
 
 	// Use a fixture with multiple nodes
-	err := generateDiagram.Execute(filepath.Join(fixtures, "inputs", "2-nodes.layli"), outputPath)
+	err = generateDiagram.Execute(filepath.Join(fixtures, "inputs", "2-nodes.layli"), outputPath)
 
 	require.NoError(t, err)
 
@@ -116,10 +119,11 @@ func TestGenerateDiagram_WithCustomDimensions(t *testing.T) {
 	outputPath := filepath.Join(tmpDir, "output.svg")
 	fixtures := fixtureDir(t)
 
-	generateDiagram := composition.NewGenerateDiagram(false)
+	generateDiagram, err := composition.NewGenerateDiagram(composition.Options{Scale: 1})
+	require.NoError(t, err)
 
 	// Use fixture with specific dimensions
-	err := generateDiagram.Execute(filepath.Join(fixtures, "inputs", "hello-world.layli"), outputPath)
+	err = generateDiagram.Execute(filepath.Join(fixtures, "inputs", "hello-world.layli"), outputPath)
 
 	require.NoError(t, err)
 
@@ -134,6 +138,28 @@ func TestGenerateDiagram_WithCustomDimensions(t *testing.T) {
 
 func TestCompositionRoot(t *testing.T) {
 	// Verify the composition root can wire everything together
-	generateDiagram := composition.NewGenerateDiagram(false)
+	generateDiagram, err := composition.NewGenerateDiagram(composition.Options{Scale: 1})
+	require.NoError(t, err)
 	assert.NotNil(t, generateDiagram)
 }
+
+func TestGenerateDiagram_ASCIIOutput(t *testing.T) {
+	tmpDir := t.TempDir()
+	outputPath := filepath.Join(tmpDir, "output.txt")
+	fixtures := fixtureDir(t)
+
+	generateDiagram, err := composition.NewGenerateDiagram(composition.Options{Scale: 1})
+	require.NoError(t, err)
+
+	err = generateDiagram.Execute(filepath.Join(fixtures, "inputs", "2-nodes.layli"), outputPath)
+	require.NoError(t, err)
+
+	content, err := os.ReadFile(outputPath)
+	require.NoError(t, err)
+
+	text := string(content)
+	assert.NotContains(t, text, "<svg", "a .txt output should not be SVG")
+	assert.Contains(t, text, "First Node")
+	assert.Contains(t, text, "Second Node")
+	assert.Contains(t, text, "┌")
+}
@@ -395,6 +395,13 @@ func (c *testContext) inTheSVGFileElementHasAttrWithVal(id, attr, val string) er
 	return c.err
 }
 
+func (c *testContext) theSVGFileHasAttrWithVal(attr, val string) error {
+	root := xmlquery.FindOne(c.svgOutput.doc, "//svg")
+	assert.Equal(c, val, root.SelectAttr(attr))
+
+	return c.err
+}
+
 func (c *testContext) theLayliFileContainsTheFollowingNodes(table *godog.Table) error {
 	table.Rows = table.Rows[1:]
 	for _, row := range table.Rows {